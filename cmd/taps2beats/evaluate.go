@@ -0,0 +1,412 @@
+// +build !js !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/evaluate"
+	"github.com/transcriptaze/taps2beats/taps2beats/tapsio"
+)
+
+// An estimated beats file and the matching reference beats file.
+type pair struct {
+	beats     string
+	reference string
+}
+
+// The evaluation of a single pair of files, for the JSON output.
+type scored struct {
+	File       string              `json:"file"`
+	Reference  string              `json:"reference"`
+	Evaluation evaluate.Evaluation `json:"evaluation"`
+}
+
+// The mean of each metric over all the evaluated pairs and the proportion of pairs with the correct tempo.
+type summary struct {
+	Files     int     `json:"files"`
+	FMeasure  float64 `json:"fmeasure"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	Cemgil    float64 `json:"cemgil"`
+	PScore    float64 `json:"pscore"`
+	CMLc      float64 `json:"CMLc"`
+	CMLt      float64 `json:"CMLt"`
+	AMLc      float64 `json:"AMLc"`
+	AMLt      float64 `json:"AMLt"`
+	InfoGain  float64 `json:"infogain"`
+	Accuracy1 float64 `json:"accuracy1"`
+	Accuracy2 float64 `json:"accuracy2"`
+}
+
+// Implements the 'evaluate' command, which scores estimated beats against reference (ground truth) beats,
+// either for a single pair of files or for every pair of files with the same name in two directories.
+func evaluateBeats(args []string) {
+	evaluator := evaluate.NewEvaluator()
+	settings := struct {
+		outfile string
+		json    bool
+		verbose bool
+		help    bool
+	}{
+		outfile: "",
+		json:    false,
+		verbose: false,
+		help:    false,
+	}
+
+	flagset := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	flagset.DurationVar(&evaluator.Window, "window", evaluator.Window, "F-measure tolerance window (e.g. 70ms)")
+	flagset.DurationVar(&evaluator.Sigma, "sigma", evaluator.Sigma, "standard deviation of the Cemgil accuracy error function (e.g. 40ms)")
+	flagset.DurationVar(&evaluator.Skip, "skip", evaluator.Skip, "ignores beats before this time (e.g. 5s, as for MIREX)")
+	flagset.Float64Var(&evaluator.Tolerance, "tolerance", evaluator.Tolerance, "tempo accuracy tolerance, as a fraction of the reference tempo")
+	flagset.StringVar(&settings.outfile, "out", settings.outfile, "output file path")
+	flagset.BoolVar(&settings.json, "json", settings.json, "writes the evaluation as JSON")
+	flagset.BoolVar(&settings.verbose, "verbose", settings.verbose, "enables verbose progress messages")
+	flagset.BoolVar(&settings.help, "help", settings.help, "displays the 'help' information")
+	flagset.Parse(args)
+
+	if settings.help {
+		helpEvaluate()
+		os.Exit(0)
+	}
+
+	if len(flagset.Args()) < 2 {
+		fmt.Printf("\n  ** ERROR: missing beats or reference file\n\n")
+		os.Exit(1)
+	}
+
+	if evaluator.Window <= 0 || evaluator.Sigma <= 0 || evaluator.Tolerance <= 0 {
+		fmt.Printf("\n  ** ERROR: invalid window, sigma or tolerance (%v, %v, %v)\n\n", evaluator.Window, evaluator.Sigma, evaluator.Tolerance)
+		os.Exit(1)
+	}
+
+	list, err := pairs(flagset.Args()[0], flagset.Args()[1], settings.verbose)
+	if err != nil {
+		fmt.Printf("\n  ** ERROR: %v\n\n", err)
+		os.Exit(1)
+	}
+
+	scores := []scored{}
+	for _, p := range list {
+		beats, err := readBeatList(p.beats)
+		if err != nil {
+			fmt.Printf("\n  ** ERROR: unable to read beats from %s (%v)\n\n", p.beats, err)
+			os.Exit(1)
+		}
+
+		reference, err := readBeatList(p.reference)
+		if err != nil {
+			fmt.Printf("\n  ** ERROR: unable to read reference beats from %s (%v)\n\n", p.reference, err)
+			os.Exit(1)
+		}
+
+		times := []time.Duration{}
+		for _, b := range reference.Beats {
+			times = append(times, b.At)
+		}
+
+		if settings.verbose {
+			fmt.Fprintf(os.Stderr, "  ... evaluating %v beats from %s against %v beats from %s\n", len(beats.Beats), p.beats, len(times), p.reference)
+		}
+
+		scores = append(scores, scored{
+			File:       p.beats,
+			Reference:  p.reference,
+			Evaluation: evaluator.Evaluate(beats, times),
+		})
+	}
+
+	var b bytes.Buffer
+	if settings.json {
+		err = writeEvaluationJSON(&b, scores)
+	} else {
+		err = writeEvaluation(&b, scores)
+	}
+
+	if err != nil {
+		fmt.Printf("\n  ** ERROR: unable to format evaluation (%v)\n\n", err)
+		os.Exit(1)
+	}
+
+	if settings.outfile == "" {
+		fmt.Print(b.String())
+	} else if err := ioutil.WriteFile(settings.outfile, b.Bytes(), 0644); err != nil {
+		fmt.Printf("\n  ** ERROR: unable to write evaluation to %s (%v)\n\n", settings.outfile, err)
+		os.Exit(1)
+	} else if settings.verbose {
+		fmt.Fprintf(os.Stderr, "  ... evaluation written to %s\n", settings.outfile)
+	}
+}
+
+// Returns the pairs of files to evaluate i.e. the two files or, if both are directories, the files in the
+// beats directory with a file with the same name (ignoring the extension) in the reference directory.
+func pairs(beats, reference string, verbose bool) ([]pair, error) {
+	b, err := os.Stat(beats)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := os.Stat(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !b.IsDir() && !r.IsDir():
+		return []pair{{beats: beats, reference: reference}}, nil
+
+	case !b.IsDir() || !r.IsDir():
+		return nil, fmt.Errorf("beats and reference must both be files or both be directories")
+	}
+
+	stems := func(dir string) (map[string]string, []string, error) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		m := map[string]string{}
+		list := []string{}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+
+			stem := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+			if _, ok := m[stem]; !ok {
+				m[stem] = filepath.Join(dir, f.Name())
+				list = append(list, stem)
+			}
+		}
+
+		sort.Strings(list)
+
+		return m, list, nil
+	}
+
+	estimated, list, err := stems(beats)
+	if err != nil {
+		return nil, err
+	}
+
+	references, _, err := stems(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []pair{}
+	for _, stem := range list {
+		if ref, ok := references[stem]; ok {
+			matched = append(matched, pair{beats: estimated[stem], reference: ref})
+		} else if verbose {
+			fmt.Fprintf(os.Stderr, "  ... no reference beats for %s\n", estimated[stem])
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no matching beats and reference files in %s and %s", beats, reference)
+	}
+
+	return matched, nil
+}
+
+// Reads a list of beats from a beats JSON file (as written by --json), a JAMS file (the first 'beat'
+// annotation) or a MIREX style .beats file (the first column of each line).
+func readBeatList(file string) (taps2beats.Beats, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return taps2beats.Beats{}, err
+	}
+
+	var reader tapsio.Reader = tapsio.MIREX{}
+	switch trimmed := bytes.TrimSpace(b); {
+	case strings.EqualFold(filepath.Ext(file), ".jams") || (bytes.HasPrefix(trimmed, []byte("{")) && bytes.Contains(b, []byte(`"annotations"`))):
+		reader = tapsio.JAMS{}
+
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		beats := taps2beats.Beats{}
+		if err := json.Unmarshal(b, &beats); err != nil {
+			return taps2beats.Beats{}, err
+		}
+
+		return beats, nil
+	}
+
+	set, err := reader.Read(bytes.NewReader(b))
+	if err != nil {
+		return taps2beats.Beats{}, err
+	}
+
+	beats := taps2beats.Beats{
+		Beats: []taps2beats.Beat{},
+	}
+
+	if len(set.Loops) > 0 {
+		for _, t := range set.Loops[0].Taps {
+			beats.Beats = append(beats.Beats, taps2beats.Beat{At: t.At, Mean: t.At})
+		}
+	}
+
+	return beats, nil
+}
+
+// Writes the evaluation of each pair of files as a table, with the mean over all the pairs if there is more
+// than one pair.
+func writeEvaluation(w io.Writer, scores []scored) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "  FILE\tBEATS\tF-MEASURE\tCEMGIL\tP-SCORE\tCMLc\tCMLt\tAMLc\tAMLt\tINFOGAIN\tBPM\tOCTAVE\n")
+	for _, s := range scores {
+		e := s.Evaluation
+		octave := "-"
+		if e.Tempo.Octave != "" {
+			octave = "x" + e.Tempo.Octave
+		}
+
+		fmt.Fprintf(tw, "  %v\t%v/%v\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.1f/%.1f\t%v\n",
+			s.File,
+			e.Beats,
+			e.Reference,
+			e.FMeasure,
+			e.Cemgil,
+			e.PScore,
+			e.CMLc,
+			e.CMLt,
+			e.AMLc,
+			e.AMLt,
+			e.InfoGain,
+			e.Tempo.Estimated,
+			e.Tempo.Reference,
+			octave)
+	}
+
+	if len(scores) > 1 {
+		m := mean(scores)
+
+		fmt.Fprintf(tw, "  MEAN (%v files)\t\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t\t%.0f%%/%.0f%%\n",
+			m.Files,
+			m.FMeasure,
+			m.Cemgil,
+			m.PScore,
+			m.CMLc,
+			m.CMLt,
+			m.AMLc,
+			m.AMLt,
+			m.InfoGain,
+			100*m.Accuracy1,
+			100*m.Accuracy2)
+	}
+
+	fmt.Fprintln(tw)
+
+	return tw.Flush()
+}
+
+// Writes the evaluation of each pair of files and the mean over all the pairs as JSON.
+func writeEvaluationJSON(w io.Writer, scores []scored) error {
+	doc := struct {
+		Evaluations []scored `json:"evaluations"`
+		Mean        summary  `json:"mean"`
+	}{
+		Evaluations: scores,
+		Mean:        mean(scores),
+	}
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", b)
+
+	return err
+}
+
+func mean(scores []scored) summary {
+	m := summary{
+		Files: len(scores),
+	}
+
+	if len(scores) == 0 {
+		return m
+	}
+
+	for _, s := range scores {
+		e := s.Evaluation
+		m.FMeasure += e.FMeasure
+		m.Precision += e.Precision
+		m.Recall += e.Recall
+		m.Cemgil += e.Cemgil
+		m.PScore += e.PScore
+		m.CMLc += e.CMLc
+		m.CMLt += e.CMLt
+		m.AMLc += e.AMLc
+		m.AMLt += e.AMLt
+		m.InfoGain += e.InfoGain
+
+		if e.Tempo.Accuracy1 {
+			m.Accuracy1++
+		}
+
+		if e.Tempo.Accuracy2 {
+			m.Accuracy2++
+		}
+	}
+
+	N := float64(len(scores))
+	m.FMeasure /= N
+	m.Precision /= N
+	m.Recall /= N
+	m.Cemgil /= N
+	m.PScore /= N
+	m.CMLc /= N
+	m.CMLt /= N
+	m.AMLc /= N
+	m.AMLt /= N
+	m.InfoGain /= N
+	m.Accuracy1 /= N
+	m.Accuracy2 /= N
+
+	return m
+}
+
+func helpEvaluate() {
+	fmt.Println()
+	fmt.Printf("  taps2beats %s\n", VERSION)
+	fmt.Println()
+	fmt.Println("  taps2beats evaluate scores estimated beats against reference (ground truth) beats using the standard")
+	fmt.Println("  beat tracking metrics i.e. F-measure, Cemgil accuracy, P-score, CMLc/CMLt/AMLc/AMLt continuity,")
+	fmt.Println("  information gain (in bits) and tempo accuracy (with octave errors i.e. x2, x1/2, x3 or x1/3). The")
+	fmt.Println("  beats and reference files can be beats JSON files (as written by taps2beats --json), JAMS files or")
+	fmt.Println("  MIREX style .beats files. If both are directories, every file in the beats directory is evaluated")
+	fmt.Println("  against the file with the same name (ignoring the extension) in the reference directory and the")
+	fmt.Println("  mean of each metric is included.")
+	fmt.Println()
+	fmt.Println("  Usage: taps2beats evaluate [--window <time>] [--sigma <time>] [--skip <time>] [--tolerance <fraction>] [--json] [--out <file>] [--verbose] <beats> <reference>")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --window <time>         F-measure tolerance window (defaults to 70ms)")
+	fmt.Println("    --sigma <time>          standard deviation of the Cemgil accuracy error function (defaults to 40ms)")
+	fmt.Println("    --skip <time>           ignores beats before this time e.g. 5s for the MIREX evaluation (defaults to 0s)")
+	fmt.Println("    --tolerance <fraction>  tempo accuracy tolerance, as a fraction of the reference tempo (defaults to 0.04)")
+	fmt.Println("    --json                  writes the evaluation as JSON")
+	fmt.Println("    --out <file>            output file path (defaults to stdout)")
+	fmt.Println("    --verbose               enables verbose progress messages (on stderr)")
+	fmt.Println("    --help                  displays this information")
+	fmt.Println()
+}
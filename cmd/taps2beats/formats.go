@@ -0,0 +1,181 @@
+// +build !js !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/midi"
+	"github.com/transcriptaze/taps2beats/taps2beats/reaper"
+	"github.com/transcriptaze/taps2beats/taps2beats/rekordbox"
+	"github.com/transcriptaze/taps2beats/taps2beats/tapsio"
+	"github.com/transcriptaze/taps2beats/taps2beats/video"
+)
+
+// Registers the command line formats with the format registry i.e. replaces the built-in formats with formats
+// configured from the command line options. Must be invoked after the command line has been parsed.
+func register() {
+	json := tapsio.JSON{
+		Tool:      "taps2beats " + VERSION,
+		Options:   map[string]string{},
+		Precision: options.precision,
+		TimeBase:  options.timebase.timebase,
+		Indent:    " ",
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		json.Options[f.Name] = f.Value.String()
+	})
+
+	// ... the input file and checksum are only known once the input has been read
+	provenance := tapsio.WriterFunc(func(w io.Writer, beats taps2beats.Beats) error {
+		format := json
+		format.Input = source.file
+		format.Checksum = source.checksum
+
+		return format.Write(w, beats)
+	})
+
+	csv := tapsio.CSV{
+		Delimiter:   ',',
+		Columns:     options.columns,
+		Taps:        options.csv == "taps",
+		BeatsPerBar: options.beatsPerBar,
+		Downbeat:    options.downbeat - 1,
+	}
+
+	tsv := csv
+	tsv.Delimiter = '\t'
+
+	mid := tapsio.MIDI{
+		Import: midi.Import{
+			Channel:    options.channel - 1,
+			Notes:      options.notes,
+			Markers:    options.loop == 0,
+			LoopLength: options.loop,
+		},
+		Export: midi.NewExport(),
+	}
+
+	mid.Export.PPQ = options.ppq
+	mid.Export.BeatsPerBar = options.beatsPerBar
+	mid.Export.Clicks = options.clicks
+	mid.Export.Downbeat = options.downbeat - 1
+
+	markers := reaper.NewExport()
+	markers.BeatsPerBar = options.beatsPerBar
+	markers.Downbeat = options.downbeat - 1
+	markers.Name = options.markerName
+	markers.Regions = options.regions
+
+	if options.markers == "beats" {
+		markers.Markers = reaper.Beats
+	}
+
+	rb := rekordbox.NewExport()
+	rb.BeatsPerBar = options.beatsPerBar
+	rb.Downbeat = options.downbeat - 1
+	rb.Collection = options.collection
+	rb.Track = options.track
+	rb.Audio = options.audio
+	rb.Version = VERSION
+
+	switch options.tempoMap {
+	case "constant":
+		mid.Export.TempoMap = midi.Constant
+		markers.TempoMap = reaper.Constant
+		rb.TempoMap = rekordbox.Constant
+
+	case "per-beat":
+		mid.Export.TempoMap = midi.PerBeat
+		markers.TempoMap = reaper.PerBeat
+		rb.TempoMap = rekordbox.PerBeat
+	}
+
+	rate, _ := taps2beats.ParseFrameRate(options.fps, options.dropFrame)
+	markersVideo := tapsio.Video{
+		Export: video.NewExport(),
+		Warn: func(m video.Marker) {
+			fmt.Fprintf(os.Stderr, "  ** WARN: %s at %v is before the start of the timeline - moved to frame %v (%s)\n", m.Name, m.At, m.Frame, rate.Timecode(m.Frame))
+		},
+	}
+
+	markersVideo.Export.Rate = rate
+	markersVideo.Export.BeatsPerBar = options.beatsPerBar
+	markersVideo.Export.Downbeat = options.downbeat - 1
+	markersVideo.Export.EveryBeat = options.markers == "beats"
+	markersVideo.Export.Name = options.markerName
+
+	if options.verbose {
+		markersVideo.Shift = func(m video.Marker) {
+			fmt.Fprintf(os.Stderr, "  ... %s at %v rounded to frame %v (%s, %v)\n", m.Name, m.At, m.Frame, rate.Timecode(m.Frame), m.Shift)
+		}
+	}
+
+	fcpxml, edl, ffmetadata := markersVideo, markersVideo, markersVideo
+	fcpxml.Format = tapsio.FCPXML
+	edl.Format = tapsio.EDL
+	ffmetadata.Format = tapsio.FFMetadata
+
+	formats := []tapsio.Format{
+		{Name: "json", Reader: tapsio.JSON{}, Writer: provenance},
+		{Name: "audacity", Reader: tapsio.Audacity{LoopLabel: options.loopLabel}, Writer: tapsio.Audacity{Labels: options.labels, BeatsPerBar: options.beatsPerBar, Downbeat: options.downbeat - 1}},
+		{Name: "txt", Reader: tapsio.TXT{LoopLabel: options.loopLabel, Warn: warn}, Writer: tapsio.TXT{TimeBase: options.timebase.timebase}},
+		{Name: "csv", Reader: csv, Writer: csv},
+		{Name: "tsv", Reader: tsv, Writer: tsv},
+		{Name: "svl", Reader: tapsio.SVL{}, Writer: tapsio.SVL{Labels: options.labels, BeatsPerBar: options.beatsPerBar, Downbeat: options.downbeat - 1, TempoLayer: options.tempoLayer}},
+		{Name: "jams", Reader: tapsio.JAMS{}, Writer: tapsio.JAMS{Version: VERSION, BeatsPerBar: options.beatsPerBar, Downbeat: options.downbeat - 1}},
+		{Name: "beats", Reader: tapsio.MIREX{}, Writer: tapsio.MIREX{BeatsPerBar: options.beatsPerBar, Downbeat: options.downbeat - 1}},
+		{Name: "midi", Reader: mid, Writer: mid},
+		{Name: "reaper", Writer: tapsio.Reaper{Export: markers}},
+		{Name: "rpp", Writer: tapsio.Reaper{Export: markers, TempoEnvelope: true}},
+		{Name: "rekordbox", Writer: rb},
+		{Name: "fcpxml", Writer: fcpxml},
+		{Name: "edl", Writer: edl},
+		{Name: "ffmetadata", Writer: ffmetadata},
+	}
+
+	for _, f := range formats {
+		if format, err := tapsio.Lookup(f.Name); err == nil {
+			format.Reader = f.Reader
+			format.Writer = f.Writer
+
+			tapsio.Register(format)
+		}
+	}
+
+	if options.format != "" {
+		tapsio.Register(tapsio.Format{
+			Name:   "template",
+			Writer: formatTemplate(),
+		})
+	}
+}
+
+// Reports a warning for a value in an input file.
+func warn(d tapsio.Diagnostic) {
+	fmt.Fprintf(os.Stderr, "  ** WARN: %v\n", d)
+}
+
+// Returns the --format template i.e. the contents of the file if --format is the path of an existing file,
+// otherwise the --format text.
+func formatTemplate() tapsio.Template {
+	text := options.format
+	if info, err := os.Stat(options.format); err == nil && !info.IsDir() {
+		if b, err := ioutil.ReadFile(options.format); err == nil {
+			text = string(b)
+		}
+	}
+
+	return tapsio.Template{
+		Text:        text,
+		BeatsPerBar: options.beatsPerBar,
+		Downbeat:    options.downbeat - 1,
+		TimeBase:    options.timebase.timebase,
+	}
+}
@@ -0,0 +1,184 @@
+// +build !js !wasm
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+type keypress struct {
+	at  time.Duration
+	key byte
+}
+
+// Records the 'taps' from the keyboard, with the terminal in raw mode so that every keypress is
+// timestamped (using the monotonic clock) as soon as it is received. Each keypress is a 'tap',
+// <Enter> starts a loop (and resets the clock) and <q>, Ctrl-C or Ctrl-D end the session. Every loop
+// (including the first) is timed from the <Enter> that starts it, so keypresses before the first
+// <Enter> are ignored.
+//
+// A running BPM estimate is displayed once it has been calculated (in the background, so as not to
+// delay the keypresses) at the end of every loop.
+func record() (int, [][]float64, error) {
+	restore, err := raw()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	defer restore()
+
+	fmt.Printf("\r\n  Tap any key in time to the music. <Enter> starts a loop, <q> ends the session\r\n\r\n")
+	fmt.Printf("  <Enter> to start loop 1 ")
+
+	start := time.Now()
+	keys := make(chan keypress, 256)
+	estimates := make(chan string, 16)
+
+	go func() {
+		buffer := make([]byte, 16)
+		for {
+			N, err := os.Stdin.Read(buffer)
+			if err != nil {
+				close(keys)
+				return
+			}
+
+			// ... a single read may hold more than one keypress
+			at := time.Since(start)
+			for _, b := range buffer[:N] {
+				keys <- keypress{at: at, key: b}
+			}
+		}
+	}()
+
+	data := [][]float64{}
+	var loop []float64
+	offset := time.Duration(0)
+
+	for {
+		select {
+		case estimate := <-estimates:
+			if loop != nil {
+				fmt.Printf("\r\n%s\r\n  loop %d: %s", estimate, len(data)+1, strings.Repeat(".", len(loop)))
+			}
+
+		case k, ok := <-keys:
+			if !ok {
+				if len(loop) > 0 {
+					data = append(data, loop)
+				}
+
+				return count(data), data, nil
+			}
+
+			switch {
+			case k.key == 'q', k.key == 0x03, k.key == 0x04:
+				if len(loop) > 0 {
+					data = append(data, loop)
+				}
+
+				fmt.Printf("\r\n")
+
+				return count(data), data, nil
+
+			case k.key == '\r', k.key == '\n':
+				if len(loop) > 0 {
+					data = append(data, loop)
+
+					go func(data [][]float64) {
+						estimates <- progress(data)
+					}(append([][]float64{}, data...))
+				}
+
+				if loop == nil || len(loop) > 0 {
+					fmt.Printf("\r\n")
+				}
+
+				loop = []float64{}
+				offset = k.at
+
+				fmt.Printf("  loop %d: ", len(data)+1)
+
+			case loop == nil:
+				// ... no loop started yet
+
+			default:
+				loop = append(loop, (k.at - offset).Seconds())
+				fmt.Printf(".")
+			}
+		}
+	}
+}
+
+// Saves the recorded 'taps' as JSON (if the file has a .json extension) or TXT.
+func save(file string, data [][]float64) error {
+	var b bytes.Buffer
+
+	if strings.HasSuffix(strings.ToLower(file), ".json") {
+		if err := formatTapsJSON(data, &b); err != nil {
+			return err
+		}
+	} else {
+		if err := formatTapsTXT(data, &b); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(file, b.Bytes(), 0644)
+}
+
+// Re-estimates the beats from all the loops recorded so far and returns a summary
+// of the running BPM estimate.
+func progress(data [][]float64) string {
+	beats := taps2beats.Taps2Beats(taps2beats.NewTapSetFromFloats(data), options.forgetting)
+
+	if beats.BPM == 0 {
+		return fmt.Sprintf("  ... %v loops, %v beats, insufficient data for BPM", len(data), len(beats.Beats))
+	}
+
+	return fmt.Sprintf("  ... %v loops, %v beats, BPM %v", len(data), len(beats.Beats), beats.BPM)
+}
+
+// Puts the terminal into raw mode (using stty) and returns a function to restore the
+// previous terminal state.
+func raw() (func(), error) {
+	get := exec.Command("stty", "-g")
+	get.Stdin = os.Stdin
+
+	state, err := get.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get terminal state (%v)", err)
+	}
+
+	set := exec.Command("stty", "raw", "-echo")
+	set.Stdin = os.Stdin
+
+	if err := set.Run(); err != nil {
+		return nil, fmt.Errorf("unable to set terminal to raw mode (%v)", err)
+	}
+
+	restore := func() {
+		cmd := exec.Command("stty", strings.TrimSpace(string(state)))
+		cmd.Stdin = os.Stdin
+		cmd.Run()
+	}
+
+	return restore, nil
+}
+
+func count(data [][]float64) int {
+	N := 0
+	for _, row := range data {
+		N += len(row)
+	}
+
+	return N
+}
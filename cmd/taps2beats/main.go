@@ -2,58 +2,191 @@
 
 // Command line utility for the taps2beats module.
 //
-//   Usage:
+//	Usage:
 //
-//   taps2beats [--verbose] [--out <file>] [--interval <interval>] [--quantize] [--forgetting <factor>] [--precision <time>] [--latency <time>] [--shift] <file>
+//	taps2beats [--verbose] [--interactive] [--out <file>] [--from <format>] [--to <format>] [--interval <interval>] [--quantize] [--bpm <BPM>] [--forgetting <factor>] [--precision <time>] [--timebase <units>] [--latency <time>] [--shift] [--ppq <ticks>] [--beats-per-bar <N>] [--clicks] [--channel <N>] [--notes <list>] [--loop <time>] [--audacity] [--labels <beats|bars>] [--loop-label <label>] [--tempo-layer] [--columns <mapping>] [--csv <beats|taps>] [--agreement] [--format <template>] [--tempo-map <map>] [--reaper] [--markers <bars|beats>] [--marker-name <template>] [--regions] [--downbeat <N>] [--rekordbox] [--collection <file>] [--track <track>] [--fps <rate>] [--drop-frame] <file>...
 //
+//	<file>                 The 'taps' file(s), each with optional per-file settings i.e.
+//	                       <file>:latency=<time>,weight=<weight>,forgetting=<factor>. Multiple
+//	                       files are combined into a single clustering run and the fit statistics
+//	                       for each file are printed to stderr. A trailing ':' (e.g. take:weight=2:)
+//	                       reads a file whose name ends in what looks like settings.
 //
-//   --verbose              Displays operational information
+//	--verbose              Displays operational information
 //
-//   --out <file>           Writes the estimated beats to the supplied file
+//	--out <file>           Writes the estimated beats to the supplied file. A file ending in .mid
+//	                       is written as a Standard MIDI File with a tempo map, time signature and
+//	                       bar markers, a file ending in .svl is written as a Sonic Visualiser
+//	                       time instants layer, a file ending in .jams is written as a JAMS file
+//	                       with 'beat' and 'tempo' annotations and files ending in .beats and .bpm
+//	                       are written as MIREX style beats and tempo files. Files ending in .csv
+//	                       and .tsv are written as CSV and TSV tables (as for --csv) and a file ending
+//	                       in .rpp is written as a Reaper project fragment with the tempo envelope.
+//	                       Files ending in .fcpxml, .edl and .ffmetadata are written as Final Cut Pro
+//	                       XML markers, CMX3600 EDL locators and FFmpeg chapters (one per bar).
 //
-//   --interval <interval>  Extrapolates (and interpolates) the beats to extend over
-//                          the supplied interval. The interval should be specified as
-//                          <start>:<end> where <start> and <end> are in Go time format
-//                          e.g. --interval 0.3s:1m10.3s.
-//                          A '*' interval (--interval '*') will interpolate the beats
-//                          over the interval from the earliest to the latest 'tap'.
+//	--from <format>        Reads the input file in the specified format (txt, json, audacity, csv, tsv,
+//	                       svl, jams, beats, midi or wav) rather than the format identified by the file
+//	                       extension or (e.g. for stdin) the file content. Defaults to txt if the format
+//	                       cannot be identified.
 //
-//   --quantize             Adjusts the estimated beats so that they fit to the estimated BPM
+//	--to <format>          Writes the output in the specified format (txt, json, audacity, csv, tsv,
+//	                       svl, jams, beats, bpm, midi, reaper, rpp, rekordbox, fcpxml, edl or ffmetadata)
+//	                       rather than the format for the --out file extension. --json, --audacity,
+//	                       --csv, --reaper and --rekordbox are aliases for --to json, audacity, csv,
+//	                       reaper and rekordbox and the last of --to and its aliases takes precedence.
+//	                       Defaults to txt.
 //
-//   --forgetting <factor>  Discounts earlier taps from earlier loops as being less accurate
-//                          than later loops due to the listener learning the music. e.g. a
-//                          factor of 0.1 discounts each loop by 10% over the subsequent one.
-//                          A negative factor inverts the weighting i.e. earlier loops are
-//                          weighted as more accurate than later loops.
+//	--interval <interval>  Extrapolates (and interpolates) the beats to extend over
+//	                       the supplied interval. The interval should be specified as
+//	                       <start>:<end> where <start> and <end> are in Go time format
+//	                       e.g. --interval 0.3s:1m10.3s.
+//	                       A '*' interval (--interval '*') will interpolate the beats
+//	                       over the interval from the earliest to the latest 'tap'.
 //
-//   --precision <time>     Rounds the beats and all times to the specified precision (in Go
-//                          time format) e.g. --precision 1ms will round all times to the
-//                          nearest millisecond. The default precision is 1ms.
+//	--quantize             Adjusts the estimated beats so that they fit to the estimated BPM
 //
-//   --latency <time>       Adjusts all times to compensate for the latency between the
-//                          actual beat and the detected 'tap' e.g. --latency 73ms
+//	--bpm <BPM>            Constrains the BPM used to quantize and interpolate the beats. A single
+//	                       value (e.g. --bpm 120) fixes the tempo so that only the offset of the
+//	                       beats is estimated, while a range (e.g. --bpm 110:130) limits the BPM
+//	                       to the range. Either end of the range may be omitted (e.g. --bpm 100:).
+//	                       The BPM can also be read from a MIREX style .bpm file (e.g. --bpm song.bpm).
 //
-//   --clean                Discards outlier taps i.e. taps that are assigned to beats with too few taps.
+//	--forgetting <factor>  Discounts earlier taps from earlier loops as being less accurate
+//	                       than later loops due to the listener learning the music. e.g. a
+//	                       factor of 0.1 discounts each loop by 10% over the subsequent one.
+//	                       A negative factor inverts the weighting i.e. earlier loops are
+//	                       weighted as more accurate than later loops.
 //
-//   --shift                Adjusts all beats (and times) so that the first beat in the
-//                          interval falls on 0s.
+//	--precision <time>     Rounds the beats and all times to the specified precision (in Go
+//	                       time format) e.g. --precision 1ms will round all times to the
+//	                       nearest millisecond. The default precision is 1ms.
 //
-//   --json                 Formats the output as prettified JSON, with all the times converted
-//                          to seconds (to a precision of 1ms)
+//	--timebase <units>     Converts all the times in the TXT and JSON output to the time base units:
+//	                       - duration: Go durations e.g. 4.524s (the default for TXT output)
+//	                       - seconds: seconds e.g. 4.524 (the default for JSON output)
+//	                       - samples[:<rate>]: audio samples at the sample rate (default 44100)
+//	                       - frames[:<fps>]: video frames at the frame rate (default 25)
+//	                       - smpte[:<fps>[df]]: hh:mm:ss:ff timecode (hh:mm:ss;ff for drop-frame)
+//	                       - ticks[:<ppq>[@<bpm>]]: MIDI ticks at the PPQ and tempo (default 480@120)
+//	                       Times are rounded to the nearest sample, frame or tick (halves away from
+//	                       zero) and SMPTE variances are a number of frames.
+//
+//	--latency <time>       Adjusts all times to compensate for the latency between the
+//	                       actual beat and the detected 'tap' e.g. --latency 73ms
+//
+//	--clean                Discards outlier taps i.e. taps that are assigned to beats with too few taps.
+//
+//	--shift                Adjusts all beats (and times) so that the first beat in the
+//	                       interval falls on 0s.
+//
+//	--json                 Formats the output as a prettified, versioned JSON document with the
+//	                       tool version, the command line options, a checksum of the input file,
+//	                       the fit statistics and the index and source (tapped or interpolated) of
+//	                       each beat. All times are converted to seconds, to the number of decimal
+//	                       places required for the --precision (3 for the default 1ms).
+//
+//	--audacity             Formats the output as an Audacity label track, with a point label at every
+//	                       beat, for importing over the waveform in Audacity.
+//
+//	--labels <beats|bars>  Labels the beats in an Audacity label track with the beat number (beats)
+//	                       or bar:beat (bars). Defaults to beats.
+//
+//	--loop-label <label>   Starts a new loop at every label matching <label> in an Audacity label file,
+//	                       with the 'taps' following the loop label relative to the loop label. Each
+//	                       label track in an Audacity label file is always a separate loop.
+//
+//	--tempo-layer          Adds a time values layer with the instantaneous BPM at each beat to a
+//	                       Sonic Visualiser (.svl) output file.
+//
+//	--columns <mapping>    Maps the loop, time and weight columns in a CSV (or TSV) input file by
+//	                       header name or column number e.g. --columns loop=take,time=onset,weight=5
+//
+//	--csv <beats|taps>     Formats the output as a CSV table of the beats or as a long-form table of
+//	                       the 'taps' (with the loop, assigned beat and residual of each 'tap').
+//
+//	--agreement            Prints the inter-tapper agreement statistics (to stderr), treating each
+//	                       tapper (the loop tapper, the input file for a multi-file session or
+//	                       otherwise each loop) as a rater:
+//	                       - the agreement coefficient i.e. the proportion of beats (over all pairs
+//	                         of tappers) that both tappers tapped within 70ms of each other
+//	                       - the mean and standard deviation of each tapper's asynchrony relative to
+//	                         the consensus beats and the number of beats tapped and missed
+//	                       - the spread (standard deviation) of the tappers for each beat, with the
+//	                         beats tapped by only some of the tappers flagged as 'disputed'
+//
+//	--format <template>    Formats the output with a Go text/template (or the template in a file, if
+//	                       <template> is the path of a file) executed against the beats i.e.:
+//	                       - .BPM, .Offset, .Count and .Statistics (the fit statistics)
+//	                       - .Beats, each with .Index, .Number, .Bar, .Beat, .At, .Mean, .Variance,
+//	                         .Taps, .Tapped, .First and .Last
+//	                       with the helper functions seconds, ms, samples <t> <rate>, timecode <t> <fps>,
+//	                       time (in the --timebase units), barbeat <index>, add, sub and join <list> <sep>
+//	                       e.g. --format '[{{range .Beats}}{{ms .At}}{{if not .Last}},{{end}}{{end}}]'
+//
+//	--tempo-map <map>      Tempo map for MIDI, Reaper and rekordbox output i.e. constant, per-beat or auto (constant
+//	                       if the beats are evenly spaced). Defaults to auto.
+//
+//	--reaper               Formats the output as a Reaper region/marker CSV file.
+//
+//	--markers <bars|beats> Places the Reaper (and video) markers on every bar or every beat. Defaults to bars.
+//
+//	--marker-name <name>   Template for the Reaper and video marker names, with {bar}, {beat} and {n} replaced by
+//	                       the bar, beat in the bar and marker number e.g. 'Bar {bar}'.
+//
+//	--regions              Adds a region for every bar to the Reaper region/marker CSV file.
+//
+//	--downbeat <N>         Beat number of the first downbeat for bar numbering and MIDI, Reaper and rekordbox output
+//	                       (defaults to 1).
+//
+//	--rekordbox            Formats the output as a rekordbox collection XML file, with the beat grid as
+//	                       TEMPO entries.
+//
+//	--collection <file>    Existing rekordbox collection XML file from which to take the track location
+//	                       and metadata.
+//
+//	--track <track>        TrackID or name of the track in the --collection file.
+//
+//	--fps <rate>           Frame rate for video marker output
+//	                       e.g. 25, 29.97 or 30000/1001. Defaults to 25.
+//
+//	--drop-frame           Uses drop-frame timecode for 29.97 and 59.94 fps video marker output.
+//
+//	--ppq <ticks>          Ticks per quarter note for MIDI output (defaults to 480)
+//
+//	--beats-per-bar <N>    Beats per bar for the MIDI time signature and bar markers and for bar:beat
+//	                       labels (defaults to 4)
+//
+//	--clicks               Adds a track with a note for every beat to the MIDI output
+//
+//	--channel <N>          MIDI channel (1-16) of the 'taps' in a MIDI input file (defaults to all channels)
+//
+//	--notes <list>         Comma separated list of the MIDI notes of the 'taps' in a MIDI input file
+//	                       e.g. --notes 36,38 (defaults to all notes)
+//
+//	--loop <time>          Splits the 'taps' in a MIDI input file into loops of the specified length
+//	                       (in Go time format) e.g. --loop 8s. The 'taps' in a MIDI file are split into
+//	                       loops at the marker events if a loop length is not specified.
+//
+//	--interactive          Records the 'taps' directly from the keyboard. Each keypress is a 'tap',
+//	                       <Enter> starts a loop (including the first) and <q> (or Ctrl-C) ends the
+//	                       session. The 'taps' are saved to <file> (if specified) in TXT or JSON format
+//	                       (if the file ends with .json).
 package main
 
 import (
 	"bytes"
 	"flag"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/tapsio"
 )
 
 const VERSION = "v0.1.0"
@@ -64,114 +197,338 @@ type interval struct {
 	end   *time.Duration
 }
 
+type tempo taps2beats.Tempo
+
+type notes []int
+
+type columns map[string]string
+
+type timebase struct {
+	timebase *taps2beats.TimeBase
+}
+
+// A boolean flag that is an alias for --to <format> e.g. --json for --to json.
+type alias struct {
+	format string
+	set    bool
+}
+
+// The --csv table i.e. 'beats' or 'taps' (--csv is an alias for --to csv).
+type table string
+
 var options = struct {
-	outfile    string
-	interval   interval
-	quantize   bool
-	forgetting float64
-	precision  time.Duration
-	latency    time.Duration
-	clean      bool
-	shift      bool
-	json       bool
-	verbose    bool
-	help       bool
+	outfile     string
+	from        string
+	to          string
+	interval    interval
+	quantize    bool
+	tempo       tempo
+	forgetting  float64
+	precision   time.Duration
+	timebase    timebase
+	latency     time.Duration
+	audio       string
+	snap        time.Duration
+	clean       bool
+	shift       bool
+	json        alias
+	audacity    alias
+	labels      string
+	loopLabel   string
+	tempoLayer  bool
+	ppq         int
+	beatsPerBar int
+	clicks      bool
+	tempoMap    string
+	reaper      alias
+	markers     string
+	markerName  string
+	regions     bool
+	downbeat    int
+	rekordbox   alias
+	collection  string
+	track       string
+	fps         string
+	dropFrame   bool
+	channel     int
+	notes       notes
+	loop        time.Duration
+	columns     columns
+	csv         table
+	agreement   bool
+	format      string
+	interactive bool
+	verbose     bool
+	help        bool
 }{
-	outfile:    "",
-	interval:   interval{},
-	quantize:   false,
-	forgetting: 0.0,
-	precision:  1 * time.Millisecond,
-	latency:    0 * time.Millisecond,
-	clean:      false,
-	shift:      false,
-	json:       false,
-	verbose:    false,
-	help:       false,
+	outfile:     "",
+	from:        "",
+	to:          "",
+	interval:    interval{},
+	quantize:    false,
+	tempo:       tempo{},
+	forgetting:  0.0,
+	precision:   1 * time.Millisecond,
+	timebase:    timebase{},
+	latency:     0 * time.Millisecond,
+	audio:       "",
+	snap:        0 * time.Millisecond,
+	clean:       false,
+	shift:       false,
+	json:        alias{format: "json"},
+	audacity:    alias{format: "audacity"},
+	labels:      "beats",
+	loopLabel:   "",
+	tempoLayer:  false,
+	ppq:         480,
+	beatsPerBar: 4,
+	clicks:      false,
+	tempoMap:    "auto",
+	reaper:      alias{format: "reaper"},
+	markers:     "bars",
+	markerName:  "",
+	regions:     false,
+	downbeat:    1,
+	rekordbox:   alias{format: "rekordbox"},
+	collection:  "",
+	track:       "",
+	fps:         "25",
+	dropFrame:   false,
+	channel:     0,
+	notes:       notes{},
+	loop:        0,
+	columns:     columns{},
+	csv:         "",
+	agreement:   false,
+	format:      "",
+	interactive: false,
+	verbose:     false,
+	help:        false,
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		render(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "evaluate" {
+		evaluateBeats(os.Args[2:])
+		return
+	}
+
 	flag.StringVar(&options.outfile, "out", options.outfile, "output file path")
 	flag.Var(&options.interval, "interval", "start and end times (in seconds) for which to return beats (e.g. 0.8s:10.0s)")
 	flag.BoolVar(&options.quantize, "quantize", options.quantize, "adjusts the tapped beats to fit a least squares fitted BPM")
+	flag.Var(&options.tempo, "bpm", "fixed BPM (e.g. 120) or BPM range (e.g. 110:130) for quantizing and interpolating beats")
 	flag.Float64Var(&options.forgetting, "forgetting", options.forgetting, "'forgetting factor' for discounting older taps")
 	flag.DurationVar(&options.precision, "precision", options.precision, "time precision for returned 'beats', in Go 'time' format (e.g. 1ms)")
+	flag.Var(&options.timebase, "timebase", "time base for TXT and JSON output (e.g. seconds, samples:48000, frames:25, smpte:29.97df or ticks:480@120)")
 	flag.DurationVar(&options.latency, "latency", options.latency, "delay for which to compensate, in Go 'time' format (e.g. 70ms)")
+	flag.StringVar(&options.audio, "audio", options.audio, "WAV file of the source audio, for snapping the beats to the audio onsets")
+	flag.DurationVar(&options.snap, "snap", options.snap, "window within which to snap the beats to the audio onsets, in Go 'time' format (e.g. 30ms)")
 	flag.BoolVar(&options.clean, "clean", options.clean, "discards outlier taps i.e. taps assigned to beats with too few taps")
 	flag.BoolVar(&options.shift, "shift", options.shift, "shifts all times so that the first beat is on 0")
-	flag.BoolVar(&options.json, "json", options.json, "Sets the output format to prettified JSON")
+	flag.Var(&options.json, "json", "Sets the output format to prettified JSON (alias for --to json)")
+	flag.Var(&options.audacity, "audacity", "Sets the output format to an Audacity label track (alias for --to audacity)")
+	flag.StringVar(&options.labels, "labels", options.labels, "Audacity labels for the beats ('beats' or 'bars')")
+	flag.StringVar(&options.loopLabel, "loop-label", options.loopLabel, "Audacity label that starts a new loop in an Audacity label file")
+	flag.BoolVar(&options.tempoLayer, "tempo-layer", options.tempoLayer, "adds a time values layer with the BPM at each beat to Sonic Visualiser output")
+	flag.IntVar(&options.ppq, "ppq", options.ppq, "ticks per quarter note for MIDI output")
+	flag.IntVar(&options.beatsPerBar, "beats-per-bar", options.beatsPerBar, "beats per bar for MIDI output and bar:beat labels")
+	flag.BoolVar(&options.clicks, "clicks", options.clicks, "adds a note for every beat to MIDI output")
+	flag.StringVar(&options.tempoMap, "tempo-map", options.tempoMap, "tempo map for MIDI, Reaper and rekordbox output ('auto', 'constant' or 'per-beat')")
+	flag.Var(&options.reaper, "reaper", "Sets the output format to a Reaper region/marker CSV file (alias for --to reaper)")
+	flag.StringVar(&options.markers, "markers", options.markers, "Reaper and video markers on 'bars' or 'beats'")
+	flag.StringVar(&options.markerName, "marker-name", options.markerName, "Reaper and video marker name template e.g. 'Bar {bar}' or '{bar}.{beat}'")
+	flag.BoolVar(&options.regions, "regions", options.regions, "adds a region for every bar to Reaper region/marker output")
+	flag.IntVar(&options.downbeat, "downbeat", options.downbeat, "beat number of the first downbeat for bar numbering and MIDI, Reaper and rekordbox output")
+	flag.Var(&options.rekordbox, "rekordbox", "Sets the output format to a rekordbox collection XML file (alias for --to rekordbox)")
+	flag.StringVar(&options.collection, "collection", options.collection, "rekordbox collection XML file with the track location and metadata")
+	flag.StringVar(&options.track, "track", options.track, "TrackID or name of the track in the rekordbox collection")
+	flag.StringVar(&options.fps, "fps", options.fps, "frame rate for video marker output (e.g. 25, 29.97 or 30000/1001)")
+	flag.BoolVar(&options.dropFrame, "drop-frame", options.dropFrame, "uses drop-frame timecode for 29.97 and 59.94 fps video marker output")
+	flag.IntVar(&options.channel, "channel", options.channel, "MIDI channel (1-16) of the 'taps' in a MIDI file (0 for all channels)")
+	flag.Var(&options.notes, "notes", "comma separated list of the MIDI notes of the 'taps' in a MIDI file (e.g. 36,38)")
+	flag.DurationVar(&options.loop, "loop", options.loop, "loop length for splitting the 'taps' in a MIDI file into loops, in Go 'time' format (e.g. 8s)")
+	flag.Var(&options.columns, "columns", "column mapping for a CSV input file (e.g. loop=take,time=onset,weight=velocity)")
+	flag.StringVar(&options.from, "from", options.from, "input file format (e.g. txt, json, csv or midi)")
+	flag.StringVar(&options.to, "to", options.to, "output file format (e.g. txt, json, csv or midi)")
+	flag.Var(&options.csv, "csv", "Sets the output format to a CSV table of the 'beats' or 'taps' (alias for --to csv)")
+	flag.BoolVar(&options.agreement, "agreement", options.agreement, "prints the inter-tapper agreement statistics (to stderr)")
+	flag.StringVar(&options.format, "format", options.format, "Go text/template (or template file) for the output e.g. '{{range .Beats}}{{ms .At}}\\n{{end}}'")
+	flag.BoolVar(&options.interactive, "interactive", options.interactive, "records the 'taps' directly from the keyboard")
 	flag.BoolVar(&options.verbose, "verbose", options.verbose, "enables verbose progress messages")
 	flag.BoolVar(&options.help, "help", options.help, "displays the 'help' information")
 	flag.Parse()
 
+	register()
+
 	if options.help {
 		help()
 		os.Exit(0)
 	}
 
+	if options.labels != "beats" && options.labels != "bars" {
+		fmt.Printf("\n  ** ERROR: invalid --labels option (%v)\n\n", options.labels)
+		os.Exit(1)
+	}
+
+	if options.tempoMap != "auto" && options.tempoMap != "constant" && options.tempoMap != "per-beat" {
+		fmt.Printf("\n  ** ERROR: invalid --tempo-map option (%v)\n\n", options.tempoMap)
+		os.Exit(1)
+	}
+
+	if options.downbeat < 1 {
+		fmt.Printf("\n  ** ERROR: invalid --downbeat option (%v)\n\n", options.downbeat)
+		os.Exit(1)
+	}
+
+	if options.markers != "bars" && options.markers != "beats" {
+		fmt.Printf("\n  ** ERROR: invalid --markers option (%v)\n\n", options.markers)
+		os.Exit(1)
+	}
+
+	if options.channel < 0 || options.channel > 16 {
+		fmt.Printf("\n  ** ERROR: invalid --channel option (%v)\n\n", options.channel)
+		os.Exit(1)
+	}
+
+	if _, err := taps2beats.ParseFrameRate(options.fps, options.dropFrame); err != nil {
+		fmt.Printf("\n  ** ERROR: invalid --fps option (%v)\n\n", err)
+		os.Exit(1)
+	}
+
+	// ... --csv for a .tsv output file writes a TSV table
+	if options.to == "csv" && options.csv != "" && strings.HasSuffix(strings.ToLower(options.outfile), ".tsv") {
+		options.to = "tsv"
+	}
+
+	if options.from != "" {
+		if format, err := tapsio.Lookup(options.from); err != nil || format.Reader == nil {
+			fmt.Printf("\n  ** ERROR: invalid --from option (%v)\n\n", options.from)
+			os.Exit(1)
+		}
+	}
+
+	if options.format != "" {
+		if _, err := formatTemplate().Parse(); err != nil {
+			fmt.Printf("\n  ** ERROR: invalid --format option (%v)\n\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if options.to != "" {
+		if format, err := tapsio.Lookup(options.to); err != nil || format.Writer == nil {
+			fmt.Printf("\n  ** ERROR: invalid --to option (%v)\n\n", options.to)
+			os.Exit(1)
+		}
+	}
+
 	if options.verbose {
 		fmt.Printf("\n  taps2beats %s\n\n", VERSION)
 	}
 
 	var file string
-	var r io.Reader
-	var parser = parseTXT
-	if len(flag.Args()) == 0 {
-		file = "<stdin>"
-		r = os.Stdin
-	} else {
-		file = flag.Args()[0]
-		f, err := os.Open(file)
+	var inputs []input
+	var onsets bool
+	var set taps2beats.TapSet
+
+	if options.interactive {
+		if len(flag.Args()) > 0 {
+			file = flag.Args()[0]
+		}
+
+		n, data, err := record()
 		if err != nil {
-			fmt.Printf("\n  ** ERROR: unable to open file %s (%v)\n\n", file, err)
+			fmt.Printf("\n  ** ERROR: unable to record taps (%v)\n\n", err)
+			os.Exit(1)
+		} else if n == 0 {
+			fmt.Printf("\n  ** ERROR: no data \n\n")
 			os.Exit(1)
 		}
 
-		defer f.Close()
+		if file != "" {
+			if err := save(file, data); err != nil {
+				fmt.Printf("\n  ** ERROR: unable to save taps to %s (%v)\n\n", file, err)
+				os.Exit(1)
+			}
 
-		r = f
+			if options.verbose {
+				fmt.Printf("  ... %v values saved to %s\n", n, file)
+			}
+		}
+
+		set = taps2beats.NewTapSetFromFloats(data)
+	} else {
+		inputs = []input{{file: "<stdin>", weight: 1.0}}
+		if len(flag.Args()) > 0 {
+			inputs = []input{}
+			for _, arg := range flag.Args() {
+				in, err := parseInput(arg)
+				if err != nil {
+					fmt.Printf("\n  ** ERROR: %v\n\n", err)
+					os.Exit(1)
+				}
 
-		if strings.HasSuffix(strings.ToLower(file), ".json") {
-			parser = parseJSON
+				inputs = append(inputs, in)
+			}
 		}
-	}
 
-	if options.verbose {
-		fmt.Printf("  ... reading data from %s\n", file)
-	}
+		list, taps, n, err := readSession(inputs)
+		if err != nil {
+			fmt.Printf("\n  ** ERROR: %v\n\n", err)
+			os.Exit(1)
+		}
 
-	N, data, err := read(r, parser)
-	if err != nil {
-		fmt.Printf("\n  ** ERROR: unable to read data from %s (%v)\n\n", file, err)
-		os.Exit(1)
-	} else if N == 0 {
-		fmt.Printf("\n  ** ERROR: no data \n\n")
-		os.Exit(1)
-	}
+		if n == 0 {
+			fmt.Printf("\n  ** ERROR: no data \n\n")
+			os.Exit(1)
+		}
 
-	if options.verbose {
-		fmt.Printf("  ... %v values read from %s\n", N, file)
+		inputs = list
+		onsets = len(inputs) == 1 && inputs[0].format.Onsets
+		set = taps
 	}
 
-	if options.verbose {
+	forgetting := options.forgetting
+	if discounted(inputs) {
+		forgetting = 0.0
+	} else if options.verbose {
 		fmt.Printf("  ... using forgetting factor %0.1f\n", options.forgetting)
 	}
 
-	beats := taps2beats.Taps2Beats(taps2beats.Floats2Seconds(data), options.forgetting)
+	var beats taps2beats.Beats
+	if onsets && len(set.Loops) == 1 {
+		beats = taps2beats.Onsets2Beats(set)
+	} else {
+		beats = taps2beats.Taps2Beats(set, forgetting)
+	}
+
+	tempo := taps2beats.Tempo(options.tempo)
 
 	// ... sanity check
-	if len(beats.Beats) <= 1 || (beats.Variance != nil && *beats.Variance > 0.1) {
+	if len(beats.Beats) == 0 || (len(beats.Beats) == 1 && !tempo.IsFixed()) || (beats.Variance != nil && *beats.Variance > 0.1) {
 		fmt.Printf("\n  ** ERROR: insufficient data\n\n")
 		os.Exit(1)
 	}
 
+	// ... tempo
+	if tempo.IsConstrained() {
+		if options.verbose {
+			fmt.Printf("  ... constraining BPM to %v\n", tempo)
+		}
+
+		beats.EstimateTempo(tempo)
+	}
+
 	// ... clean
 	if options.clean {
 		if options.verbose {
 			fmt.Printf("  ... discarding outlier taps\n")
 		}
 
-		if b, err := beats.Clean(); err != nil {
+		if b, err := beats.CleanWithTempo(tempo); err != nil {
 			fmt.Printf("\n  ** ERROR: unable to clean beats (%v)\n\n", err)
 			os.Exit(1)
 		} else {
@@ -185,14 +542,14 @@ func main() {
 			fmt.Printf("  ... quantizing tapped beats to match estimated BPM\n")
 		}
 
-		if err := beats.Quantize(); err != nil {
+		if err := beats.QuantizeWithTempo(tempo); err != nil {
 			fmt.Printf("\n  ** ERROR: unable to quantize beats (%v)\n\n", err)
 			os.Exit(1)
 		}
 	}
 
 	// ... interpolate
-	if options.interval.set && len(beats.Beats) > 1 {
+	if options.interval.set && (len(beats.Beats) > 1 || tempo.IsFixed()) {
 		var start time.Duration
 		var end time.Duration
 
@@ -212,7 +569,7 @@ func main() {
 			fmt.Printf("  ... interpolating missing beats over interval %v..%v \n", start, end)
 		}
 
-		if err := beats.Interpolate(start, end); err != nil {
+		if err := beats.InterpolateWithTempo(start, end, tempo); err != nil {
 			fmt.Printf("\n  ** ERROR: unable to interpolate beats (%v)\n\n", err)
 			os.Exit(1)
 		}
@@ -222,6 +579,14 @@ func main() {
 		fmt.Printf("  ... %v beats\n", len(beats.Beats))
 	}
 
+	if len(inputs) > 1 {
+		printSession(os.Stderr, inputs, beats)
+	}
+
+	if options.agreement {
+		printAgreement(os.Stderr, set, beats)
+	}
+
 	if options.latency != 0 {
 		if options.verbose {
 			fmt.Printf("  ... compensating for %v latency\n", options.latency)
@@ -230,6 +595,22 @@ func main() {
 		beats.Sub(options.latency)
 	}
 
+	if options.snap > 0 {
+		if options.audio == "" {
+			fmt.Printf("\n  ** ERROR: --snap requires the source audio (--audio <file>)\n\n")
+			os.Exit(1)
+		}
+
+		if options.verbose {
+			fmt.Printf("  ... snapping beats to onsets in %s (window %v)\n", options.audio, options.snap)
+		}
+
+		if err := snap(&beats, options.audio, options.snap); err != nil {
+			fmt.Printf("\n  ** ERROR: unable to snap beats to audio (%v)\n\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if options.shift {
 		if options.verbose {
 			fmt.Printf("  ... shifting beats to start at 0\n")
@@ -248,16 +629,24 @@ func main() {
 	// ... format and print
 	var b bytes.Buffer
 
-	if options.json || strings.HasSuffix(strings.ToLower(options.outfile), ".json") {
-		if err := formatJSON(beats, &b); err != nil {
-			fmt.Printf("\n  ** ERROR: unable to format output as JSON (%v)\n\n", err)
-			os.Exit(1)
-		}
-	} else {
-		if err := formatTXT(beats, &b); err != nil {
-			fmt.Printf("\n  ** ERROR: unable to format output (%v)\n\n", err)
-			os.Exit(1)
-		}
+	to := options.to
+	if to == "" && options.format != "" {
+		to = "template"
+	}
+
+	format, err := tapsio.WriterFor(to, options.outfile)
+	if err != nil && to == "" {
+		format, err = tapsio.Lookup("txt")
+	}
+
+	if err != nil {
+		fmt.Printf("\n  ** ERROR: unable to format output (%v)\n\n", err)
+		os.Exit(1)
+	}
+
+	if err := format.Writer.Write(&b, beats); err != nil {
+		fmt.Printf("\n  ** ERROR: unable to format output as %v (%v)\n\n", format.Name, err)
+		os.Exit(1)
 	}
 
 	if options.outfile == "" {
@@ -288,12 +677,28 @@ func help() {
 	fmt.Println("  contain the same number of values, the values do not have to be in time order, nor are they")
 	fmt.Println("  required to have the same precision.")
 	fmt.Println()
-	fmt.Println("  Usage: taps2beats [--interval <interval>] [--quantize] [--forgetting <factor>] [--latency <delay>] [--precision <time>] [--shift] [--out <file>] [--json] [--verbose] <file>")
+	fmt.Println("  Usage: taps2beats [--interactive] [--interval <interval>] [--quantize] [--bpm <BPM>] [--forgetting <factor>] [--latency <delay>] [--precision <time>] [--timebase <units>] [--shift] [--out <file>] [--from <format>] [--to <format>] [--json] [--ppq <ticks>] [--beats-per-bar <N>] [--clicks] [--channel <N>] [--notes <list>] [--loop <time>] [--audacity] [--labels <beats|bars>] [--loop-label <label>] [--tempo-layer] [--columns <mapping>] [--csv <beats|taps>] [--agreement] [--format <template>] [--tempo-map <map>] [--reaper] [--markers <bars|beats>] [--marker-name <template>] [--regions] [--downbeat <N>] [--rekordbox] [--collection <file>] [--track <track>] [--fps <rate>] [--drop-frame] [--verbose] <file>...")
+	fmt.Println()
+	fmt.Println("         taps2beats render [options] <beats file>  (taps2beats render --help for details)")
+	fmt.Println("         taps2beats evaluate [options] <beats> <reference>  (taps2beats evaluate --help for details)")
 	fmt.Println()
 	fmt.Println("  Arguments:")
 	fmt.Println()
 	fmt.Println("    file  Path to file containing the whitespace delimited taps to be clustered into beats. Reads")
-	fmt.Println("          from <stdin> if the file is not specified.")
+	fmt.Println("          from <stdin> if the file is not specified. A .wav file is processed with a spectral flux")
+	fmt.Println("          onset detector and each detected onset is used as a beat. The note-on events in a .mid file")
+	fmt.Println("          are used as the 'taps', split into loops at the markers (or --loop). An Audacity label file")
+	fmt.Println("          is detected automatically and the start of each label is used as a 'tap', with each label")
+	fmt.Println("          track as a loop. The time instants layers in a Sonic Visualiser .svl file and the 'beat'")
+	fmt.Println("          annotations in a JAMS file are used as loops and a MIREX style .beats file is used as the beats.")
+	fmt.Println("          A .csv (or .tsv) file is read as a table of loop, time and (optional) weight columns and a")
+	fmt.Println("          version 2 .json file can include the id, tapper, start, end, latency, weight and labels of each")
+	fmt.Println("          loop and the weight (or confidence) and label of each 'tap'.")
+	fmt.Println()
+	fmt.Println("          Multiple files are combined into a single session, with optional per-file settings i.e.")
+	fmt.Println("          <file>:latency=<time>,weight=<weight>,forgetting=<factor> (e.g. alice.txt:latency=70ms,weight=0.5).")
+	fmt.Println("          The fit statistics for each file are printed to stderr. A trailing ':' (e.g. take:weight=2:) reads")
+	fmt.Println("          a file whose name ends in what looks like settings.")
 	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
@@ -309,6 +714,11 @@ func help() {
 	fmt.Println("                           clustered taps for that beat. --quantize adjusts the estimated beats so that")
 	fmt.Println("                           they fit a straight line i.e. constant BPM")
 	fmt.Println()
+	fmt.Println("    --bpm <BPM>            constrains the BPM used to quantize and interpolate the beats. A single value")
+	fmt.Println("                           (e.g. --bpm 120) fixes the tempo so that only the offset of the beats is")
+	fmt.Println("                           estimated, while a range (e.g. --bpm 110:130) limits the BPM to that range.")
+	fmt.Println("                           The BPM can also be read from a MIREX style .bpm file (e.g. --bpm song.bpm)")
+	fmt.Println()
 	fmt.Println("    --forgetting <factor>  'forgetting factor' for discounting older taps, on the basis that the later")
 	fmt.Println("                           taps are probably more accurate since the person is more familiar with the song.")
 	fmt.Println("                           The factor is applied on a per-line basis i.e. all the taps in a line are")
@@ -318,11 +728,72 @@ func help() {
 	fmt.Println()
 	fmt.Println("    --latency <delay>      delay for which to compensate, in Go 'time' format (e.g. 70ms)")
 	fmt.Println()
+	fmt.Println("    --audio <file>         WAV file of the source audio (used by --snap)")
+	fmt.Println()
+	fmt.Println("    --snap <window>        moves each beat to the strongest transient in the source audio within the")
+	fmt.Println("                           window (in Go 'time' format) around the beat, provided the transient is")
+	fmt.Println("                           sufficiently distinct e.g. --audio song.wav --snap 30ms. The correction")
+	fmt.Println("                           applied to each beat is displayed with --verbose")
+	fmt.Println()
 	fmt.Println("    --precision <time>    time precision for returned 'beats', in Go 'time' format (e.g. 1ms)")
-	fmt.Println("    --out                 output file path")
+	fmt.Println("    --timebase <units>    time base for TXT and JSON output times (duration, seconds, samples[:<rate>],")
+	fmt.Println("                          frames[:<fps>], smpte[:<fps>[df]] or ticks[:<ppq>[@<bpm>]]). Times are rounded")
+	fmt.Println("                          to the nearest sample, frame or tick")
+	fmt.Println("    --out                 output file path. A file ending in .mid is written as a Standard MIDI File with")
+	fmt.Println("                          a tempo map, time signature and bar markers, and a file ending in .svl is")
+	fmt.Println("                          written as a Sonic Visualiser time instants layer. Files ending in .jams, .beats")
+	fmt.Println("                          and .bpm are written as JAMS and MIREX style beats and tempo files and files")
+	fmt.Println("                          ending in .csv and .tsv are written as CSV and TSV tables. A file ending in .rpp")
+	fmt.Println("                          is written as a Reaper project fragment with the tempo envelope and files ending")
+	fmt.Println("                          in .fcpxml, .edl and .ffmetadata are written as video markers and chapters")
+	fmt.Println("    --from <format>       input file format (txt, json, audacity, csv, tsv, svl, jams, beats, midi or wav).")
+	fmt.Println("                          Defaults to the format for the file extension or content")
+	fmt.Println("    --to <format>         output file format (txt, json, audacity, csv, tsv, svl, jams, beats, bpm, midi,")
+	fmt.Println("                          reaper, rpp, rekordbox, fcpxml, edl or ffmetadata). Defaults to the format for")
+	fmt.Println("                          the --out file extension. --json, --audacity, --csv, --reaper and --rekordbox")
+	fmt.Println("                          are aliases for --to json, audacity, csv, reaper and rekordbox")
 	fmt.Println("    --clean               discards outlier taps i.e. taps assigned to beats with too few taps")
 	fmt.Println("    --shift               shifts all times so that the first beat is on 0 and the offset is 0")
-	fmt.Println("    --json                formats the output as prettified JSON")
+	fmt.Println("    --json                formats the output as a versioned JSON document with the options, input checksum,")
+	fmt.Println("                          fit statistics and beat sources, with the times to the --precision")
+	fmt.Println("    --audacity            formats the output as an Audacity label track")
+	fmt.Println("    --labels <labels>     labels for the beats in an Audacity label track i.e. 'beats' (beat number) or")
+	fmt.Println("                          'bars' (bar:beat). Defaults to 'beats'")
+	fmt.Println("    --loop-label <label>  starts a new loop at every label matching <label> in an Audacity label file")
+	fmt.Println("    --tempo-layer         adds a time values layer with the BPM at each beat to Sonic Visualiser output")
+	fmt.Println("    --columns <mapping>   maps the loop, time and weight columns in a CSV input file by header name or")
+	fmt.Println("                          column number (e.g. loop=take,time=onset,weight=velocity)")
+	fmt.Println("    --csv <beats|taps>    formats the output as a CSV table of the beats or a long-form table of the 'taps'")
+	fmt.Println("                          (with the loop, assigned beat and residual of each 'tap')")
+	fmt.Println("    --agreement           prints the inter-tapper agreement coefficient, per-tapper asynchrony and per-beat")
+	fmt.Println("                          spread (with disputed beats) to stderr")
+	fmt.Println("    --format <template>   Go text/template (or template file) for the output, executed against the beats")
+	fmt.Println("                          (.BPM, .Offset, .Count, .Statistics and .Beats) with the helper functions seconds,")
+	fmt.Println("                          ms, samples, timecode, time, barbeat, add, sub and join")
+	fmt.Println("    --tempo-map <map>     tempo map for MIDI, Reaper and rekordbox output i.e. 'constant', 'per-beat' or 'auto'")
+	fmt.Println("                          (constant if the beats are evenly spaced). Defaults to 'auto'")
+	fmt.Println("    --reaper              formats the output as a Reaper region/marker CSV file")
+	fmt.Println("    --markers <markers>   places the Reaper and video markers on every bar ('bars') or every beat ('beats')")
+	fmt.Println("    --marker-name <name>  template for the Reaper/video marker names, with {bar}, {beat} and {n} replaced by the")
+	fmt.Println("                          bar, beat in the bar and marker number (e.g. 'Bar {bar}')")
+	fmt.Println("    --regions             adds a region for every bar to the Reaper region/marker CSV file")
+	fmt.Println("    --downbeat <N>        beat number of the first downbeat for bar numbering and MIDI, Reaper and rekordbox output (defaults to 1)")
+	fmt.Println("    --rekordbox           formats the output as a rekordbox collection XML file")
+	fmt.Println("    --collection <file>   rekordbox collection XML file from which to take the track location and metadata")
+	fmt.Println("    --track <track>       TrackID or name of the track in the rekordbox collection")
+	fmt.Println("    --fps <rate>          frame rate for video marker output (e.g. 25, 29.97 or 30000/1001). Defaults to 25")
+	fmt.Println("    --drop-frame          uses drop-frame timecode for 29.97 and 59.94 fps video marker output")
+	fmt.Println("    --ppq <ticks>         ticks per quarter note for MIDI output (defaults to 480)")
+	fmt.Println("    --beats-per-bar <N>   beats per bar for the MIDI time signature and bar markers and for bar:beat labels")
+	fmt.Println("                          (defaults to 4)")
+	fmt.Println("    --clicks              adds a track with a note for every beat to the MIDI output")
+	fmt.Println("    --channel <N>         MIDI channel (1-16) of the 'taps' in a MIDI input file (defaults to all channels)")
+	fmt.Println("    --notes <list>        comma separated list of the MIDI notes of the 'taps' in a MIDI input file (e.g. 36,38)")
+	fmt.Println("    --loop <time>         splits the 'taps' in a MIDI input file into loops of the specified length (e.g. 8s)")
+	fmt.Println("                          rather than at the marker events")
+	fmt.Println("    --interactive         records the 'taps' directly from the keyboard. Each keypress is a 'tap', <Enter>")
+	fmt.Println("                          starts a loop (including the first) and <q> (or Ctrl-C) ends the session. The")
+	fmt.Println("                          recorded 'taps' are saved to the input file (if specified)")
 	fmt.Println("    --verbose             enables verbose progress messages")
 	fmt.Println("    --help                displays the this information")
 
@@ -361,6 +832,186 @@ func (v *interval) String() string {
 	return "*"
 }
 
+func (v *tempo) String() string {
+	return taps2beats.Tempo(*v).String()
+}
+
+func (v *tempo) Set(s string) error {
+	if strings.HasSuffix(strings.ToLower(s), ".bpm") {
+		bpm, err := readBPM(s)
+		if err != nil {
+			return err
+		} else if bpm <= 0 {
+			return fmt.Errorf("invalid BPM (%v)", bpm)
+		}
+
+		*v = tempo(taps2beats.FixedTempo(bpm))
+
+		return nil
+	}
+
+	tokens := strings.Split(s, ":")
+
+	if len(tokens) == 1 {
+		bpm, err := strconv.ParseFloat(tokens[0], 64)
+		if err != nil {
+			return err
+		} else if bpm <= 0 {
+			return fmt.Errorf("invalid BPM (%v)", bpm)
+		}
+
+		*v = tempo(taps2beats.FixedTempo(bpm))
+
+		return nil
+	}
+
+	if len(tokens) != 2 {
+		return fmt.Errorf("invalid BPM range (%v)", s)
+	}
+
+	var min, max float64
+	if tokens[0] != "" {
+		bpm, err := strconv.ParseFloat(tokens[0], 64)
+		if err != nil {
+			return err
+		}
+
+		min = bpm
+	}
+
+	if tokens[1] != "" {
+		bpm, err := strconv.ParseFloat(tokens[1], 64)
+		if err != nil {
+			return err
+		}
+
+		max = bpm
+	}
+
+	if min < 0 || max < 0 || (max > 0 && max < min) {
+		return fmt.Errorf("invalid BPM range (%v)", s)
+	}
+
+	*v = tempo{Min: min, Max: max}
+
+	return nil
+}
+
+func (v *notes) String() string {
+	list := []string{}
+	for _, n := range *v {
+		list = append(list, fmt.Sprintf("%v", n))
+	}
+
+	return strings.Join(list, ",")
+}
+
+func (v *notes) Set(s string) error {
+	list := notes{}
+	for _, token := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(token))
+		if err != nil {
+			return err
+		} else if n < 0 || n > 127 {
+			return fmt.Errorf("invalid MIDI note (%v)", n)
+		}
+
+		list = append(list, n)
+	}
+
+	*v = list
+
+	return nil
+}
+
+func (v *timebase) String() string {
+	if v.timebase == nil {
+		return ""
+	}
+
+	return v.timebase.String()
+}
+
+func (v *timebase) Set(s string) error {
+	tb, err := taps2beats.ParseTimeBase(s)
+	if err != nil {
+		return err
+	}
+
+	v.timebase = &tb
+
+	return nil
+}
+
+func (v *alias) IsBoolFlag() bool {
+	return true
+}
+
+func (v *alias) String() string {
+	return fmt.Sprintf("%v", v.set)
+}
+
+func (v *alias) Set(s string) error {
+	set, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+
+	v.set = set
+	if set {
+		options.to = v.format
+	}
+
+	return nil
+}
+
+func (v *table) String() string {
+	return string(*v)
+}
+
+func (v *table) Set(s string) error {
+	if s != "beats" && s != "taps" {
+		return fmt.Errorf("invalid table (%v)", s)
+	}
+
+	*v = table(s)
+	options.to = "csv"
+
+	return nil
+}
+
+func (v *columns) String() string {
+	list := []string{}
+	for _, k := range []string{"loop", "time", "weight"} {
+		if c, ok := (*v)[k]; ok {
+			list = append(list, fmt.Sprintf("%v=%v", k, c))
+		}
+	}
+
+	return strings.Join(list, ",")
+}
+
+func (v *columns) Set(s string) error {
+	mapping := columns{}
+	for _, token := range strings.Split(s, ",") {
+		kv := strings.SplitN(token, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[1]) == "" {
+			return fmt.Errorf("invalid column mapping (%v)", token)
+		}
+
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		if key != "loop" && key != "time" && key != "weight" {
+			return fmt.Errorf("invalid column (%v)", kv[0])
+		}
+
+		mapping[key] = strings.TrimSpace(kv[1])
+	}
+
+	*v = mapping
+
+	return nil
+}
+
 func (v *interval) Set(s string) error {
 	re := regexp.MustCompile(`[0-9]+(\.[0-9]*)?`)
 	tokens := strings.Split(s, ":")
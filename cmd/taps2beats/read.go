@@ -3,66 +3,70 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"regexp"
-	"strconv"
-	"strings"
+	"os"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/tapsio"
 )
 
-func read(r io.Reader, parse func([]byte) ([][]float64, error)) (int, [][]float64, error) {
-	bytes, err := ioutil.ReadAll(r)
-	if err != nil {
-		return 0, nil, err
-	}
+// Input file(s) and SHA-256 checksum(s) of the input data, for the provenance of the beats in a JSON output file.
+var source = struct {
+	file     string
+	checksum string
+}{}
 
-	data, err := parse(bytes)
+// Reads the 'taps' in the format selected by --from or, if not specified, by the file extension or the content
+// (defaulting to TXT).
+func read(file string, r io.Reader) (tapsio.Format, taps2beats.TapSet, error) {
+	b, err := ioutil.ReadAll(r)
 	if err != nil {
-		return 0, nil, err
+		return tapsio.Format{}, taps2beats.TapSet{}, err
 	}
 
-	count := 0
-	for _, row := range data {
-		count += len(row)
+	checksum := fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+	if source.file == "" {
+		source.file = file
+		source.checksum = checksum
+	} else {
+		source.file += ", " + file
+		source.checksum += ", " + checksum
 	}
 
-	return count, data, nil
-}
-
-func parseJSON(bytes []byte) ([][]float64, error) {
-	taps := struct {
-		Taps [][]float64 `json:"taps"`
-	}{}
+	format, err := tapsio.ReaderFor(options.from, file, b)
+	if err != nil && options.from != "" {
+		return tapsio.Format{}, taps2beats.TapSet{}, err
+	} else if err != nil {
+		if format, err = tapsio.Lookup("txt"); err != nil {
+			return tapsio.Format{}, taps2beats.TapSet{}, err
+		}
+	}
 
-	if err := json.Unmarshal(bytes, &taps); err != nil {
-		return nil, err
+	set, err := format.Reader.Read(bytes.NewReader(b))
+	if err != nil {
+		return format, taps2beats.TapSet{}, err
 	}
 
-	return taps.Taps, nil
+	return format, set, nil
 }
 
-func parseTXT(bytes []byte) ([][]float64, error) {
-	data := [][]float64{}
-	re := regexp.MustCompile(`\s+`)
-	for _, line := range strings.Split(string(bytes), "\n") {
-		tokens := re.Split(line, -1)
-		row := []float64{}
-		for _, t := range tokens {
-			if t != "" {
-				if v, err := strconv.ParseFloat(t, 64); err != nil {
-					fmt.Printf("  ** WARN: invalid value (%s)\n", t)
-				} else {
-					row = append(row, v)
-				}
-			}
-		}
+// Reads the tempo from a MIREX style .bpm file.
+func readBPM(file string) (float64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
 
-		if len(row) > 0 {
-			data = append(data, row)
-		}
+	defer f.Close()
+
+	bpm, err := tapsio.ReadBPM(f)
+	if err != nil {
+		return 0, fmt.Errorf("%v in %s", err, file)
 	}
 
-	return data, nil
+	return bpm, nil
 }
@@ -0,0 +1,185 @@
+// +build !js !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/audio"
+)
+
+// Implements the 'render' command, which renders a metronome click track for a set of beats
+// (in the JSON format written by --json) as a WAV file.
+func render(args []string) {
+	settings := struct {
+		outfile     string
+		sampleRate  int
+		bits        int
+		beatsPerBar int
+		downbeat    int
+		countIn     int
+		mix         string
+		click       string
+		accent      string
+		gain        float64
+		verbose     bool
+		help        bool
+	}{
+		outfile:     "clicks.wav",
+		sampleRate:  44100,
+		bits:        16,
+		beatsPerBar: 4,
+		downbeat:    1,
+		countIn:     0,
+		mix:         "",
+		click:       "",
+		accent:      "",
+		gain:        0.5,
+		verbose:     false,
+		help:        false,
+	}
+
+	flagset := flag.NewFlagSet("render", flag.ExitOnError)
+	flagset.StringVar(&settings.outfile, "out", settings.outfile, "output WAV file path")
+	flagset.IntVar(&settings.sampleRate, "sample-rate", settings.sampleRate, "sample rate of the rendered WAV file")
+	flagset.IntVar(&settings.bits, "bits", settings.bits, "bits per sample of the rendered WAV file (16, 24 or 32)")
+	flagset.IntVar(&settings.beatsPerBar, "beats-per-bar", settings.beatsPerBar, "beats per bar, for accenting the downbeats (0 for no accents)")
+	flagset.IntVar(&settings.downbeat, "downbeat", settings.downbeat, "beat number of the first downbeat")
+	flagset.IntVar(&settings.countIn, "count-in", settings.countIn, "number of count-in clicks before the first beat")
+	flagset.StringVar(&settings.mix, "mix", settings.mix, "source WAV file to mix with the clicks")
+	flagset.StringVar(&settings.click, "click", settings.click, "WAV file for the click sound")
+	flagset.StringVar(&settings.accent, "accent", settings.accent, "WAV file for the accented (downbeat) click sound")
+	flagset.Float64Var(&settings.gain, "gain", settings.gain, "gain applied to the clicks when mixing with the source audio")
+	flagset.BoolVar(&settings.verbose, "verbose", settings.verbose, "enables verbose progress messages")
+	flagset.BoolVar(&settings.help, "help", settings.help, "displays the 'help' information")
+	flagset.Parse(args)
+
+	if settings.help {
+		helpRender()
+		os.Exit(0)
+	}
+
+	if len(flagset.Args()) == 0 {
+		fmt.Printf("\n  ** ERROR: missing beats file\n\n")
+		os.Exit(1)
+	}
+
+	file := flagset.Args()[0]
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		fmt.Printf("\n  ** ERROR: unable to read beats from %s (%v)\n\n", file, err)
+		os.Exit(1)
+	}
+
+	beats := taps2beats.Beats{}
+	if err := json.Unmarshal(bytes, &beats); err != nil {
+		fmt.Printf("\n  ** ERROR: unable to read beats from %s (%v)\n\n", file, err)
+		os.Exit(1)
+	}
+
+	if settings.verbose {
+		fmt.Printf("  ... %v beats read from %s\n", len(beats.Beats), file)
+	}
+
+	track := audio.NewClickTrack(settings.sampleRate)
+	track.BeatsPerBar = settings.beatsPerBar
+	track.Downbeat = settings.downbeat - 1
+	track.CountIn = settings.countIn
+	track.Gain = settings.gain
+
+	if settings.click != "" {
+		wav, err := readWAV(settings.click)
+		if err != nil {
+			fmt.Printf("\n  ** ERROR: unable to read click sound from %s (%v)\n\n", settings.click, err)
+			os.Exit(1)
+		}
+
+		track.Click = track.Resample(wav)
+	}
+
+	if settings.accent != "" {
+		wav, err := readWAV(settings.accent)
+		if err != nil {
+			fmt.Printf("\n  ** ERROR: unable to read accent sound from %s (%v)\n\n", settings.accent, err)
+			os.Exit(1)
+		}
+
+		track.Accent = track.Resample(wav)
+	}
+
+	if settings.mix != "" {
+		wav, err := readWAV(settings.mix)
+		if err != nil {
+			fmt.Printf("\n  ** ERROR: unable to read source audio from %s (%v)\n\n", settings.mix, err)
+			os.Exit(1)
+		}
+
+		if settings.verbose {
+			fmt.Printf("  ... mixing with %v of audio from %s\n", wav.Duration(), settings.mix)
+		}
+
+		track.Source = wav
+	}
+
+	if err := writeWAV(settings.outfile, track.Render(beats), settings.bits); err != nil {
+		fmt.Printf("\n  ** ERROR: unable to write click track to %s (%v)\n\n", settings.outfile, err)
+		os.Exit(1)
+	}
+
+	if settings.verbose {
+		fmt.Printf("  ... click track written to %s\n", settings.outfile)
+	}
+}
+
+func helpRender() {
+	fmt.Println()
+	fmt.Printf("  taps2beats %s\n", VERSION)
+	fmt.Println()
+	fmt.Println("  taps2beats render writes a WAV click track with a click at every beat in a beats file (as written by")
+	fmt.Println("  taps2beats --json), to check the estimated beats by ear.")
+	fmt.Println()
+	fmt.Println("  Usage: taps2beats render [--out <file>] [--sample-rate <rate>] [--bits <bits>] [--beats-per-bar <N>] [--downbeat <beat>] [--count-in <N>] [--mix <file>] [--click <file>] [--accent <file>] [--gain <gain>] [--verbose] <file>")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --out <file>           output WAV file path (defaults to clicks.wav)")
+	fmt.Println("    --sample-rate <rate>   sample rate of the rendered WAV file (defaults to 44100)")
+	fmt.Println("    --bits <bits>          bits per sample of the rendered WAV file i.e. 16, 24 or 32 (defaults to 16)")
+	fmt.Println("    --beats-per-bar <N>    beats per bar, for accenting the downbeats (defaults to 4, 0 for no accents)")
+	fmt.Println("    --downbeat <beat>      beat number of the first downbeat (defaults to 1)")
+	fmt.Println("    --count-in <N>         number of count-in clicks before the first beat (defaults to 0)")
+	fmt.Println("    --mix <file>           source WAV file to mix with the clicks")
+	fmt.Println("    --click <file>         WAV file for the click sound (defaults to a short 1kHz tone)")
+	fmt.Println("    --accent <file>        WAV file for the accented click sound (defaults to a short 1.5kHz tone)")
+	fmt.Println("    --gain <gain>          gain applied to the clicks when mixing with the source audio (defaults to 0.5)")
+	fmt.Println("    --verbose              enables verbose progress messages")
+	fmt.Println("    --help                 displays this information")
+	fmt.Println()
+}
+
+func readWAV(file string) (*audio.WAV, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return audio.ReadWAV(f)
+}
+
+func writeWAV(file string, wav *audio.WAV, bits int) error {
+	var b bytes.Buffer
+
+	if err := audio.WriteWAV(&b, wav, bits, false); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, b.Bytes(), 0644)
+}
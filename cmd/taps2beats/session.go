@@ -0,0 +1,249 @@
+// +build !js !wasm
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/tapsio"
+)
+
+// An input file in a (possibly multi-file) session, with the optional per-file latency, weight and
+// forgetting factor.
+type input struct {
+	file       string
+	latency    time.Duration
+	weight     float64
+	forgetting *float64
+	format     tapsio.Format
+	loops      []int // indices of the file loops in the combined TapSet
+}
+
+var settings = regexp.MustCompile(`^(latency|weight|forgetting)=[^,=]+(,(latency|weight|forgetting)=[^,=]+)*$`)
+
+// Parses an input file argument i.e. <file>[:latency=<time>,weight=<weight>,forgetting=<factor>].
+//
+// The settings are only recognised if everything after the last ':' is a list of the known settings, so
+// e.g. 'takes/a:b=c.txt' is just a file name. A trailing ':' is an empty list of settings i.e. a file with
+// a name that looks like a file with settings (e.g. 'take:weight=2') can be given as 'take:weight=2:'.
+func parseInput(arg string) (input, error) {
+	in := input{
+		file:   arg,
+		weight: 1.0,
+	}
+
+	if strings.HasSuffix(arg, ":") {
+		in.file = strings.TrimSuffix(arg, ":")
+		return in, nil
+	}
+
+	ix := strings.LastIndex(arg, ":")
+	if ix < 0 || !settings.MatchString(arg[ix+1:]) {
+		return in, nil
+	}
+
+	in.file = arg[:ix]
+
+	for _, setting := range strings.Split(arg[ix+1:], ",") {
+		kv := strings.SplitN(setting, "=", 2)
+		switch kv[0] {
+		case "latency":
+			if v, err := time.ParseDuration(kv[1]); err != nil {
+				return in, fmt.Errorf("invalid latency for %v (%v)", in.file, kv[1])
+			} else {
+				in.latency = v
+			}
+
+		case "weight":
+			if v, err := strconv.ParseFloat(kv[1], 64); err != nil || v <= 0 {
+				return in, fmt.Errorf("invalid weight for %v (%v)", in.file, kv[1])
+			} else {
+				in.weight = v
+			}
+
+		case "forgetting":
+			if v, err := strconv.ParseFloat(kv[1], 64); err != nil || v <= -1 || v >= 1 {
+				return in, fmt.Errorf("invalid forgetting factor for %v (%v)", in.file, kv[1])
+			} else {
+				in.forgetting = &v
+			}
+		}
+	}
+
+	return in, nil
+}
+
+// Reads the 'taps' from a single input file (or stdin), closing the file as soon as it has been read.
+func readFile(file string) (tapsio.Format, taps2beats.TapSet, error) {
+	var r io.Reader = os.Stdin
+	if file != "<stdin>" {
+		f, err := os.Open(file)
+		if err != nil {
+			return tapsio.Format{}, taps2beats.TapSet{}, fmt.Errorf("unable to open file %s (%v)", file, err)
+		}
+
+		defer f.Close()
+		r = f
+	}
+
+	format, taps, err := read(file, r)
+	if err != nil {
+		return tapsio.Format{}, taps2beats.TapSet{}, fmt.Errorf("unable to read data from %s (%v)", file, err)
+	}
+
+	return format, taps, nil
+}
+
+// Reads the 'taps' from all the input files, with the per-file latency, weight and forgetting factor (or,
+// if not specified, the --forgetting factor) applied to the loops in each file. A single file without any
+// per-file settings is returned as is, to be discounted by the --forgetting factor.
+func readSession(inputs []input) ([]input, taps2beats.TapSet, int, error) {
+	set := taps2beats.TapSet{
+		Loops: []taps2beats.Loop{},
+	}
+
+	N := 0
+	for i, in := range inputs {
+		if options.verbose {
+			fmt.Printf("  ... reading data from %s\n", in.file)
+		}
+
+		format, taps, err := readFile(in.file)
+		if err != nil {
+			return nil, set, 0, err
+		}
+
+		n := 0
+		for _, loop := range taps.Loops {
+			n += len(loop.Taps)
+		}
+
+		if options.verbose {
+			fmt.Printf("  ... %v values read from %s (%v)\n", n, in.file, format.Name)
+		}
+
+		if discounted(inputs) {
+			forgetting := options.forgetting
+			if in.forgetting != nil {
+				forgetting = *in.forgetting
+			}
+
+			taps = taps.Forget(forgetting)
+			for j := range taps.Loops {
+				taps.Loops[j].Latency += in.latency
+				taps.Loops[j].Weight *= in.weight
+			}
+		}
+
+		if len(inputs) > 1 {
+			for j := range taps.Loops {
+				if taps.Loops[j].Tapper == "" {
+					taps.Loops[j].Tapper = in.file
+				}
+			}
+		}
+
+		inputs[i].format = format
+		inputs[i].loops = []int{}
+		for range taps.Loops {
+			inputs[i].loops = append(inputs[i].loops, len(set.Loops)+len(inputs[i].loops))
+		}
+
+		set = set.Merge(taps)
+		N += n
+	}
+
+	return inputs, set, N, nil
+}
+
+// Returns true if the per-file settings and forgetting factors are applied to the input files i.e. for a
+// multi-file session or a single file with per-file settings.
+func discounted(inputs []input) bool {
+	if len(inputs) == 1 {
+		in := inputs[0]
+
+		return in.latency != 0 || in.weight != 1.0 || in.forgetting != nil
+	}
+
+	return len(inputs) > 1
+}
+
+// Prints a table of the fit statistics for each input file i.e. the number of loops and 'taps', the number
+// of beats with 'taps' from the file and the mean and RMS difference between the 'taps' and the beats.
+func printSession(w io.Writer, inputs []input, beats taps2beats.Beats) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "  FILE\tLOOPS\tTAPS\tBEATS\tASYNCHRONY\tRESIDUAL\n")
+	for _, in := range inputs {
+		stats := beats.StatisticsFor(in.loops...)
+
+		fmt.Fprintf(tw, "  %v\t%v\t%v\t%v/%v\t%v\t%v\n",
+			in.file,
+			len(in.loops),
+			stats.Taps,
+			stats.Tapped,
+			stats.Beats,
+			stats.Asynchrony.Round(100*time.Microsecond),
+			stats.Residual.Round(100*time.Microsecond))
+	}
+	fmt.Fprintln(tw)
+
+	tw.Flush()
+}
+
+// Prints the inter-tapper agreement i.e. the agreement coefficient, the asynchrony of each tapper relative to
+// the consensus beats and the spread of the tappers for each beat (flagging the disputed beats).
+func printAgreement(w io.Writer, set taps2beats.TapSet, beats taps2beats.Beats) {
+	agreement := beats.Agreement(set.Tappers(), taps2beats.AgreementWindow)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw)
+	if math.IsNaN(agreement.Coefficient) {
+		fmt.Fprintf(tw, "  AGREEMENT: -  (requires at least two tappers)\n")
+	} else {
+		fmt.Fprintf(tw, "  AGREEMENT: %.3f  (±%v)\n", agreement.Coefficient, agreement.Window)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "  TAPPER\tLOOPS\tTAPS\tTAPPED\tMISSED\tASYNCHRONY\tDEVIATION\n")
+	for _, t := range agreement.Tappers {
+		fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			t.Tapper,
+			t.Loops,
+			t.Taps,
+			t.Tapped,
+			t.Missed,
+			t.Asynchrony.Round(100*time.Microsecond),
+			t.Deviation.Round(100*time.Microsecond))
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "  BEAT\tAT\tTAPPERS\tSPREAD\t\n")
+	for _, b := range agreement.Beats {
+		disputed := ""
+		if b.Disputed {
+			disputed = "disputed"
+		}
+
+		fmt.Fprintf(tw, "  %v\t%v\t%v/%v\t%v\t%v\n",
+			b.Beat,
+			b.At.Round(time.Millisecond),
+			b.Tapped,
+			b.Tappers,
+			b.Spread.Round(100*time.Microsecond),
+			disputed)
+	}
+	fmt.Fprintln(tw)
+
+	tw.Flush()
+}
@@ -0,0 +1,40 @@
+// +build !js !wasm
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/audio"
+)
+
+// Minimum confidence for snapping a beat to an audio onset.
+const threshold = 0.3
+
+func snap(beats *taps2beats.Beats, file string, window time.Duration) error {
+	wav, err := readWAV(file)
+	if err != nil {
+		return err
+	}
+
+	envelope := audio.DefaultOnsetDetector.Envelope(wav)
+	corrections := envelope.Snap(beats, window, threshold)
+
+	if options.verbose {
+		snapped := 0
+		for _, c := range corrections {
+			if c.Snapped {
+				snapped++
+				fmt.Printf("      beat %-3d %-12v %-12v %v (confidence %.2f)\n", c.Beat+1, c.From, c.To, c.Delta(), c.Confidence)
+			} else {
+				fmt.Printf("      beat %-3d %-12v %-12v - (confidence %.2f)\n", c.Beat+1, c.From, c.To, c.Confidence)
+			}
+		}
+
+		fmt.Printf("  ... %v of %v beats snapped to audio onsets\n", snapped, len(corrections))
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package taps2beats
+
+import (
+	"math"
+	"time"
+)
+
+// Default tolerance window for the inter-tapper agreement coefficient (as for the beat tracking F-measure).
+const AgreementWindow = 70 * time.Millisecond
+
+// Inter-tapper agreement for a set of beats, treating each tapper as a rater. The coefficient is the pairwise
+// percent agreement i.e. for every pair of tappers, the proportion of the beats tapped by either tapper (and
+// within the span of the other tapper's 'taps') for which both tappers tapped the beat and the mean 'taps' of
+// the two tappers are within the tolerance window, over all the pairs of tappers.
+type Agreement struct {
+	Coefficient float64           // pairwise agreement (0 to 1) or NaN if there are fewer than two tappers
+	Window      time.Duration     // tolerance window for the coefficient
+	Tappers     []TapperAgreement // per-tapper statistics, in order of the first loop of each tapper
+	Beats       []BeatAgreement   // per-beat statistics
+}
+
+// The agreement statistics for a single tapper i.e. the number of beats tapped (and missed, for beats within
+// the span of the tapper's 'taps') and the mean and standard deviation of the difference between the
+// tapper's 'taps' and the consensus beats.
+type TapperAgreement struct {
+	Tapper     string
+	Loops      int
+	Taps       int
+	Tapped     int
+	Missed     int
+	Asynchrony time.Duration
+	Deviation  time.Duration
+}
+
+// The agreement statistics for a single beat i.e. the number of tappers that tapped the beat (out of the
+// tappers whose 'taps' span the beat), the standard deviation of the mean 'taps' of each tapper and whether
+// the tappers disagree about whether the beat exists.
+type BeatAgreement struct {
+	Beat     int
+	At       time.Duration
+	Tapped   int
+	Tappers  int
+	Spread   time.Duration
+	Disputed bool
+}
+
+// Returns the tapper for each loop i.e. the loop Tapper or, if not specified, the loop ID (so that each loop
+// without a tapper is treated as a separate tapper).
+func (set TapSet) Tappers() []string {
+	tappers := make([]string, len(set.Loops))
+	for i, loop := range set.Loops {
+		if loop.Tapper != "" {
+			tappers[i] = loop.Tapper
+		} else {
+			tappers[i] = loop.ID
+		}
+	}
+
+	return tappers
+}
+
+// Returns the inter-tapper agreement for the beats, where tappers is the tapper of each loop (indexed as
+// for the beat Loops) and window is the tolerance window for the agreement coefficient.
+func (beats Beats) Agreement(tappers []string, window time.Duration) Agreement {
+	names := []string{}
+	index := map[string]int{}
+	loops := map[string]int{}
+	for _, t := range tappers {
+		if _, ok := index[t]; !ok {
+			index[t] = len(names)
+			names = append(names, t)
+		}
+
+		loops[t]++
+	}
+
+	N := len(names)
+	tapper := func(loop int) int {
+		if loop >= 0 && loop < len(tappers) {
+			return index[tappers[loop]]
+		}
+
+		return -1
+	}
+
+	// ... per-beat, per-tapper mean 'taps'
+	means := make([][]*time.Duration, len(beats.Beats))
+	first := make([]time.Duration, N)
+	last := make([]time.Duration, N)
+	seen := make([]bool, N)
+	sum := make([]float64, N)
+	sumsq := make([]float64, N)
+	count := make([]int, N)
+
+	for i, b := range beats.Beats {
+		total := make([]time.Duration, N)
+		n := make([]int, N)
+		for j, t := range b.Taps {
+			k := -1
+			if j < len(b.Loops) {
+				k = tapper(b.Loops[j])
+			}
+
+			if k < 0 {
+				continue
+			}
+
+			total[k] += t
+			n[k]++
+
+			dt := (t - b.At).Seconds()
+			sum[k] += dt
+			sumsq[k] += dt * dt
+			count[k]++
+
+			if !seen[k] || t < first[k] {
+				first[k] = t
+			}
+
+			if !seen[k] || t > last[k] {
+				last[k] = t
+			}
+
+			seen[k] = true
+		}
+
+		means[i] = make([]*time.Duration, N)
+		for k := range n {
+			if n[k] > 0 {
+				mean := total[k] / time.Duration(n[k])
+				means[i][k] = &mean
+			}
+		}
+	}
+
+	agreement := Agreement{
+		Coefficient: math.NaN(),
+		Window:      window,
+		Tappers:     make([]TapperAgreement, N),
+		Beats:       make([]BeatAgreement, len(beats.Beats)),
+	}
+
+	for k, t := range names {
+		agreement.Tappers[k] = TapperAgreement{
+			Tapper: t,
+			Loops:  loops[t],
+			Taps:   count[k],
+		}
+
+		if count[k] > 0 {
+			mean := sum[k] / float64(count[k])
+			variance := sumsq[k]/float64(count[k]) - mean*mean
+			agreement.Tappers[k].Asynchrony = Seconds(mean)
+			agreement.Tappers[k].Deviation = Seconds(math.Sqrt(math.Max(variance, 0)))
+		}
+	}
+
+	covers := func(k, i int) bool {
+		at := beats.Beats[i].At
+
+		return means[i][k] != nil || (seen[k] && at >= first[k] && at <= last[k])
+	}
+
+	// ... per-beat spread and disputed beats
+	for i, b := range beats.Beats {
+		ba := BeatAgreement{
+			Beat: i + 1,
+			At:   b.At,
+		}
+
+		values := []float64{}
+		for k := 0; k < N; k++ {
+			if means[i][k] != nil {
+				ba.Tapped++
+				ba.Tappers++
+				agreement.Tappers[k].Tapped++
+				values = append(values, means[i][k].Seconds())
+			} else if covers(k, i) {
+				ba.Tappers++
+				agreement.Tappers[k].Missed++
+			}
+		}
+
+		if len(values) > 1 {
+			mean := 0.0
+			for _, v := range values {
+				mean += v
+			}
+			mean /= float64(len(values))
+
+			variance := 0.0
+			for _, v := range values {
+				variance += (v - mean) * (v - mean)
+			}
+
+			ba.Spread = Seconds(math.Sqrt(variance / float64(len(values)-1)))
+		}
+
+		ba.Disputed = ba.Tapped > 0 && ba.Tapped < ba.Tappers
+		agreement.Beats[i] = ba
+	}
+
+	// ... pairwise agreement
+	agreed := 0
+	total := 0
+	for p := 0; p < N; p++ {
+		for q := p + 1; q < N; q++ {
+			for i := range beats.Beats {
+				mp, mq := means[i][p], means[i][q]
+				switch {
+				case mp != nil && mq != nil:
+					total++
+					if dt := *mp - *mq; dt <= window && dt >= -window {
+						agreed++
+					}
+
+				case mp != nil && covers(q, i), mq != nil && covers(p, i):
+					total++
+				}
+			}
+		}
+	}
+
+	if total > 0 {
+		agreement.Coefficient = float64(agreed) / float64(total)
+	}
+
+	return agreement
+}
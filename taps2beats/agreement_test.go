@@ -0,0 +1,75 @@
+package taps2beats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAgreement(t *testing.T) {
+	beats := Beats{
+		Beats: []Beat{
+			{At: Seconds(0.50), Taps: seconds(0.49, 0.51, 0.52), Loops: []int{0, 1, 2}},
+			{At: Seconds(1.00), Taps: seconds(0.98, 1.02), Loops: []int{0, 1}},
+			{At: Seconds(1.50), Taps: seconds(1.48, 1.60), Loops: []int{0, 2}},
+			{At: Seconds(2.00), Taps: seconds(2.00, 2.02), Loops: []int{0, 2}},
+		},
+	}
+
+	agreement := beats.Agreement([]string{"alice", "bob", "carol"}, AgreementWindow)
+
+	// ... alice and bob agree on beats 1 and 2 (beats 3 and 4 are outside bob's 'taps')
+	//     alice and carol agree on beats 1 and 4 but not on beat 2 (missed by carol) or beat 3 (120ms apart)
+	//     bob and carol agree on beat 1 but not on beat 2 (beats 3 and 4 are outside bob's 'taps')
+	expected := 5.0 / 8.0
+	if math.Abs(agreement.Coefficient-expected) > 1e-9 {
+		t.Errorf("Incorrect agreement coefficient - expected:%v, got:%v", expected, agreement.Coefficient)
+	}
+
+	tappers := []TapperAgreement{
+		{Tapper: "alice", Loops: 1, Taps: 4, Tapped: 4, Missed: 0, Asynchrony: -12500 * time.Microsecond, Deviation: 8292 * time.Microsecond},
+		{Tapper: "bob", Loops: 1, Taps: 2, Tapped: 2, Missed: 0, Asynchrony: 15 * time.Millisecond, Deviation: 5 * time.Millisecond},
+		{Tapper: "carol", Loops: 1, Taps: 3, Tapped: 3, Missed: 1, Asynchrony: 46700 * time.Microsecond, Deviation: 37712 * time.Microsecond},
+	}
+
+	for i, e := range tappers {
+		a := agreement.Tappers[i]
+		a.Asynchrony = a.Asynchrony.Round(100 * time.Microsecond)
+		a.Deviation = a.Deviation.Round(time.Microsecond)
+		e.Asynchrony = e.Asynchrony.Round(100 * time.Microsecond)
+		e.Deviation = e.Deviation.Round(time.Microsecond)
+
+		if a.Tapper != e.Tapper || a.Loops != e.Loops || a.Taps != e.Taps || a.Tapped != e.Tapped || a.Missed != e.Missed || a.Asynchrony != e.Asynchrony || a.Deviation != e.Deviation {
+			t.Errorf("Incorrect tapper agreement\n   expected:%+v\n   got:     %+v", e, a)
+		}
+	}
+
+	disputed := []bool{false, true, false, false}
+	tapped := []int{3, 2, 2, 2}
+	for i, b := range agreement.Beats {
+		if b.Disputed != disputed[i] || b.Tapped != tapped[i] {
+			t.Errorf("Incorrect beat %d agreement - got:%+v", i+1, b)
+		}
+	}
+
+	if spread := agreement.Beats[2].Spread.Round(time.Millisecond); spread != 85*time.Millisecond {
+		t.Errorf("Incorrect beat 3 spread - expected:%v, got:%v", 85*time.Millisecond, spread)
+	}
+}
+
+func TestAgreementWithOneTapper(t *testing.T) {
+	beats := Beats{
+		Beats: []Beat{
+			{At: Seconds(0.50), Taps: seconds(0.49, 0.51), Loops: []int{0, 1}},
+		},
+	}
+
+	tappers := NewTapSetFromFloats([][]float64{{0.49}, {0.51}}).Tappers()
+	if len(tappers) != 2 || tappers[0] != "1" || tappers[1] != "2" {
+		t.Errorf("Incorrect tappers - got:%v", tappers)
+	}
+
+	if a := beats.Agreement([]string{"alice", "alice"}, AgreementWindow); !math.IsNaN(a.Coefficient) {
+		t.Errorf("Expected NaN agreement coefficient for a single tapper - got:%v", a.Coefficient)
+	}
+}
@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// In-place iterative radix-2 FFT. Panics if the length of the array is not a power of 2.
+func fft(x []complex128) {
+	N := len(x)
+	if N&(N-1) != 0 {
+		panic("FFT length must be a power of 2")
+	}
+
+	// ... bit reversal permutation
+	for i, j := 1, 0; i < N; i++ {
+		bit := N >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	// ... butterflies
+	for size := 2; size <= N; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < N; start += size {
+			wn := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := x[start+k+size/2] * wn
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				wn *= w
+			}
+		}
+	}
+}
+
+// Hann window of length N.
+func hann(N int) []float64 {
+	w := make([]float64, N)
+	for i := range w {
+		w[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(N))
+	}
+
+	return w
+}
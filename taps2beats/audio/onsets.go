@@ -0,0 +1,246 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// Spectral flux onset detector.
+//
+// The onset strength envelope is the half-wave rectified difference between the log magnitude
+// spectra of successive (Hann windowed) frames, normalized to a maximum of 1.0. Onsets are the
+// peaks in the envelope that exceed the local mean by more than the threshold, refined to the
+// sharpest rise in the short term energy of the audio around the peak.
+type OnsetDetector struct {
+	FrameSize   int           // FFT frame size in samples (must be a power of 2, defaults to 1024)
+	HopSize     int           // interval between frames in samples (defaults to 256)
+	Threshold   float64       // minimum height of a peak above the local mean (0.0 to 1.0)
+	MinInterval time.Duration // minimum interval between onsets
+}
+
+// Onset strength envelope for an audio signal.
+type Envelope struct {
+	Rate   float64   // frames per second
+	Values []float64 // normalized onset strength for each frame
+
+	frame      int
+	samples    []float32
+	sampleRate int
+}
+
+// Default onset detector settings i.e. 1024 sample frames with a 256 sample hop (23ms and 5.8ms at 44.1kHz).
+var DefaultOnsetDetector = OnsetDetector{
+	FrameSize:   1024,
+	HopSize:     256,
+	Threshold:   0.1,
+	MinInterval: 100 * time.Millisecond,
+}
+
+const gamma = 100.0 // log magnitude compression factor
+
+// Detects the onsets in the audio using the default onset detector and returns them as a
+// single loop of 'taps' for Taps2Beats.
+func Taps(wav *WAV) [][]time.Duration {
+	return DefaultOnsetDetector.Taps(wav)
+}
+
+// Detects the onsets in the audio and returns them as a single loop of 'taps' for Taps2Beats.
+func (d OnsetDetector) Taps(wav *WAV) [][]time.Duration {
+	return [][]time.Duration{d.Onsets(wav)}
+}
+
+// Detects the onsets in the audio and returns the onset times in ascending order.
+func (d OnsetDetector) Onsets(wav *WAV) []time.Duration {
+	envelope := d.Envelope(wav)
+	onsets := []time.Duration{}
+
+	N := len(envelope.Values)
+	w := int(math.Ceil(d.MinInterval.Seconds() * envelope.Rate / 2))
+	if w < 1 {
+		w = 1
+	}
+
+	last := -1
+	for i, v := range envelope.Values {
+		if v <= 0 {
+			continue
+		}
+
+		peak := true
+		sum := 0.0
+		count := 0
+		for j := i - 2*w; j <= i+w; j++ {
+			if j >= 0 && j < N {
+				sum += envelope.Values[j]
+				count++
+
+				if j >= i-w && envelope.Values[j] > v {
+					peak = false
+				}
+			}
+		}
+
+		if !peak || v < sum/float64(count)+d.Threshold {
+			continue
+		}
+
+		if last >= 0 && float64(i-last) < d.MinInterval.Seconds()*envelope.Rate {
+			continue
+		}
+
+		last = i
+		onsets = append(onsets, envelope.Refine(envelope.At(i), envelope.window()))
+	}
+
+	return onsets
+}
+
+// Calculates the onset strength envelope of the (mono mixed) audio.
+func (d OnsetDetector) Envelope(wav *WAV) Envelope {
+	samples := wav.Mono()
+	N := d.frameSize()
+	hop := d.hopSize()
+	window := hann(N)
+	frames := 0
+	if len(samples) > 0 {
+		frames = 1 + (len(samples)-1)/hop
+	}
+
+	envelope := Envelope{
+		Rate:       float64(wav.SampleRate) / float64(hop),
+		Values:     make([]float64, frames),
+		frame:      N,
+		samples:    samples,
+		sampleRate: wav.SampleRate,
+	}
+
+	previous := make([]float64, N/2+1)
+	spectrum := make([]float64, N/2+1)
+	x := make([]complex128, N)
+	max := 0.0
+
+	for i := 0; i < frames; i++ {
+		start := i*hop - N/2 // ... frames are centred on i*hop
+		for j := range x {
+			if k := start + j; k >= 0 && k < len(samples) {
+				x[j] = complex(float64(samples[k])*window[j], 0)
+			} else {
+				x[j] = 0
+			}
+		}
+
+		fft(x)
+
+		flux := 0.0
+		for k := range spectrum {
+			spectrum[k] = math.Log1p(gamma * math.Hypot(real(x[k]), imag(x[k])))
+			if i > 0 && spectrum[k] > previous[k] {
+				flux += spectrum[k] - previous[k]
+			}
+		}
+
+		envelope.Values[i] = flux
+		if flux > max {
+			max = flux
+		}
+
+		previous, spectrum = spectrum, previous
+	}
+
+	if max > 0 {
+		for i := range envelope.Values {
+			envelope.Values[i] /= max
+		}
+	}
+
+	return envelope
+}
+
+func (d OnsetDetector) frameSize() int {
+	if d.FrameSize <= 0 {
+		return DefaultOnsetDetector.FrameSize
+	}
+
+	return d.FrameSize
+}
+
+func (d OnsetDetector) hopSize() int {
+	if d.HopSize <= 0 {
+		return DefaultOnsetDetector.HopSize
+	}
+
+	return d.HopSize
+}
+
+// Returns the time of the centre of a frame.
+func (e Envelope) At(frame int) time.Duration {
+	return time.Duration(float64(frame) / e.Rate * float64(time.Second))
+}
+
+// Returns the frame nearest to a time.
+func (e Envelope) Frame(t time.Duration) int {
+	return int(math.Round(t.Seconds() * e.Rate))
+}
+
+// Returns the interval between frames.
+func (e Envelope) Period() time.Duration {
+	return time.Duration(float64(time.Second) / e.Rate)
+}
+
+// Returns half the frame duration i.e. the resolution of the onset strength envelope.
+func (e Envelope) window() time.Duration {
+	return time.Duration(float64(e.frame) / 2.0 / float64(e.sampleRate) * float64(time.Second))
+}
+
+// Returns the time of the sharpest rise in the short term (1ms) energy of the audio within
+// the window around the supplied time, or the supplied time if the envelope has no audio.
+func (e Envelope) Refine(t time.Duration, window time.Duration) time.Duration {
+	if len(e.samples) == 0 || e.sampleRate == 0 {
+		return t
+	}
+
+	block := e.sampleRate / 1000
+	if block < 1 {
+		block = 1
+	}
+
+	step := block / 4
+	if step < 1 {
+		step = 1
+	}
+
+	centre := int(math.Round(t.Seconds() * float64(e.sampleRate)))
+	w := int(math.Round(window.Seconds() * float64(e.sampleRate)))
+	start := centre - w - block
+	end := centre + w
+	if start < 0 {
+		start = 0
+	}
+
+	if end > len(e.samples)-block {
+		end = len(e.samples) - block
+	}
+
+	energy := func(k int) float64 {
+		sum := 0.0
+		for _, v := range e.samples[k : k+block] {
+			sum += float64(v) * float64(v)
+		}
+		return sum
+	}
+
+	best := -1
+	rise := 0.0
+	for k := start + block; k <= end; k += step {
+		if d := energy(k) - energy(k-block); d > rise {
+			best = k
+			rise = d
+		}
+	}
+
+	if best < 0 {
+		return t
+	}
+
+	return time.Duration(float64(best) / float64(e.sampleRate) * float64(time.Second))
+}
@@ -0,0 +1,108 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestOnsets(t *testing.T) {
+	expected := []time.Duration{}
+	for i := 0; i < 8; i++ {
+		expected = append(expected, 316*time.Millisecond+time.Duration(i)*526*time.Millisecond)
+	}
+
+	wav := clicks(44100, 5*time.Second, expected)
+	onsets := DefaultOnsetDetector.Onsets(wav)
+
+	if len(onsets) != len(expected) {
+		t.Fatalf("Incorrect number of onsets - expected:%v, got:%v (%v)", len(expected), len(onsets), onsets)
+	}
+
+	for i, v := range expected {
+		if dt := onsets[i] - v; dt < -time.Millisecond || dt > time.Millisecond {
+			t.Errorf("Incorrect onset %d - expected:%v, got:%v", i+1, v, onsets[i])
+		}
+	}
+}
+
+func TestOnsetsWithDefaultSettings(t *testing.T) {
+	expected := []time.Duration{500 * time.Millisecond, 1000 * time.Millisecond, 1500 * time.Millisecond}
+
+	wav := clicks(44100, 2*time.Second, expected)
+	onsets := OnsetDetector{Threshold: 0.1}.Onsets(wav)
+
+	if len(onsets) != len(expected) {
+		t.Fatalf("Incorrect number of onsets - expected:%v, got:%v (%v)", len(expected), len(onsets), onsets)
+	}
+
+	if e := (OnsetDetector{}).Envelope(wav); e.Rate != 44100.0/256 {
+		t.Errorf("Incorrect envelope rate - expected:%v, got:%v", 44100.0/256, e.Rate)
+	}
+}
+
+func TestTaps(t *testing.T) {
+	expected := []time.Duration{500 * time.Millisecond, 1000 * time.Millisecond, 1500 * time.Millisecond}
+
+	taps := Taps(clicks(48000, 2*time.Second, expected))
+
+	if len(taps) != 1 || len(taps[0]) != len(expected) {
+		t.Fatalf("Invalid taps - expected:1 loop with %v taps, got:%v", len(expected), taps)
+	}
+}
+
+func TestOnsetsWithSilence(t *testing.T) {
+	wav := WAV{
+		SampleRate: 44100,
+		Samples:    [][]float32{make([]float32, 44100)},
+	}
+
+	if onsets := DefaultOnsetDetector.Onsets(&wav); len(onsets) != 0 {
+		t.Errorf("Unexpected onsets - expected:none, got:%v", onsets)
+	}
+}
+
+func TestFFT(t *testing.T) {
+	x := make([]complex128, 16)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*3*float64(i)/16), 0)
+	}
+
+	fft(x)
+
+	for k, v := range x {
+		expected := 0.0
+		if k == 3 || k == 13 {
+			expected = 8.0
+		}
+
+		if math.Abs(math.Hypot(real(v), imag(v))-expected) > 0.000001 {
+			t.Errorf("Incorrect FFT bin %d - expected:%v, got:%v", k, expected, v)
+		}
+	}
+}
+
+// Generates a (quiet) noise floor with decaying noise bursts at the supplied times.
+func clicks(rate int, duration time.Duration, at []time.Duration) *WAV {
+	N := int(duration.Seconds() * float64(rate))
+	samples := make([]float32, N)
+	r := rand.New(rand.NewSource(1))
+
+	for i := range samples {
+		samples[i] = float32(0.001 * (2*r.Float64() - 1))
+	}
+
+	for _, t := range at {
+		start := int(math.Round(t.Seconds() * float64(rate)))
+		for i := 0; i < rate/20 && start+i < N; i++ {
+			decay := math.Exp(-float64(i) / (0.005 * float64(rate)))
+			samples[start+i] += float32(0.8 * decay * (2*r.Float64() - 1))
+		}
+	}
+
+	return &WAV{
+		SampleRate: rate,
+		Samples:    [][]float32{samples},
+	}
+}
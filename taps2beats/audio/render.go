@@ -0,0 +1,247 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Settings for rendering a metronome click track from a set of beats.
+type ClickTrack struct {
+	SampleRate  int       // sample rate of the rendered audio
+	BeatsPerBar int       // beats per bar, for accenting the downbeats (0 for no accents)
+	Downbeat    int       // index of the first downbeat in the list of beats
+	CountIn     int       // number of count-in clicks before the first beat
+	Click       []float32 // click sound (mono, at SampleRate)
+	Accent      []float32 // accented click sound for downbeats (mono, at SampleRate)
+	Source      *WAV      // source audio to mix with the clicks (optional)
+	Gain        float64   // gain applied to the clicks when mixing with the source audio
+}
+
+// Returns a click track with the default click sounds i.e. short decaying 1kHz and 1.5kHz (accented)
+// sine waves and 4 beats per bar.
+func NewClickTrack(sampleRate int) ClickTrack {
+	return ClickTrack{
+		SampleRate:  sampleRate,
+		BeatsPerBar: 4,
+		Click:       Tone(sampleRate, 1000, 30*time.Millisecond),
+		Accent:      Tone(sampleRate, 1500, 30*time.Millisecond),
+		Gain:        0.5,
+	}
+}
+
+// Generates a short exponentially decaying sine wave for use as a click sound.
+func Tone(sampleRate int, frequency float64, duration time.Duration) []float32 {
+	N := int(duration.Seconds() * float64(sampleRate))
+	tone := make([]float32, N)
+	tau := duration.Seconds() / 5.0
+
+	for i := range tone {
+		t := float64(i) / float64(sampleRate)
+		tone[i] = float32(0.9 * math.Exp(-t/tau) * math.Sin(2*math.Pi*frequency*t))
+	}
+
+	return tone
+}
+
+// Renders a click track with a click at every beat (accented on the downbeats), preceded by the
+// count-in clicks (if any) and mixed with the source audio (if any). If the count-in starts before
+// 0s, the rendered audio (including the source audio) is delayed by the count-in lead time.
+func (c ClickTrack) Render(beats taps2beats.Beats) *WAV {
+	rate := c.SampleRate
+	if rate <= 0 {
+		rate = 44100
+	}
+
+	clicks := []time.Duration{}
+	accents := []bool{}
+
+	var period time.Duration
+	switch N := len(beats.Beats); {
+	case beats.BPM > 0:
+		period = time.Minute / time.Duration(beats.BPM)
+
+	case N > 1:
+		period = (beats.Beats[N-1].At - beats.Beats[0].At) / time.Duration(N-1)
+	}
+
+	if period > 0 && c.CountIn > 0 && len(beats.Beats) > 0 {
+		for i := c.CountIn; i > 0; i-- {
+			clicks = append(clicks, beats.Beats[0].At-time.Duration(i)*period)
+			accents = append(accents, i == c.CountIn)
+		}
+	}
+
+	for i, b := range beats.Beats {
+		clicks = append(clicks, b.At)
+		accents = append(accents, c.BeatsPerBar > 0 && mod(i-c.Downbeat, c.BeatsPerBar) == 0)
+	}
+
+	lead := time.Duration(0)
+	if len(clicks) > 0 && clicks[0] < 0 {
+		lead = -clicks[0]
+	}
+
+	var source []float32
+	if c.Source != nil {
+		source = resample(c.Source.Mono(), c.Source.SampleRate, rate)
+	}
+
+	N := 0
+	if len(clicks) > 0 {
+		N = index(clicks[len(clicks)-1]+lead+time.Second, rate)
+	}
+
+	if M := index(lead, rate) + len(source); M > N {
+		N = M
+	}
+
+	samples := make([]float32, N)
+
+	gain := float32(1.0)
+	if source != nil {
+		offset := index(lead, rate)
+		for i, v := range source {
+			samples[offset+i] = v
+		}
+
+		gain = float32(c.Gain)
+	}
+
+	for i, t := range clicks {
+		sound := c.Click
+		if accents[i] && c.Accent != nil {
+			sound = c.Accent
+		}
+
+		offset := index(t+lead, rate)
+		for j, v := range sound {
+			if k := offset + j; k >= 0 && k < N {
+				samples[k] += gain * v
+			}
+		}
+	}
+
+	for i, v := range samples {
+		if v > 1.0 {
+			samples[i] = 1.0
+		} else if v < -1.0 {
+			samples[i] = -1.0
+		}
+	}
+
+	return &WAV{
+		SampleRate:    rate,
+		BitsPerSample: 16,
+		Samples:       [][]float32{samples},
+	}
+}
+
+// Writes the audio as a WAV file, encoded as PCM (16, 24 or 32 bit) or as 32 bit IEEE float.
+func WriteWAV(w io.Writer, wav *WAV, bits int, float bool) error {
+	var format uint16 = formatPCM
+	switch {
+	case float && bits == 32:
+		format = formatFloat
+	case !float && (bits == 16 || bits == 24 || bits == 32):
+	default:
+		return fmt.Errorf("unsupported WAV format (bits per sample:%v, float:%v)", bits, float)
+	}
+
+	channels := len(wav.Samples)
+	N := 0
+	if channels > 0 {
+		N = len(wav.Samples[0])
+	}
+
+	width := bits / 8
+	size := N * channels * width
+
+	b := bufio.NewWriter(w)
+	b.WriteString("RIFF")
+	binary.Write(b, binary.LittleEndian, uint32(4+8+16+8+size+size%2))
+	b.WriteString("WAVE")
+	b.WriteString("fmt ")
+	binary.Write(b, binary.LittleEndian, uint32(16))
+	binary.Write(b, binary.LittleEndian, format)
+	binary.Write(b, binary.LittleEndian, uint16(channels))
+	binary.Write(b, binary.LittleEndian, uint32(wav.SampleRate))
+	binary.Write(b, binary.LittleEndian, uint32(wav.SampleRate*channels*width))
+	binary.Write(b, binary.LittleEndian, uint16(channels*width))
+	binary.Write(b, binary.LittleEndian, uint16(bits))
+	b.WriteString("data")
+	binary.Write(b, binary.LittleEndian, uint32(size))
+
+	sample := make([]byte, width)
+	for i := 0; i < N; i++ {
+		for ch := 0; ch < channels; ch++ {
+			v := float64(wav.Samples[ch][i])
+			switch {
+			case float:
+				binary.LittleEndian.PutUint32(sample, math.Float32bits(float32(v)))
+			case bits == 16:
+				binary.LittleEndian.PutUint16(sample, uint16(int16(quantize(v, 32767))))
+			case bits == 24:
+				u := uint32(int32(quantize(v, 8388607)))
+				sample[0], sample[1], sample[2] = byte(u), byte(u>>8), byte(u>>16)
+			case bits == 32:
+				binary.LittleEndian.PutUint32(sample, uint32(int32(quantize(v, 2147483647))))
+			}
+
+			b.Write(sample)
+		}
+	}
+
+	if size%2 != 0 {
+		b.WriteByte(0)
+	}
+
+	return b.Flush()
+}
+
+// Linear interpolation resampling.
+func resample(samples []float32, from, to int) []float32 {
+	if from == to || from <= 0 || len(samples) == 0 {
+		return samples
+	}
+
+	N := int(float64(len(samples)) * float64(to) / float64(from))
+	resampled := make([]float32, N)
+	ratio := float64(from) / float64(to)
+
+	for i := range resampled {
+		x := float64(i) * ratio
+		j := int(x)
+		f := float32(x - float64(j))
+
+		if j+1 < len(samples) {
+			resampled[i] = samples[j]*(1-f) + samples[j+1]*f
+		} else {
+			resampled[i] = samples[len(samples)-1]
+		}
+	}
+
+	return resampled
+}
+
+// Resamples a click sound to the sample rate of a click track.
+func (c ClickTrack) Resample(sound *WAV) []float32 {
+	return resample(sound.Mono(), sound.SampleRate, c.SampleRate)
+}
+
+func quantize(v float64, scale float64) float64 {
+	return math.Round(math.Max(-1.0, math.Min(1.0, v)) * scale)
+}
+
+func index(t time.Duration, rate int) int {
+	return int(math.Round(t.Seconds() * float64(rate)))
+}
+
+func mod(i, n int) int {
+	return ((i % n) + n) % n
+}
@@ -0,0 +1,117 @@
+package audio
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestRender(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM: 120,
+		Beats: []taps2beats.Beat{
+			{At: 500 * time.Millisecond},
+			{At: 1000 * time.Millisecond},
+			{At: 1500 * time.Millisecond},
+			{At: 2000 * time.Millisecond},
+			{At: 2500 * time.Millisecond},
+		},
+	}
+
+	track := NewClickTrack(8000)
+	track.Click = []float32{0.25}
+	track.Accent = []float32{0.75}
+	track.CountIn = 2
+
+	wav := track.Render(beats)
+
+	if wav.SampleRate != 8000 {
+		t.Errorf("Incorrect sample rate - expected:%v, got:%v", 8000, wav.SampleRate)
+	}
+
+	// ... count-in starts at -0.5s so everything is delayed by 0.5s
+	expected := map[int]float32{0: 0.75, 4000: 0.25, 8000: 0.75, 12000: 0.25, 16000: 0.25, 20000: 0.25, 24000: 0.75}
+	for i, v := range wav.Samples[0] {
+		if e := expected[i]; v != e {
+			t.Errorf("Incorrect sample %d - expected:%v, got:%v", i, e, v)
+		}
+	}
+
+	if N := len(wav.Samples[0]); N != 32000 {
+		t.Errorf("Incorrect length - expected:%v, got:%v", 32000, N)
+	}
+}
+
+func TestRenderWithoutBeats(t *testing.T) {
+	track := NewClickTrack(8000)
+	track.CountIn = 4
+
+	wav := track.Render(taps2beats.Beats{BPM: 120})
+
+	if N := len(wav.Samples[0]); N != 0 {
+		t.Errorf("Incorrect length - expected:%v, got:%v", 0, N)
+	}
+}
+
+func TestRenderWithSource(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: []taps2beats.Beat{
+			{At: 250 * time.Millisecond},
+		},
+	}
+
+	source := make([]float32, 16000)
+	for i := range source {
+		source[i] = 0.1
+	}
+
+	track := NewClickTrack(8000)
+	track.Click = []float32{0.5}
+	track.Accent = []float32{0.5}
+	track.Source = &WAV{SampleRate: 16000, Samples: [][]float32{source}}
+
+	wav := track.Render(beats)
+
+	if N := len(wav.Samples[0]); N != 10000 {
+		t.Fatalf("Incorrect length - expected:%v, got:%v", 10000, N)
+	}
+
+	if v := wav.Samples[0][2000]; math.Abs(float64(v)-0.35) > 0.00001 {
+		t.Errorf("Incorrect mix - expected:%v, got:%v", 0.35, v)
+	}
+
+	if v := wav.Samples[0][4000]; math.Abs(float64(v)-0.1) > 0.00001 {
+		t.Errorf("Incorrect mix - expected:%v, got:%v", 0.1, v)
+	}
+}
+
+func TestWriteWAV(t *testing.T) {
+	samples := [][]float32{{0.0, 0.5, -0.5, 0.25, -1.0}}
+
+	for _, f := range []struct {
+		bits  int
+		float bool
+	}{{16, false}, {24, false}, {32, false}, {32, true}} {
+		var b bytes.Buffer
+
+		if err := WriteWAV(&b, &WAV{SampleRate: 44100, Samples: samples}, f.bits, f.float); err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		wav, err := DecodeWAV(b.Bytes())
+		if err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		expected := [][]float64{{0.0, 0.5, -0.5, 0.25, -1.0}}
+
+		check(wav, 44100, f.bits, expected, 0.0001, t)
+	}
+
+	if err := WriteWAV(&bytes.Buffer{}, &WAV{SampleRate: 44100, Samples: samples}, 64, false); err == nil {
+		t.Errorf("Expected error for unsupported format")
+	}
+}
@@ -0,0 +1,88 @@
+package audio
+
+import (
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Correction applied to a single beat when snapping the beats to the audio onsets.
+type Correction struct {
+	Beat       int           // index of the beat in the Beats list
+	From       time.Duration // original beat time
+	To         time.Duration // corrected beat time (unchanged if the beat was not snapped)
+	Confidence float64       // confidence (0.0 to 1.0) in the onset the beat was snapped to
+	Snapped    bool          // true if the beat was moved to an onset
+}
+
+// Moves each beat to the strongest transient in the onset strength envelope within the window around
+// the beat, provided the confidence in the transient is at least the threshold. The confidence is the
+// (normalized) strength of the transient weighted by its prominence above the mean strength in the
+// window. Snapped beat times are refined to the sharpest rise in the short term energy of the audio.
+//
+// The BPM and offset are not re-estimated, and the mean, variance and 'taps' of each beat are unchanged.
+// Returns the correction applied to each beat.
+func (e Envelope) Snap(beats *taps2beats.Beats, window time.Duration, threshold float64) []Correction {
+	if beats == nil {
+		return []Correction{}
+	}
+
+	corrections := make([]Correction, len(beats.Beats))
+	for i, b := range beats.Beats {
+		corrections[i] = Correction{
+			Beat: i,
+			From: b.At,
+			To:   b.At,
+		}
+
+		start := e.Frame(b.At - window)
+		end := e.Frame(b.At + window)
+		if start < 0 {
+			start = 0
+		}
+
+		if end > len(e.Values)-1 {
+			end = len(e.Values) - 1
+		}
+
+		peak := -1
+		sum := 0.0
+		for j := start; j <= end; j++ {
+			sum += e.Values[j]
+			if peak < 0 || e.Values[j] > e.Values[peak] {
+				peak = j
+			}
+		}
+
+		// ... no transient, or the strongest transient is at the edge of the window (i.e. probably
+		//     belongs to an adjacent onset)
+		if peak < 0 || e.Values[peak] <= 0 || (peak == start && start > 0) || (peak == end && end < len(e.Values)-1) {
+			continue
+		}
+
+		mean := sum / float64(end-start+1)
+		confidence := e.Values[peak] * (1.0 - mean/e.Values[peak])
+
+		corrections[i].Confidence = confidence
+
+		if confidence >= threshold {
+			at := e.Refine(e.At(peak), e.window())
+			if at < b.At-window {
+				at = b.At - window
+			} else if at > b.At+window {
+				at = b.At + window
+			}
+
+			beats.Beats[i].At = at
+			corrections[i].To = at
+			corrections[i].Snapped = true
+		}
+	}
+
+	return corrections
+}
+
+// Returns the time difference between the corrected and original beat.
+func (c Correction) Delta() time.Duration {
+	return c.To - c.From
+}
@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestSnap(t *testing.T) {
+	onsets := []time.Duration{500 * time.Millisecond, 1000 * time.Millisecond, 1500 * time.Millisecond}
+	envelope := DefaultOnsetDetector.Envelope(clicks(44100, 2500*time.Millisecond, onsets))
+
+	beats := taps2beats.Beats{
+		Beats: []taps2beats.Beat{
+			{At: 487 * time.Millisecond},
+			{At: 1021 * time.Millisecond},
+			{At: 1503 * time.Millisecond},
+			{At: 2000 * time.Millisecond},
+		},
+	}
+
+	corrections := envelope.Snap(&beats, 40*time.Millisecond, 0.3)
+
+	if len(corrections) != 4 {
+		t.Fatalf("Incorrect number of corrections - expected:%v, got:%v", 4, len(corrections))
+	}
+
+	for i, v := range onsets {
+		if !corrections[i].Snapped {
+			t.Errorf("Beat %d not snapped (confidence %.3f)", i+1, corrections[i].Confidence)
+		}
+
+		if dt := beats.Beats[i].At - v; dt < -time.Millisecond || dt > time.Millisecond {
+			t.Errorf("Incorrect beat %d - expected:%v, got:%v", i+1, v, beats.Beats[i].At)
+		}
+
+		if corrections[i].Delta() != beats.Beats[i].At-corrections[i].From {
+			t.Errorf("Incorrect correction %d - expected:%v, got:%v", i+1, beats.Beats[i].At-corrections[i].From, corrections[i].Delta())
+		}
+	}
+
+	if corrections[3].Snapped || beats.Beats[3].At != 2000*time.Millisecond {
+		t.Errorf("Beat without an onset unexpectedly snapped - expected:%v, got:%v", 2000*time.Millisecond, beats.Beats[3].At)
+	}
+}
+
+func TestSnapWithLowConfidence(t *testing.T) {
+	onsets := []time.Duration{500 * time.Millisecond}
+	envelope := DefaultOnsetDetector.Envelope(clicks(44100, time.Second, onsets))
+
+	beats := taps2beats.Beats{
+		Beats: []taps2beats.Beat{
+			{At: 510 * time.Millisecond},
+		},
+	}
+
+	corrections := envelope.Snap(&beats, 40*time.Millisecond, 1.1)
+
+	if corrections[0].Snapped || beats.Beats[0].At != 510*time.Millisecond {
+		t.Errorf("Low confidence beat unexpectedly snapped - expected:%v, got:%v", 510*time.Millisecond, beats.Beats[0].At)
+	}
+}
@@ -0,0 +1,208 @@
+// Audio functions for using an audio file as a source of 'taps' and for refining and checking
+// the estimated beats against the audio.
+//
+// The WAV implementation is a minimal pure Go reader for uncompressed PCM (8, 16, 24 and 32 bit)
+// and IEEE float (32 and 64 bit) WAV files, including WAVE_FORMAT_EXTENSIBLE files.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+)
+
+// Decoded audio from a WAV file, with the samples normalized to the range -1.0 to +1.0.
+type WAV struct {
+	SampleRate    int         // samples per second
+	BitsPerSample int         // bits per sample of the original encoding
+	Float         bool        // true if the original encoding was IEEE float
+	Samples       [][]float32 // samples for each channel
+}
+
+const (
+	formatPCM        = 0x0001
+	formatFloat      = 0x0003
+	formatExtensible = 0xfffe
+)
+
+// Reads and decodes a WAV file.
+func ReadWAV(r io.Reader) (*WAV, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV file")
+	}
+
+	var format, channels, bits uint16
+	var rate uint32
+	var data []byte
+	var gotfmt bool
+
+	chunks := b[12:]
+	for len(chunks) >= 8 {
+		id := string(chunks[0:4])
+		size := int(binary.LittleEndian.Uint32(chunks[4:8]))
+		chunk := chunks[8:]
+		if size > len(chunk) {
+			size = len(chunk) // ... truncated file (or streamed WAV with an invalid size)
+		}
+
+		chunk = chunk[:size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid 'fmt ' chunk")
+			}
+
+			format = binary.LittleEndian.Uint16(chunk[0:2])
+			channels = binary.LittleEndian.Uint16(chunk[2:4])
+			rate = binary.LittleEndian.Uint32(chunk[4:8])
+			bits = binary.LittleEndian.Uint16(chunk[14:16])
+
+			if format == formatExtensible {
+				if size < 40 {
+					return nil, fmt.Errorf("invalid WAVE_FORMAT_EXTENSIBLE 'fmt ' chunk")
+				}
+
+				format = binary.LittleEndian.Uint16(chunk[24:26])
+			}
+
+			gotfmt = true
+
+		case "data":
+			data = chunk
+		}
+
+		skip := 8 + size + size%2
+		if skip > len(chunks) {
+			break
+		}
+
+		chunks = chunks[skip:]
+	}
+
+	switch {
+	case !gotfmt:
+		return nil, fmt.Errorf("missing 'fmt ' chunk")
+
+	case data == nil:
+		return nil, fmt.Errorf("missing 'data' chunk")
+
+	case channels == 0:
+		return nil, fmt.Errorf("invalid number of channels (%v)", channels)
+
+	case rate == 0:
+		return nil, fmt.Errorf("invalid sample rate (%v)", rate)
+	}
+
+	decode, err := decoder(format, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	width := int(bits) / 8
+	frame := width * int(channels)
+	N := len(data) / frame
+
+	wav := WAV{
+		SampleRate:    int(rate),
+		BitsPerSample: int(bits),
+		Float:         format == formatFloat,
+		Samples:       make([][]float32, channels),
+	}
+
+	for ch := range wav.Samples {
+		wav.Samples[ch] = make([]float32, N)
+	}
+
+	for i := 0; i < N; i++ {
+		for ch := 0; ch < int(channels); ch++ {
+			offset := i*frame + ch*width
+			wav.Samples[ch][i] = decode(data[offset : offset+width])
+		}
+	}
+
+	return &wav, nil
+}
+
+// Returns the duration of the audio.
+func (wav WAV) Duration() time.Duration {
+	if len(wav.Samples) == 0 || wav.SampleRate == 0 {
+		return 0
+	}
+
+	return time.Duration(len(wav.Samples[0])) * time.Second / time.Duration(wav.SampleRate)
+}
+
+// Returns the audio mixed down to a single channel.
+func (wav WAV) Mono() []float32 {
+	if len(wav.Samples) == 0 {
+		return []float32{}
+	}
+
+	if len(wav.Samples) == 1 {
+		return wav.Samples[0]
+	}
+
+	N := len(wav.Samples[0])
+	mono := make([]float32, N)
+	scale := 1.0 / float32(len(wav.Samples))
+
+	for _, samples := range wav.Samples {
+		for i, v := range samples {
+			mono[i] += v * scale
+		}
+	}
+
+	return mono
+}
+
+func decoder(format, bits uint16) (func([]byte) float32, error) {
+	switch {
+	case format == formatPCM && bits == 8:
+		return func(b []byte) float32 {
+			return (float32(b[0]) - 128.0) / 128.0
+		}, nil
+
+	case format == formatPCM && bits == 16:
+		return func(b []byte) float32 {
+			return float32(int16(binary.LittleEndian.Uint16(b))) / 32768.0
+		}, nil
+
+	case format == formatPCM && bits == 24:
+		return func(b []byte) float32 {
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			return float32(v) / 8388608.0
+		}, nil
+
+	case format == formatPCM && bits == 32:
+		return func(b []byte) float32 {
+			return float32(float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648.0)
+		}, nil
+
+	case format == formatFloat && bits == 32:
+		return func(b []byte) float32 {
+			return math.Float32frombits(binary.LittleEndian.Uint32(b))
+		}, nil
+
+	case format == formatFloat && bits == 64:
+		return func(b []byte) float32 {
+			return float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported WAV format (format:%v, bits per sample:%v)", format, bits)
+}
+
+// Utility function to decode a WAV file from a byte slice.
+func DecodeWAV(b []byte) (*WAV, error) {
+	return ReadWAV(bytes.NewReader(b))
+}
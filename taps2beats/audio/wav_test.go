@@ -0,0 +1,178 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReadWAV16(t *testing.T) {
+	samples := [][]float64{{0.0, 0.5, -0.5, 0.25}, {0.1, -0.1, 0.2, -0.2}}
+	wav, err := DecodeWAV(encode(formatPCM, 16, 44100, samples, false))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	check(wav, 44100, 16, samples, 0.0001, t)
+}
+
+func TestReadWAV24(t *testing.T) {
+	samples := [][]float64{{0.0, 0.5, -0.5, 0.25, -0.999}}
+	wav, err := DecodeWAV(encode(formatPCM, 24, 48000, samples, false))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	check(wav, 48000, 24, samples, 0.000001, t)
+}
+
+func TestReadWAV32(t *testing.T) {
+	samples := [][]float64{{0.0, 0.5, -0.5, 0.25, -0.999}}
+	wav, err := DecodeWAV(encode(formatPCM, 32, 48000, samples, false))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	check(wav, 48000, 32, samples, 0.000001, t)
+}
+
+func TestReadWAVFloat(t *testing.T) {
+	samples := [][]float64{{0.0, 0.5, -0.5, 0.25}, {0.125, -0.125, 1.0, -1.0}}
+
+	for _, bits := range []int{32, 64} {
+		wav, err := DecodeWAV(encode(formatFloat, bits, 22050, samples, false))
+		if err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		if !wav.Float {
+			t.Errorf("Incorrect format - expected:float, got:PCM")
+		}
+
+		check(wav, 22050, bits, samples, 0.000001, t)
+	}
+}
+
+func TestReadWAVExtensible(t *testing.T) {
+	samples := [][]float64{{0.0, 0.5, -0.5, 0.25}}
+	wav, err := DecodeWAV(encode(formatPCM, 24, 96000, samples, true))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	check(wav, 96000, 24, samples, 0.000001, t)
+}
+
+func TestReadWAVWithInvalidData(t *testing.T) {
+	if _, err := DecodeWAV([]byte("RIFF....WAVX")); err == nil {
+		t.Errorf("Expected error for invalid WAV file")
+	}
+
+	if _, err := DecodeWAV(encode(formatPCM, 12, 44100, [][]float64{{0.0}}, false)); err == nil {
+		t.Errorf("Expected error for unsupported bits per sample")
+	}
+}
+
+func TestWAVMono(t *testing.T) {
+	wav := WAV{
+		SampleRate: 4,
+		Samples:    [][]float32{{0.5, 1.0}, {0.5, 0.0}},
+	}
+
+	if mono := wav.Mono(); len(mono) != 2 || mono[0] != 0.5 || mono[1] != 0.5 {
+		t.Errorf("Incorrect mono mix - expected:%v, got:%v", []float32{0.5, 0.5}, mono)
+	}
+
+	if duration := wav.Duration(); duration != 500*time.Millisecond {
+		t.Errorf("Incorrect duration - expected:%v, got:%v", 500*time.Millisecond, duration)
+	}
+}
+
+func check(wav *WAV, rate, bits int, samples [][]float64, tolerance float64, t *testing.T) {
+	if wav.SampleRate != rate {
+		t.Errorf("Incorrect sample rate - expected:%v, got:%v", rate, wav.SampleRate)
+	}
+
+	if wav.BitsPerSample != bits {
+		t.Errorf("Incorrect bits per sample - expected:%v, got:%v", bits, wav.BitsPerSample)
+	}
+
+	if len(wav.Samples) != len(samples) {
+		t.Fatalf("Incorrect number of channels - expected:%v, got:%v", len(samples), len(wav.Samples))
+	}
+
+	for ch := range samples {
+		if len(wav.Samples[ch]) != len(samples[ch]) {
+			t.Fatalf("Incorrect number of samples - expected:%v, got:%v", len(samples[ch]), len(wav.Samples[ch]))
+		}
+
+		for i, v := range samples[ch] {
+			if math.Abs(float64(wav.Samples[ch][i])-v) > tolerance {
+				t.Errorf("Incorrect sample [%d][%d] - expected:%v, got:%v", ch, i, v, wav.Samples[ch][i])
+			}
+		}
+	}
+}
+
+// Encodes samples as a WAV file for testing.
+func encode(format, bits, rate int, samples [][]float64, extensible bool) []byte {
+	var data bytes.Buffer
+
+	channels := len(samples)
+	for i := range samples[0] {
+		for ch := 0; ch < channels; ch++ {
+			v := samples[ch][i]
+			switch {
+			case format == formatFloat && bits == 32:
+				binary.Write(&data, binary.LittleEndian, float32(v))
+			case format == formatFloat && bits == 64:
+				binary.Write(&data, binary.LittleEndian, v)
+			case bits == 16:
+				binary.Write(&data, binary.LittleEndian, int16(math.Round(v*32767)))
+			case bits == 24:
+				u := uint32(int32(math.Round(v * 8388607)))
+				data.Write([]byte{byte(u), byte(u >> 8), byte(u >> 16)})
+			case bits == 32:
+				binary.Write(&data, binary.LittleEndian, int32(math.Round(v*2147483647)))
+			default:
+				data.Write(make([]byte, (bits+7)/8))
+			}
+		}
+	}
+
+	var fmtchunk bytes.Buffer
+	width := (bits + 7) / 8
+	tag := uint16(format)
+	if extensible {
+		tag = formatExtensible
+	}
+
+	binary.Write(&fmtchunk, binary.LittleEndian, tag)
+	binary.Write(&fmtchunk, binary.LittleEndian, uint16(channels))
+	binary.Write(&fmtchunk, binary.LittleEndian, uint32(rate))
+	binary.Write(&fmtchunk, binary.LittleEndian, uint32(rate*channels*width))
+	binary.Write(&fmtchunk, binary.LittleEndian, uint16(channels*width))
+	binary.Write(&fmtchunk, binary.LittleEndian, uint16(bits))
+	if extensible {
+		binary.Write(&fmtchunk, binary.LittleEndian, uint16(22))
+		binary.Write(&fmtchunk, binary.LittleEndian, uint16(bits))
+		binary.Write(&fmtchunk, binary.LittleEndian, uint32(0))
+		binary.Write(&fmtchunk, binary.LittleEndian, uint16(format))
+		fmtchunk.Write([]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71})
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+8+fmtchunk.Len()+8+data.Len()))
+	b.WriteString("WAVE")
+	b.WriteString("fmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(fmtchunk.Len()))
+	b.Write(fmtchunk.Bytes())
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(data.Len()))
+	b.Write(data.Bytes())
+
+	return b.Bytes()
+}
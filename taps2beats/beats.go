@@ -28,16 +28,19 @@ type Beats struct {
 	Offset   time.Duration `json:"offset"`
 	Beats    []Beat        `json:"beats"`
 	Variance *float64      `json:"-"`
+	TimeBase *TimeBase     `json:"-"` // time base for String (defaults to Go durations)
 }
 
 // Contains the estimated time of a single beat, the mean and variance of the 'taps' that were
-// used to estimate the beat and a list of the 'taps' that were assigned to this beat.
+// used to estimate the beat and a list of the 'taps' that were assigned to this beat. Loops (if
+// known) is the index of the loop (row) in the input data of each of the 'taps'.
 type Beat struct {
 	beat     int             `json:"-"`
 	At       time.Duration   `json:"at"`
 	Mean     time.Duration   `json:"mean"`
 	Variance time.Duration   `json:"variance"`
 	Taps     []time.Duration `json:"taps"`
+	Loops    []int           `json:"-"`
 }
 
 // Used for marshaling and unmarshaling time as untyped seconds when marshaling and unmarshaling
@@ -82,28 +85,43 @@ const (
 // Clusters the provided 'taps' into an optimal set of beats and estimates the average BPM and the offset of the
 // first beats (on the assumption that the BPM is fixed).
 //
-// The supplied list of 'taps' may contain multiple loops, with each loop being a separate row in the list.
+// The supplied set of 'taps' may contain multiple loops, with the 'taps' in each loop weighted by the loop and 'tap'
+// weights (e.g. to discount the 'taps' from a less reliable tapper or to use the velocity of a MIDI note as a measure
+// of confidence) and adjusted for the loop start and latency.
 //
 // The forgetting factor is used to discount earlier loops in favour of later loops, on the grounds that later 'taps'
 // will probably be more accurate. A forgetting factor of 0.0 assumes all taps are equally accurate, while a value of
 // 0.1 discounts each loop by 10% over the subsequent loop. A forgetting factor of -0.1 discounts each subsequent loop
 // by 10% over the preceding loop.
-func Taps2Beats(taps [][]time.Duration, forgetting float64) Beats {
+func Taps2Beats(set TapSet, forgetting float64) Beats {
+	taps, weighting := set.taps()
+
 	data := []float64{}
-	for _, row := range taps {
+	loops := []int{}
+	for i, row := range taps {
 		for _, t := range row {
 			data = append(data, t.Seconds())
+			loops = append(loops, i)
+		}
+	}
+
+	w := weights(taps, forgetting)
+	ix := 0
+	for _, row := range weighting {
+		for _, v := range row {
+			w[ix] *= v
+			ix++
 		}
 	}
 
-	clusters := ckmeans.CKMeans1dDp(data, weights(taps, forgetting))
+	clusters := ckmeans.CKMeans1dDp(data, w)
 
 	beats := make([]Beat, len(clusters))
 	for i, cluster := range clusters {
-		beats[i] = makeBeat(cluster.Center, cluster)
+		beats[i] = makeBeat(cluster.Center, cluster, loops)
 	}
 
-	BPM, offset := bpm(beats)
+	BPM, offset := bpm(beats, Tempo{})
 
 	sort.SliceStable(beats, func(i, j int) bool { return beats[i].At < beats[j].At })
 
@@ -127,9 +145,53 @@ func Taps2Beats(taps [][]time.Duration, forgetting float64) Beats {
 	return result
 }
 
+// Converts a list of onsets (e.g. from an audio onset detector or another beat detection algorithm) to beats
+// without clustering i.e. each onset is a beat with a single 'tap'. Clustering a single list of onsets is not
+// useful because there are no repeated observations of each beat. The onsets are adjusted for the loop start
+// and latency and the onsets from all the loops in the set are combined.
+func Onsets2Beats(set TapSet) Beats {
+	taps, _ := set.taps()
+
+	beats := []Beat{}
+	for i, row := range taps {
+		for _, t := range row {
+			beats = append(beats, Beat{
+				At:    t,
+				Mean:  t,
+				Taps:  []time.Duration{t},
+				Loops: []int{i},
+			})
+		}
+	}
+
+	sort.SliceStable(beats, func(i, j int) bool { return beats[i].At < beats[j].At })
+
+	BPM, offset := bpm(beats, Tempo{})
+
+	result := Beats{
+		BPM:    BPM,
+		Offset: offset,
+		Beats:  beats,
+	}
+
+	if len(beats) > 0 {
+		variance := 0.0
+		result.Variance = &variance
+	}
+
+	return result
+}
+
 // Adjusts the times of the beats by performing a least squares reqression to fit the estimated beats to
 // a straight line (on the assumption that the BPM is reasonably constant).
 func (beats *Beats) Quantize() error {
+	return beats.QuantizeWithTempo(Tempo{})
+}
+
+// Adjusts the times of the beats by performing a least squares reqression to fit the estimated beats to
+// a straight line, with the gradient of the line constrained to the supplied tempo. A fixed tempo fits only
+// the offset of the beats.
+func (beats *Beats) QuantizeWithTempo(tempo Tempo) error {
 	switch {
 	case beats == nil:
 		return nil
@@ -139,13 +201,13 @@ func (beats *Beats) Quantize() error {
 		beats.Offset = 0 * time.Millisecond
 		return nil
 
-	case len(beats.Beats) < 2:
+	case len(beats.Beats) < 2 && !tempo.IsFixed():
 		beats.BPM = 0
 		beats.Offset = beats.Beats[0].At
 		return nil
 
 	default:
-		m, c, err := fit(beats.Beats)
+		m, c, err := fit(beats.Beats, tempo)
 		if err != nil {
 			return err
 		}
@@ -153,14 +215,16 @@ func (beats *Beats) Quantize() error {
 		quantized := []Beat{}
 		for _, b := range beats.Beats {
 			quantized = append(quantized, Beat{
+				beat:     b.beat,
 				At:       Seconds(float64(b.beat)*m + c),
 				Mean:     b.Mean,
 				Variance: b.Variance,
 				Taps:     b.Taps,
+				Loops:    b.Loops,
 			})
 		}
 
-		beats.BPM, beats.Offset = bpm(quantized)
+		beats.BPM, beats.Offset = bpm(quantized, tempo)
 		beats.Beats = quantized
 
 		return nil
@@ -170,6 +234,13 @@ func (beats *Beats) Quantize() error {
 // Estimates beats that are not in the provided list by using least squares regression to fit the beats
 // to a straight line (assumes the BPM is reasonably constant).
 func (beats *Beats) Interpolate(start, end time.Duration) error {
+	return beats.InterpolateWithTempo(start, end, Tempo{})
+}
+
+// Estimates beats that are not in the provided list by using least squares regression to fit the beats
+// to a straight line, with the gradient of the line constrained to the supplied tempo. A fixed tempo fits
+// only the offset of the beats.
+func (beats *Beats) InterpolateWithTempo(start, end time.Duration, tempo Tempo) error {
 	switch {
 	case beats == nil:
 		return nil
@@ -177,11 +248,15 @@ func (beats *Beats) Interpolate(start, end time.Duration) error {
 	case len(beats.Beats) == 0:
 		return fmt.Errorf("Insufficient data")
 
-	case len(beats.Beats) == 1 && beats.BPM == 0:
+	case len(beats.Beats) == 1 && beats.BPM == 0 && !tempo.IsFixed():
 		return fmt.Errorf("Insufficient data")
 
 	case len(beats.Beats) == 1:
 		m := 60.0 / float64(beats.BPM)
+		if tempo.IsFixed() {
+			m = tempo.period()
+		}
+
 		c := beats.Beats[0].At.Seconds() - m
 		bmin := int(math.Floor((start.Seconds() - c) / m))
 		bmax := int(math.Ceil((end.Seconds() - c) / m))
@@ -191,20 +266,22 @@ func (beats *Beats) Interpolate(start, end time.Duration) error {
 			tt := float64(b)*m + c
 			if tt >= start.Seconds() && tt <= end.Seconds() {
 				if b == 1 {
-					interpolated = append(interpolated, beats.Beats[0])
+					beat := beats.Beats[0]
+					beat.beat = 1
+					interpolated = append(interpolated, beat)
 				} else {
-					interpolated = append(interpolated, Beat{At: Seconds(tt)})
+					interpolated = append(interpolated, Beat{beat: b, At: Seconds(tt)})
 				}
 			}
 		}
 
-		beats.BPM, beats.Offset = bpm(interpolated)
+		beats.BPM, beats.Offset = bpm(interpolated, tempo)
 		beats.Beats = interpolated
 
 		return nil
 
 	default:
-		m, c, err := fit(beats.Beats)
+		m, c, err := fit(beats.Beats, tempo)
 		if err != nil {
 			return err
 		}
@@ -224,18 +301,26 @@ func (beats *Beats) Interpolate(start, end time.Duration) error {
 				if beat, ok := index[b]; ok {
 					interpolated = append(interpolated, beat)
 				} else {
-					interpolated = append(interpolated, Beat{At: Seconds(tt)})
+					interpolated = append(interpolated, Beat{beat: b, At: Seconds(tt)})
 				}
 			}
 		}
 
-		beats.BPM, beats.Offset = bpm(interpolated)
+		beats.BPM, beats.Offset = bpm(interpolated, tempo)
 		beats.Beats = interpolated
 
 		return nil
 	}
 }
 
+// Re-estimates the BPM and offset of the beats with the BPM constrained to the supplied tempo, without
+// adjusting the beats themselves.
+func (beats *Beats) EstimateTempo(tempo Tempo) {
+	if beats != nil {
+		beats.BPM, beats.Offset = bpm(beats.Beats, tempo)
+	}
+}
+
 // Discards 'outlier' beats in a desperate attempt to obtain a better estimate of the beats and BPM.
 // Outlier beats are identified as those beats which have 'fewer than expected' taps, where 'fewer
 // than expected' is defined as less than a third of the median.
@@ -247,6 +332,12 @@ func (beats *Beats) Interpolate(start, end time.Duration) error {
 // heuristics should only be used when the forgetting factor is zero i.e. all taps are equally
 // weighted.
 func (beats *Beats) Clean() (Beats, error) {
+	return beats.CleanWithTempo(Tempo{})
+}
+
+// Discards 'outlier' beats (as for Clean), with the BPM of the cleaned beats re-estimated within the
+// constraints of the supplied tempo.
+func (beats *Beats) CleanWithTempo(tempo Tempo) (Beats, error) {
 	// ... calculate the median taps per beat
 	taps := []int{}
 	for _, beat := range beats.Beats {
@@ -273,10 +364,16 @@ func (beats *Beats) Clean() (Beats, error) {
 	// ... discard any beats with 'too few taps'
 	fence := median / 3.0
 	data := []float64{}
+	loops := []int{}
 	for _, beat := range beats.Beats {
 		if float64(len(beat.Taps)) >= fence {
-			for _, t := range beat.Taps {
+			for j, t := range beat.Taps {
 				data = append(data, t.Seconds())
+				if j < len(beat.Loops) {
+					loops = append(loops, beat.Loops[j])
+				} else {
+					loops = append(loops, -1)
+				}
 			}
 		}
 	}
@@ -290,10 +387,10 @@ func (beats *Beats) Clean() (Beats, error) {
 
 	cleaned := make([]Beat, len(clusters))
 	for i, cluster := range clusters {
-		cleaned[i] = makeBeat(cluster.Center, cluster)
+		cleaned[i] = makeBeat(cluster.Center, cluster, loops)
 	}
 
-	BPM, offset := bpm(cleaned)
+	BPM, offset := bpm(cleaned, tempo)
 
 	sort.SliceStable(cleaned, func(i, j int) bool { return cleaned[i].At < cleaned[j].At })
 
@@ -386,9 +483,29 @@ func (beats Beats) MarshalJSON() ([]byte, error) {
 	return json.Marshal(b)
 }
 
-// Custom JSON unmarshaler for the Beats struct that unmarshals times stored as (float) seconds.
+// Custom JSON unmarshaler for the Beats struct that unmarshals times stored as (float) seconds. Also
+// accepts a versioned beats Document.
 func (beats *Beats) UnmarshalJSON(bytes []byte) error {
 	if beats != nil {
+		v := struct {
+			Version int `json:"version"`
+		}{}
+
+		if err := json.Unmarshal(bytes, &v); err != nil {
+			return err
+		}
+
+		if v.Version != 0 {
+			d := Document{}
+			if err := json.Unmarshal(bytes, &d); err != nil {
+				return err
+			}
+
+			*beats = d.Beats
+
+			return nil
+		}
+
 		type beat struct {
 			At       instant   `json:"at"`
 			Mean     instant   `json:"mean"`
@@ -429,23 +546,28 @@ func (beats *Beats) UnmarshalJSON(bytes []byte) error {
 
 // Implementation of the Stringer interface.
 func (beats Beats) String() string {
+	tb := TimeBase{Units: UnitDuration}
+	if beats.TimeBase != nil {
+		tb = *beats.TimeBase
+	}
+
 	var width = 0
 
 	for _, beat := range beats.Beats {
-		if s := fmt.Sprintf("%v", beat.At); len(s) > width {
+		if s := tb.Format(beat.At); len(s) > width {
 			width = len(s)
 		}
 
-		if s := fmt.Sprintf("%v", beat.Mean); len(s) > width {
+		if s := tb.Format(beat.Mean); len(s) > width {
 			width = len(s)
 		}
 
-		if s := fmt.Sprintf("%v", beat.Variance); len(s) > width {
+		if s := tb.FormatInterval(beat.Variance); len(s) > width {
 			width = len(s)
 		}
 
 		for _, t := range beat.Taps {
-			if s := fmt.Sprintf("%v", t); len(s) > width {
+			if s := tb.Format(t); len(s) > width {
 				width = len(s)
 			}
 		}
@@ -454,18 +576,18 @@ func (beats Beats) String() string {
 	var b bytes.Buffer
 
 	fmt.Fprintf(&b, "BPM:    %d\n", beats.BPM)
-	fmt.Fprintf(&b, "Offset: %v\n", beats.Offset)
+	fmt.Fprintf(&b, "Offset: %v\n", tb.Format(beats.Offset))
 	fmt.Fprintln(&b)
 	for i, beat := range beats.Beats {
 		s := ""
 		s += fmt.Sprintf("%-3d", i+1)
-		s += fmt.Sprintf(" %-[1]*s", width, beat.At)
+		s += fmt.Sprintf(" %-[1]*s", width, tb.Format(beat.At))
 
 		if len(beat.Taps) > 0 {
-			s += fmt.Sprintf(" %-[1]*s", width, beat.Mean)
-			s += fmt.Sprintf(" %-[1]*s", width, beat.Variance)
+			s += fmt.Sprintf(" %-[1]*s", width, tb.Format(beat.Mean))
+			s += fmt.Sprintf(" %-[1]*s", width, tb.FormatInterval(beat.Variance))
 			for _, t := range beat.Taps {
-				s += fmt.Sprintf(" %-[1]*s", width, t)
+				s += fmt.Sprintf(" %-[1]*s", width, tb.Format(t))
 			}
 		}
 
@@ -521,12 +643,12 @@ func weights(taps [][]time.Duration, forgetting float64) []float64 {
 }
 
 // Estimate the BPM and offset of the first beats by applying least squares reqression to a set of beats.
-func bpm(beats []Beat) (uint, time.Duration) {
-	if len(beats) < 2 {
+func bpm(beats []Beat, tempo Tempo) (uint, time.Duration) {
+	if len(beats) < 2 && !(tempo.IsFixed() && len(beats) > 0) {
 		return 0, 0
 	}
 
-	m, c, err := fit(beats)
+	m, c, err := fit(beats, tempo)
 	if err != nil {
 		return 0, 0
 	}
@@ -546,13 +668,14 @@ func bpm(beats []Beat) (uint, time.Duration) {
 }
 
 // Performs a least squares reqression on a set of beats and returns the gradient
-// and offset of the calculated line.
-func fit(beats []Beat) (float64, float64, error) {
-	if len(beats) < 2 {
+// and offset of the calculated line. The gradient is constrained to the supplied
+// tempo, with only the offset being fitted for a fixed tempo.
+func fit(beats []Beat, tempo Tempo) (float64, float64, error) {
+	if len(beats) < 2 && !(tempo.IsFixed() && len(beats) > 0) {
 		panic("Insufficient data")
 	}
 
-	err := reindex(beats)
+	err := reindex(beats, tempo)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -564,15 +687,38 @@ func fit(beats []Beat) (float64, float64, error) {
 		t = append(t, b.At.Seconds())
 	}
 
+	if tempo.IsFixed() {
+		m := tempo.period()
+
+		return m, intercept(x, t, m), nil
+	}
+
 	m, c := regression.OrdinaryLeastSquares(x, t)
 
+	if p := tempo.constrain(m); p != m {
+		return p, intercept(x, t, p), nil
+	}
+
 	return m, c, nil
 }
 
+// Calculates the least squares offset of a line with a fixed gradient.
+func intercept(x, t []float64, m float64) float64 {
+	sum := 0.0
+	for i := range x {
+		sum += t[i] - m*x[i]
+	}
+
+	return sum / float64(len(x))
+}
+
 // Performs a 'best guess' as to which beat number corresponds to which estimated beat,by fitting the
 // beats to a line, adjusting the beats and calculating the variance between the beats and adjusted
 // beats. Returns when the average variance is sufficiently low.
-func reindex(beats []Beat) error {
+//
+// The search is restricted to the BPM range of the supplied tempo and, for a fixed tempo, the beat
+// numbers are simply the nearest beat for the fixed period.
+func reindex(beats []Beat, tempo Tempo) error {
 	sort.SliceStable(beats, func(i, j int) bool { return beats[i].At < beats[j].At })
 
 	at := make([]float64, len(beats))
@@ -587,7 +733,38 @@ func reindex(beats []Beat) error {
 		index[i] = i + 1
 	}
 
+	// ... fixed tempo
+	if tempo.IsFixed() && N > 0 {
+		m := tempo.period()
+		for i := range beats {
+			beats[i].beat = 1 + int(math.Round((at[i]-at[0])/m))
+		}
+
+		for i := 1; i < N; i++ {
+			if beats[i].beat <= beats[i-1].beat {
+				return fmt.Errorf("Error mapping taps to beats at %v BPM: %v", tempo.Min, beats)
+			}
+		}
+
+		return nil
+	}
+
 	// ... trivial cases
+	if N == 2 && tempo.IsConstrained() {
+		dt := Seconds(at[1] - at[0]).Minutes()
+		for k := 1; ; k++ {
+			BPM := float64(k) / dt
+			if tempo.Max > 0 && BPM > tempo.Max {
+				return fmt.Errorf("Error mapping taps to beats in range %v: %v", tempo, beats)
+			}
+
+			if BPM >= tempo.Min {
+				index[1] = 1 + k
+				break
+			}
+		}
+	}
+
 	if N <= 2 {
 		for i := range beats {
 			beats[i].beat = index[i]
@@ -603,11 +780,23 @@ func reindex(beats []Beat) error {
 	y0 := 1.0
 
 	dt := Seconds(xn - x0).Minutes()
+	bmin := N
 	bmax := int(math.Ceil(dt * float64(MaxBPM*MinSubdivision/4)))
 	variance := math.MaxFloat64
 
+	// ... allows one beat of slack at either end of the tempo range (the fitted gradient is clamped to the range)
+	if tempo.Min > 0 {
+		if b := int(math.Floor(dt*tempo.Min)) + 1; b > bmin {
+			bmin = b
+		}
+	}
+
+	if tempo.Max > 0 {
+		bmax = int(math.Ceil(dt*tempo.Max)) + 1
+	}
+
 loop:
-	for i := N; i <= bmax; i++ {
+	for i := bmin; i <= bmax; i++ {
 		yn := float64(i)
 		m := (yn - y0) / (xn - x0)
 		c := yn - m*xn
@@ -653,14 +842,20 @@ loop:
 	return nil
 }
 
-// Converts a result from the ckmeans.1d.dp algorithm to a Beat.
-func makeBeat(at float64, cluster ckmeans.Cluster) Beat {
+// Converts a result from the ckmeans.1d.dp algorithm to a Beat, with the loop of each 'tap' looked up
+// from the loop index of the clustered data.
+func makeBeat(at float64, cluster ckmeans.Cluster, loops []int) Beat {
 	taps := make([]time.Duration, len(cluster.Values))
+	indices := make([]int, len(cluster.Values))
 
 	for i, v := range cluster.Values {
 		taps[i] = Seconds(v)
 	}
 
+	for i, ix := range cluster.Indices {
+		indices[i] = loops[ix]
+	}
+
 	//	sort.SliceStable(taps, func(i, j int) bool { return taps[i] < taps[j] })
 
 	return Beat{
@@ -668,5 +863,6 @@ func makeBeat(at float64, cluster ckmeans.Cluster) Beat {
 		Mean:     Seconds(cluster.Center),
 		Variance: Seconds(cluster.Variance),
 		Taps:     taps,
+		Loops:    indices,
 	}
 }
@@ -98,7 +98,7 @@ func TestTaps2Beats(t *testing.T) {
 		Beats:  []Beat{beats[8], beats[9], beats[10], beats[11], beats[12], beats[13], beats[14], beats[15]},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.0)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.0)
 
 	if beats.BPM != expected.BPM {
 		t.Errorf("Incorrect BPM - expected:%v, got:%v", expected.BPM, beats.BPM)
@@ -132,7 +132,7 @@ func TestTaps2BeatsWithMissingBeat(t *testing.T) {
 		{4.517911093, 5.069403016, 5.586174007, 6.108568986, 6.578649068, 7.681606914, 8.26211078},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.0)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.0)
 
 	if beats.BPM != expected.BPM {
 		t.Errorf("Incorrect BPM - expected:%v, got:%v", expected.BPM, beats.BPM)
@@ -162,7 +162,7 @@ func TestTaps2BeatsWithWeirdData(t *testing.T) {
 		{50.0, 50.1, 50.2, 49.9, 49.8},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.0)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.0)
 
 	if beats.BPM != expected.BPM {
 		t.Errorf("Incorrect BPM - expected:%v, got:%v", expected.BPM, beats.BPM)
@@ -191,7 +191,7 @@ func TestTaps2BeatsWithForgetting(t *testing.T) {
 		},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.1)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.1)
 
 	if beats.BPM != expected.BPM {
 		t.Errorf("Incorrect BPM - expected:%v, got:%v", expected.BPM, beats.BPM)
@@ -224,7 +224,7 @@ func TestTaps2BeatsWithUnorderedTaps(t *testing.T) {
 		Beats:  []Beat{beats[8], beats[9], beats[10], beats[11], beats[12], beats[13], beats[14], beats[15]},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.0)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.0)
 
 	if beats.BPM != expected.BPM {
 		t.Errorf("Incorrect BPM - expected:%v, got:%v", expected.BPM, beats.BPM)
@@ -237,6 +237,72 @@ func TestTaps2BeatsWithUnorderedTaps(t *testing.T) {
 	compare(beats.Beats, expected.Beats, t)
 }
 
+func TestTaps2BeatsWithWeights(t *testing.T) {
+	set := NewTapSetFromFloats(taps)
+	set.Loops[0].Weight = 5.0
+
+	beats := Taps2Beats(set, 0.0)
+
+	if len(beats.Beats) != 8 {
+		t.Fatalf("Invalid result\n   expected: %v beats\n   got:      %v beats", 8, len(beats.Beats))
+	}
+
+	for i, b := range beats.Beats {
+		if len(b.Loops) != len(b.Taps) {
+			t.Fatalf("Invalid beat %d 'loops' - expected:%v values, got:%v", i+1, len(b.Taps), len(b.Loops))
+		}
+
+		sum := 0.0
+		sumw := 0.0
+		for j, tap := range b.Taps {
+			w := 1.0
+			if b.Loops[j] == 0 {
+				w = 5.0
+			}
+
+			found := false
+			for _, v := range taps[b.Loops[j]] {
+				if math.Abs(v-tap.Seconds()) < 0.000001 {
+					found = true
+				}
+			}
+
+			if !found {
+				t.Errorf("Invalid beat %d 'loops' - tap %v is not in loop %v", i+1, tap, b.Loops[j])
+			}
+
+			sum += w * tap.Seconds()
+			sumw += w
+		}
+
+		if mean := sum / sumw; math.Abs(b.Mean.Seconds()-mean) > 0.000001 {
+			t.Errorf("Invalid beat %d 'mean' - expected:%.6f, got:%v", i+1, mean, b.Mean)
+		}
+	}
+}
+
+func TestTaps2BeatsWithUnweightedTapSet(t *testing.T) {
+	expected := Taps2Beats(NewTapSetFromFloats(taps), 0.0)
+
+	set := TapSet{}
+	for _, row := range taps {
+		loop := Loop{}
+		for _, v := range row {
+			loop.Taps = append(loop.Taps, Tap{At: Seconds(v)})
+		}
+
+		set.Loops = append(set.Loops, loop)
+	}
+
+	beats := Taps2Beats(set, 0.0)
+
+	if beats.BPM != expected.BPM || beats.Offset != expected.Offset {
+		t.Errorf("Incorrect BPM/offset - expected:%v/%v, got:%v/%v", expected.BPM, expected.Offset, beats.BPM, beats.Offset)
+	}
+
+	compare(beats.Beats, expected.Beats, t)
+}
+
 func seconds(floats ...float64) []time.Duration {
 	l := []time.Duration{}
 
@@ -283,3 +349,27 @@ func compare(beats, expected []Beat, t *testing.T) {
 		}
 	}
 }
+
+func TestOnsets2Beats(t *testing.T) {
+	onsets := [][]time.Duration{seconds(1.368, 0.316, 0.842, 1.894, 2.420)}
+
+	beats := Onsets2Beats(NewTapSet(onsets))
+
+	if beats.BPM != 114 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%v", 114, beats.BPM)
+	}
+
+	if math.Abs(beats.Offset.Seconds()-0.316) > 0.0011 {
+		t.Errorf("Incorrect offset - expected:%v, got:%v", 316*time.Millisecond, beats.Offset)
+	}
+
+	expected := []Beat{
+		{At: Seconds(0.316), Mean: Seconds(0.316), Taps: seconds(0.316)},
+		{At: Seconds(0.842), Mean: Seconds(0.842), Taps: seconds(0.842)},
+		{At: Seconds(1.368), Mean: Seconds(1.368), Taps: seconds(1.368)},
+		{At: Seconds(1.894), Mean: Seconds(1.894), Taps: seconds(1.894)},
+		{At: Seconds(2.420), Mean: Seconds(2.420), Taps: seconds(2.420)},
+	}
+
+	compare(beats.Beats, expected, t)
+}
@@ -15,6 +15,7 @@ type Cluster struct {
 	Center   float64   // mean of the values assigned to this cluster from the data set
 	Variance float64   // variance of the values assigned to this cluster from the data set
 	Values   []float64 // the values from the data set that are assigned to this cluster
+	Indices  []int     // the indices in the data set of the values assigned to this cluster
 }
 
 // ckmeans.1d.dp implementation using L2 dissimilarity and linear clustering. Panics
@@ -81,6 +82,7 @@ func CKMeans1dDp(data, weights []float64) []Cluster {
 
 	for i, ix := range index {
 		clustered[ix].Values = append(clustered[ix].Values, data[i])
+		clustered[ix].Indices = append(clustered[ix].Indices, i)
 	}
 
 	return clustered
@@ -36,9 +36,9 @@ func TestCKMeans(t *testing.T) {
 	x := []float64{-0.9, 1.0, 1.1, 1.9, 2.0, 2.1}
 	w := []float64{3, 1, 2, 2, 1, 1}
 	expected := []Cluster{
-		Cluster{Center: -0.9, Variance: 0.0, Values: []float64{-0.9}},
-		Cluster{Center: 1.06666667, Variance: 0.00666667, Values: []float64{1.0, 1.1}},
-		Cluster{Center: 1.975, Variance: 0.0275 / 2, Values: []float64{1.9, 2.0, 2.1}},
+		Cluster{Center: -0.9, Variance: 0.0, Values: []float64{-0.9}, Indices: []int{0}},
+		Cluster{Center: 1.06666667, Variance: 0.00666667, Values: []float64{1.0, 1.1}, Indices: []int{1, 2}},
+		Cluster{Center: 1.975, Variance: 0.0275 / 2, Values: []float64{1.9, 2.0, 2.1}, Indices: []int{3, 4, 5}},
 	}
 
 	clusters := CKMeans1dDp(x, w)
@@ -53,6 +53,10 @@ func TestCKMeans(t *testing.T) {
 				t.Errorf("(cluster %d) invalid 'variance' - expected:%v, got:%v", i, expected[i].Variance, clusters[i].Variance)
 			}
 
+			if !reflect.DeepEqual(clusters[i].Indices, expected[i].Indices) {
+				t.Errorf("(cluster %d) invalid 'indices' - expected:%v, got:%v", i, expected[i].Indices, clusters[i].Indices)
+			}
+
 			if len(clusters[i].Values) != len(expected[i].Values) {
 				t.Errorf("(cluster %d) invalid 'values' - expected:%v, got:%v", i, expected[i].Values, clusters[i].Values)
 			} else {
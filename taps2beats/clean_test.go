@@ -153,3 +153,27 @@ func TestCleanWithSingleTapBeat(t *testing.T) {
 
 	compare(cleaned.Beats, expected.Beats, t)
 }
+
+func TestCleanWithTempo(t *testing.T) {
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.0)
+
+	tests := []struct {
+		tempo    Tempo
+		expected uint
+	}{
+		{Tempo{}, 114},
+		{FixedTempo(120), 120},
+		{Tempo{Min: 116, Max: 130}, 116},
+	}
+
+	for _, test := range tests {
+		cleaned, err := beats.CleanWithTempo(test.tempo)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if cleaned.BPM != test.expected {
+			t.Errorf("Incorrect BPM for tempo %v - expected:%v, got:%v", test.tempo, test.expected, cleaned.BPM)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package taps2beats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Version of the JSON document written by Document.MarshalJSON.
+const DocumentVersion = 2
+
+// A versioned JSON document for a set of beats, with the provenance of the beats (the tool, the options and
+// a checksum of the input) and the statistics of the fit. Unlike the Beats JSON format (which rounds all times
+// to 1ms), the times are written to the document precision so that the beats can be read back losslessly.
+//
+// If the document has a time base, the times are written in the time base units (e.g. samples or SMPTE
+// timecode) and are only as precise as the time base units.
+//
+// The beat numbers are the beat numbers assigned when the beats were quantized or interpolated (or the
+// position of each beat if the beats have not been numbered). The statistics are always calculated from
+// the beats when the document is written and are only read back from the document.
+type Document struct {
+	Tool       string            // name and version of the tool that estimated the beats
+	Options    map[string]string // options used to estimate the beats
+	Input      string            // input file
+	Checksum   string            // checksum of the input file e.g. sha256:...
+	Precision  time.Duration     // precision of the times in the document (0 for nanoseconds)
+	TimeBase   *TimeBase         // time base of the times in the document (defaults to seconds)
+	Statistics Statistics        // fit statistics (as read from the document)
+	Beats      Beats
+}
+
+// Statistics for the fit of the beats to the 'taps'.
+type Statistics struct {
+	Beats        int           // number of beats
+	Tapped       int           // number of beats with 'taps'
+	Interpolated int           // number of beats without 'taps'
+	Taps         int           // total number of 'taps'
+	Loops        int           // number of loops (if known)
+	Variance     *float64      // average variance of the tapped beats
+	Residual     time.Duration // RMS difference between the 'taps' and the beats
+	Asynchrony   time.Duration // mean difference between the 'taps' and the beats
+}
+
+type documentBeat struct {
+	Beat     int       `json:"beat"`
+	Source   string    `json:"source"`
+	At       decimal   `json:"at"`
+	Mean     decimal   `json:"mean"`
+	Variance decimal   `json:"variance"`
+	Taps     []decimal `json:"taps"`
+	Loops    []int     `json:"loops,omitempty"`
+}
+
+type documentStatistics struct {
+	Beats        int      `json:"beats"`
+	Tapped       int      `json:"tapped"`
+	Interpolated int      `json:"interpolated"`
+	Taps         int      `json:"taps"`
+	Loops        int      `json:"loops,omitempty"`
+	Variance     *float64 `json:"variance,omitempty"`
+	Residual     decimal  `json:"residual"`
+	Asynchrony   decimal  `json:"asynchrony"`
+}
+
+type document struct {
+	Version    int                `json:"version"`
+	Tool       string             `json:"tool,omitempty"`
+	Options    map[string]string  `json:"options,omitempty"`
+	Input      *documentInput     `json:"input,omitempty"`
+	Precision  string             `json:"precision"`
+	TimeBase   string             `json:"timebase,omitempty"`
+	Statistics documentStatistics `json:"statistics"`
+	BPM        uint               `json:"BPM"`
+	Offset     decimal            `json:"offset"`
+	Beats      []documentBeat     `json:"beats"`
+}
+
+type documentInput struct {
+	File     string `json:"file,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// A time marshalled as seconds to a fixed number of decimal places (or in the time base units, if the document
+// has a time base) and unmarshalled rounded to the nearest nanosecond (rather than truncated, so that e.g. 4.524
+// is read back as 4.524s). An interval (e.g. a variance) is marshalled as an interval in the time base units.
+type decimal struct {
+	t        time.Duration
+	digits   int
+	timebase *TimeBase
+	interval bool
+	raw      string
+}
+
+func (d decimal) MarshalJSON() ([]byte, error) {
+	if d.timebase == nil {
+		return []byte(strconv.FormatFloat(d.t.Seconds(), 'f', d.digits, 64)), nil
+	}
+
+	v := d.timebase.Format(d.t)
+	if d.interval {
+		v = d.timebase.FormatInterval(d.t)
+	}
+
+	if d.timebase.Units == UnitDuration || (d.timebase.Units == UnitSMPTE && !d.interval) {
+		return json.Marshal(v)
+	}
+
+	return []byte(v), nil
+}
+
+func (d *decimal) UnmarshalJSON(bytes []byte) error {
+	var s string
+	if err := json.Unmarshal(bytes, &s); err != nil {
+		s = string(bytes)
+	}
+
+	d.raw = s
+
+	return nil
+}
+
+// Converts the unmarshalled time from the time base units (or seconds if the time base is nil).
+func (d *decimal) resolve(timebase *TimeBase, interval bool) error {
+	if timebase == nil {
+		v, err := strconv.ParseFloat(d.raw, 64)
+		if err != nil {
+			return err
+		}
+
+		d.t = time.Duration(math.Round(v * float64(time.Second)))
+
+		return nil
+	}
+
+	if interval && timebase.Units == UnitSMPTE {
+		tb := *timebase
+		tb.Units = UnitFrames
+		timebase = &tb
+	}
+
+	t, err := timebase.Parse(d.raw)
+	if err != nil {
+		return err
+	}
+
+	d.t = t
+
+	return nil
+}
+
+// Returns the fit statistics for the beats.
+func (beats Beats) Statistics() Statistics {
+	return beats.statistics(func(int) bool { return true })
+}
+
+// Returns the fit statistics for the 'taps' from a subset of the loops (e.g. the loops from a single file or
+// tapper) i.e. a beat is 'tapped' if it has 'taps' from any of the loops and 'interpolated' otherwise.
+func (beats Beats) StatisticsFor(loops ...int) Statistics {
+	subset := map[int]bool{}
+	for _, l := range loops {
+		subset[l] = true
+	}
+
+	stats := beats.statistics(func(l int) bool { return subset[l] })
+	stats.Variance = nil
+
+	return stats
+}
+
+func (beats Beats) statistics(include func(loop int) bool) Statistics {
+	stats := Statistics{
+		Beats:    len(beats.Beats),
+		Variance: beats.Variance,
+	}
+
+	loops := map[int]bool{}
+	sum := 0.0
+	sumsq := 0.0
+	for _, b := range beats.Beats {
+		tapped := false
+		for i, t := range b.Taps {
+			loop := -1
+			if i < len(b.Loops) {
+				loop = b.Loops[i]
+			}
+
+			if !include(loop) {
+				continue
+			}
+
+			dt := (t - b.At).Seconds()
+			sum += dt
+			sumsq += dt * dt
+			stats.Taps++
+			tapped = true
+
+			if loop >= 0 {
+				loops[loop] = true
+			}
+		}
+
+		if tapped {
+			stats.Tapped++
+		} else {
+			stats.Interpolated++
+		}
+	}
+
+	stats.Loops = len(loops)
+	if stats.Taps > 0 {
+		stats.Residual = Seconds(math.Sqrt(sumsq / float64(stats.Taps)))
+		stats.Asynchrony = Seconds(sum / float64(stats.Taps))
+	}
+
+	return stats
+}
+
+// Custom JSON marshaller for a Document.
+func (d Document) MarshalJSON() ([]byte, error) {
+	digits := 9
+	if d.Precision > 0 {
+		digits = 0
+		for p := time.Second; p > d.Precision && digits < 9; p /= 10 {
+			digits++
+		}
+	}
+
+	t := func(v time.Duration) decimal {
+		return decimal{t: v, digits: digits, timebase: d.TimeBase}
+	}
+
+	dt := func(v time.Duration) decimal {
+		return decimal{t: v, digits: digits, timebase: d.TimeBase, interval: true}
+	}
+
+	stats := d.Beats.Statistics()
+
+	doc := document{
+		Version:   DocumentVersion,
+		Tool:      d.Tool,
+		Options:   d.Options,
+		Precision: d.Precision.String(),
+		Statistics: documentStatistics{
+			Beats:        stats.Beats,
+			Tapped:       stats.Tapped,
+			Interpolated: stats.Interpolated,
+			Taps:         stats.Taps,
+			Loops:        stats.Loops,
+			Variance:     stats.Variance,
+			Residual:     dt(stats.Residual),
+			Asynchrony:   dt(stats.Asynchrony),
+		},
+		BPM:    d.Beats.BPM,
+		Offset: t(d.Beats.Offset),
+		Beats:  make([]documentBeat, len(d.Beats.Beats)),
+	}
+
+	if d.TimeBase != nil {
+		doc.TimeBase = d.TimeBase.String()
+	}
+
+	if d.Input != "" || d.Checksum != "" {
+		doc.Input = &documentInput{
+			File:     d.Input,
+			Checksum: d.Checksum,
+		}
+	}
+
+	numbers := numbers(d.Beats.Beats)
+	for i, b := range d.Beats.Beats {
+		source := "interpolated"
+		if len(b.Taps) > 0 {
+			source = "tapped"
+		}
+
+		doc.Beats[i] = documentBeat{
+			Beat:     numbers[i],
+			Source:   source,
+			At:       t(b.At),
+			Mean:     t(b.Mean),
+			Variance: dt(b.Variance),
+			Taps:     make([]decimal, len(b.Taps)),
+			Loops:    b.Loops,
+		}
+
+		for j, tap := range b.Taps {
+			doc.Beats[i].Taps[j] = t(tap)
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+// Custom JSON unmarshaller for a Document. Also accepts the original (unversioned) Beats JSON format.
+func (d *Document) UnmarshalJSON(bytes []byte) error {
+	if d == nil {
+		return nil
+	}
+
+	doc := document{}
+	if err := json.Unmarshal(bytes, &doc); err != nil {
+		return err
+	}
+
+	if doc.Version == 0 {
+		beats := Beats{}
+		if err := json.Unmarshal(bytes, &beats); err != nil {
+			return err
+		}
+
+		*d = Document{Precision: time.Millisecond, Beats: beats}
+
+		return nil
+	}
+
+	if doc.Version != DocumentVersion {
+		return fmt.Errorf("unsupported beats document version (%v)", doc.Version)
+	}
+
+	precision := time.Duration(0)
+	if doc.Precision != "" {
+		if p, err := time.ParseDuration(doc.Precision); err != nil {
+			return err
+		} else {
+			precision = p
+		}
+	}
+
+	var timebase *TimeBase
+	if doc.TimeBase != "" {
+		if tb, err := ParseTimeBase(doc.TimeBase); err != nil {
+			return err
+		} else {
+			timebase = &tb
+		}
+	}
+
+	beats, err := doc.beats(timebase)
+	if err != nil {
+		return err
+	}
+
+	stats, err := doc.statistics(timebase)
+	if err != nil {
+		return err
+	}
+
+	*d = Document{
+		Tool:       doc.Tool,
+		Options:    doc.Options,
+		Precision:  precision,
+		TimeBase:   timebase,
+		Statistics: stats,
+		Beats:      beats,
+	}
+
+	if doc.Input != nil {
+		d.Input = doc.Input.File
+		d.Checksum = doc.Input.Checksum
+	}
+
+	return nil
+}
+
+func (doc document) beats(timebase *TimeBase) (Beats, error) {
+	if err := doc.Offset.resolve(timebase, false); err != nil {
+		return Beats{}, err
+	}
+
+	beats := Beats{
+		BPM:      doc.BPM,
+		Offset:   doc.Offset.t,
+		Beats:    make([]Beat, len(doc.Beats)),
+		Variance: doc.Statistics.Variance,
+	}
+
+	for i, b := range doc.Beats {
+		for _, v := range []*decimal{&b.At, &b.Mean} {
+			if err := v.resolve(timebase, false); err != nil {
+				return Beats{}, err
+			}
+		}
+
+		if err := b.Variance.resolve(timebase, true); err != nil {
+			return Beats{}, err
+		}
+
+		beats.Beats[i] = Beat{
+			beat:     b.Beat,
+			At:       b.At.t,
+			Mean:     b.Mean.t,
+			Variance: b.Variance.t,
+			Taps:     make([]time.Duration, len(b.Taps)),
+			Loops:    b.Loops,
+		}
+
+		for j, tap := range b.Taps {
+			if err := tap.resolve(timebase, false); err != nil {
+				return Beats{}, err
+			}
+
+			beats.Beats[i].Taps[j] = tap.t
+		}
+	}
+
+	return beats, nil
+}
+
+func (doc document) statistics(timebase *TimeBase) (Statistics, error) {
+	stats := Statistics{
+		Beats:        doc.Statistics.Beats,
+		Tapped:       doc.Statistics.Tapped,
+		Interpolated: doc.Statistics.Interpolated,
+		Taps:         doc.Statistics.Taps,
+		Loops:        doc.Statistics.Loops,
+		Variance:     doc.Statistics.Variance,
+	}
+
+	if err := doc.Statistics.Residual.resolve(timebase, true); err != nil {
+		return Statistics{}, err
+	}
+
+	stats.Residual = doc.Statistics.Residual.t
+
+	if err := doc.Statistics.Asynchrony.resolve(timebase, true); err != nil {
+		return Statistics{}, err
+	}
+
+	stats.Asynchrony = doc.Statistics.Asynchrony.t
+
+	return stats, nil
+}
+
+// Returns the beat numbers assigned when the beats were quantized or interpolated or, if the beats have not
+// been numbered, the position of each beat in the list.
+func numbers(beats []Beat) []int {
+	numbered := false
+	for _, b := range beats {
+		if b.beat != 0 {
+			numbered = true
+		}
+	}
+
+	numbers := make([]int, len(beats))
+	for i, b := range beats {
+		if numbered {
+			numbers[i] = b.beat
+		} else {
+			numbers[i] = i + 1
+		}
+	}
+
+	return numbers
+}
@@ -0,0 +1,178 @@
+package taps2beats
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalDocument(t *testing.T) {
+	expected := `{"version":2,"tool":"taps2beats v0.1.0","options":{"forgetting":"0.1"},"input":{"file":"taps.txt","checksum":"sha256:1234"},"precision":"100µs","statistics":{"beats":3,"tapped":1,"interpolated":2,"taps":2,"loops":2,"residual":0.0100,"asynchrony":0.0000},"BPM":120,"offset":0.2500,"beats":[{"beat":1,"source":"interpolated","at":0.2500,"mean":0.0000,"variance":0.0000,"taps":[]},{"beat":2,"source":"tapped","at":0.7500,"mean":0.7500,"variance":0.0001,"taps":[0.7400,0.7600],"loops":[0,1]},{"beat":3,"source":"interpolated","at":1.2500,"mean":0.0000,"variance":0.0000,"taps":[]}]}`
+
+	doc := Document{
+		Tool:      "taps2beats v0.1.0",
+		Options:   map[string]string{"forgetting": "0.1"},
+		Input:     "taps.txt",
+		Checksum:  "sha256:1234",
+		Precision: 100 * time.Microsecond,
+		Beats: Beats{
+			BPM:    120,
+			Offset: 250 * time.Millisecond,
+			Beats: []Beat{
+				{At: Seconds(0.25)},
+				{At: Seconds(0.75), Mean: Seconds(0.75), Variance: Seconds(0.0001), Taps: seconds(0.74, 0.76), Loops: []int{0, 1}},
+				{At: Seconds(1.25)},
+			},
+		},
+	}
+
+	bytes, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(bytes) != expected {
+		t.Errorf("JSON marshal error:\n    expected: %s\n    got:      %s\n", expected, string(bytes))
+	}
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.0)
+	if err := beats.Interpolate(Seconds(3), Seconds(12)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stats := beats.Statistics()
+
+	for _, precision := range []time.Duration{0, time.Nanosecond} {
+		bytes, err := json.Marshal(Document{Tool: "taps2beats", Precision: precision, Beats: beats})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var doc Document
+		if err := json.Unmarshal(bytes, &doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if doc.Tool != "taps2beats" || doc.Precision != precision {
+			t.Errorf("Incorrect document - got:%+v", doc)
+		}
+
+		if !reflect.DeepEqual(doc.Statistics, stats) {
+			t.Errorf("Incorrect statistics\n   expected:%+v\n   got:     %+v", stats, doc.Statistics)
+		}
+
+		compareBeats(beats, doc.Beats, t)
+
+		for i, b := range doc.Beats.Beats {
+			if b.beat != beats.Beats[i].beat {
+				t.Errorf("Incorrect beat %d number - expected:%v, got:%v", i+1, beats.Beats[i].beat, b.beat)
+			}
+		}
+
+		var b Beats
+		if err := json.Unmarshal(bytes, &b); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		compareBeats(beats, b, t)
+	}
+}
+
+func TestUnmarshalDocumentV1(t *testing.T) {
+	bytes := []byte(`{"BPM":114,"offset":0.316,"beats":[{"at":4.524,"mean":4.524,"variance":0.024,"taps":[4.570,4.506]}]}`)
+
+	var doc Document
+	if err := json.Unmarshal(bytes, &doc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if doc.Precision != time.Millisecond || doc.Beats.BPM != 114 || len(doc.Beats.Beats) != 1 || len(doc.Beats.Beats[0].Taps) != 2 {
+		t.Errorf("Incorrect document - got:%+v", doc)
+	}
+
+	if err := json.Unmarshal([]byte(`{"version":3,"beats":[]}`), &doc); err == nil {
+		t.Errorf("Expected error for unsupported version")
+	}
+}
+
+func compareBeats(expected, beats Beats, t *testing.T) {
+	if beats.BPM != expected.BPM || beats.Offset != expected.Offset {
+		t.Errorf("Incorrect BPM/offset - expected:%v/%v, got:%v/%v", expected.BPM, expected.Offset, beats.BPM, beats.Offset)
+	}
+
+	if !reflect.DeepEqual(beats.Variance, expected.Variance) {
+		t.Errorf("Incorrect variance - expected:%v, got:%v", expected.Variance, beats.Variance)
+	}
+
+	if len(beats.Beats) != len(expected.Beats) {
+		t.Fatalf("Incorrect number of beats - expected:%v, got:%v", len(expected.Beats), len(beats.Beats))
+	}
+
+	for i, b := range beats.Beats {
+		e := expected.Beats[i]
+		taps := len(b.Taps) == len(e.Taps) && (len(b.Taps) == 0 || reflect.DeepEqual(b.Taps, e.Taps))
+		loops := len(b.Loops) == len(e.Loops) && (len(b.Loops) == 0 || reflect.DeepEqual(b.Loops, e.Loops))
+
+		if b.At != e.At || b.Mean != e.Mean || b.Variance != e.Variance || !taps || !loops {
+			t.Errorf("Incorrect beat %d\n   expected:%+v\n   got:     %+v", i+1, e, b)
+		}
+	}
+}
+
+func TestDocumentWithTimeBase(t *testing.T) {
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.0)
+
+	for _, spec := range []string{"samples:48000", "smpte:25", "ticks:960@120"} {
+		tb, _ := ParseTimeBase(spec)
+
+		bytes, err := json.Marshal(Document{Precision: time.Millisecond, TimeBase: &tb, Beats: beats})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var doc Document
+		if err := json.Unmarshal(bytes, &doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if doc.TimeBase == nil || *doc.TimeBase != tb {
+			t.Errorf("Incorrect time base - expected:%v, got:%v", tb, doc.TimeBase)
+		}
+
+		for i, b := range doc.Beats.Beats {
+			if tb.Value(b.At) != tb.Value(beats.Beats[i].At) {
+				t.Errorf("Incorrect %v beat %d - expected:%v, got:%v", spec, i+1, beats.Beats[i].At, b.At)
+			}
+		}
+	}
+}
+
+func TestStatisticsFor(t *testing.T) {
+	beats := Beats{
+		Beats: []Beat{
+			{At: Seconds(0.5), Taps: seconds(0.49, 0.53), Loops: []int{0, 1}},
+			{At: Seconds(1.0), Taps: seconds(1.01), Loops: []int{1}},
+		},
+	}
+
+	tests := []struct {
+		loops    []int
+		expected Statistics
+	}{
+		{[]int{0}, Statistics{Beats: 2, Tapped: 1, Interpolated: 1, Taps: 1, Loops: 1, Residual: 10 * time.Millisecond, Asynchrony: -10 * time.Millisecond}},
+		{[]int{1}, Statistics{Beats: 2, Tapped: 2, Interpolated: 0, Taps: 2, Loops: 1, Residual: 22360 * time.Microsecond, Asynchrony: 20 * time.Millisecond}},
+	}
+
+	for _, test := range tests {
+		stats := beats.StatisticsFor(test.loops...)
+		stats.Residual = stats.Residual.Round(10 * time.Microsecond)
+		stats.Asynchrony = stats.Asynchrony.Round(time.Microsecond)
+
+		if !reflect.DeepEqual(stats, test.expected) {
+			t.Errorf("Incorrect statistics for loops %v\n   expected:%+v\n   got:     %+v", test.loops, test.expected, stats)
+		}
+	}
+}
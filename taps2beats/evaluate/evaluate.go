@@ -0,0 +1,488 @@
+// Package evaluate scores a set of estimated beats against a reference (ground truth) beat list using the
+// standard beat tracking evaluation metrics i.e. F-measure, Cemgil accuracy, P-score, the CMLc/CMLt/AMLc/AMLt
+// continuity metrics, information gain and the tempo accuracy (including octave errors).
+//
+// The metrics follow the definitions in Davies, Degara and Plumbley, "Evaluation Methods for Musical Audio
+// Beat Tracking Algorithms" (Queen Mary University of London, Technical Report C4DM-TR-09-06, 2009). The
+// beat lists passed to the individual metric functions are expected to be sorted in time order.
+package evaluate
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Settings for a beat tracking evaluation. Beats before Skip are discarded from both the estimated and
+// reference beats before evaluating (the MIREX evaluation skips the first 5 seconds).
+type Evaluator struct {
+	Window    time.Duration // F-measure tolerance window (±)
+	Sigma     time.Duration // standard deviation of the Cemgil accuracy Gaussian error function
+	Threshold float64       // P-score tolerance, as a fraction of the median reference beat interval
+	Phase     float64       // continuity phase tolerance, as a fraction of the reference beat interval
+	Period    float64       // continuity period tolerance, as a fraction of the reference beat interval
+	Bins      int           // number of bins in the information gain beat error histogram
+	Tolerance float64       // tempo accuracy tolerance, as a fraction of the reference tempo
+	Skip      time.Duration // beats before this time are ignored
+}
+
+// The result of evaluating a set of estimated beats against a reference beat list. All the accuracy metrics
+// range from 0 to 1 except for information gain which is in bits (from 0 to log2(Bins)).
+type Evaluation struct {
+	Beats     int     `json:"beats"`
+	Reference int     `json:"reference"`
+	FMeasure  float64 `json:"fmeasure"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	Cemgil    float64 `json:"cemgil"`
+	PScore    float64 `json:"pscore"`
+	CMLc      float64 `json:"CMLc"`
+	CMLt      float64 `json:"CMLt"`
+	AMLc      float64 `json:"AMLc"`
+	AMLt      float64 `json:"AMLt"`
+	InfoGain  float64 `json:"infogain"`
+	Tempo     Tempo   `json:"tempo"`
+}
+
+// Tempo accuracy of an estimate. Accuracy1 is true if the estimated tempo is within the tolerance of the
+// reference tempo and Accuracy2 is true if it is within the tolerance of the reference tempo or of the
+// reference tempo at double, half, triple or third the tempo. Octave is the ratio that matched ("1", "2",
+// "1/2", "3" or "1/3"), or empty if the estimated tempo does not match at all.
+type Tempo struct {
+	Estimated float64 `json:"estimated"`
+	Reference float64 `json:"reference"`
+	Accuracy1 bool    `json:"accuracy1"`
+	Accuracy2 bool    `json:"accuracy2"`
+	Octave    string  `json:"octave,omitempty"`
+}
+
+// Continuity-based accuracy, as the proportion of the reference beats in the longest continuously correct
+// segment (Correct) and in total (Total).
+type Continuity struct {
+	Correct float64
+	Total   float64
+}
+
+// Returns the default evaluation settings i.e. the same settings as the MIREX beat tracking evaluation,
+// except that no beats are skipped.
+func NewEvaluator() Evaluator {
+	return Evaluator{
+		Window:    70 * time.Millisecond,
+		Sigma:     40 * time.Millisecond,
+		Threshold: 0.2,
+		Phase:     0.175,
+		Period:    0.175,
+		Bins:      41,
+		Tolerance: 0.04,
+		Skip:      0,
+	}
+}
+
+// Evaluates the estimated beats against the reference beat list. The estimated tempo is the beats BPM or, if
+// not set, the tempo of the median beat interval.
+func (e Evaluator) Evaluate(beats taps2beats.Beats, reference []time.Duration) Evaluation {
+	estimated := []time.Duration{}
+	for _, b := range beats.Beats {
+		estimated = append(estimated, b.At)
+	}
+
+	estimated = trim(estimated, e.Skip)
+	reference = trim(reference, e.Skip)
+
+	f, p, r := FMeasure(estimated, reference, e.Window)
+	cml := CML(estimated, reference, e.Phase, e.Period)
+	aml := AML(estimated, reference, e.Phase, e.Period)
+
+	bpm := float64(beats.BPM)
+	if bpm == 0 {
+		bpm = BPM(estimated)
+	}
+
+	return Evaluation{
+		Beats:     len(estimated),
+		Reference: len(reference),
+		FMeasure:  f,
+		Precision: p,
+		Recall:    r,
+		Cemgil:    Cemgil(estimated, reference, e.Sigma),
+		PScore:    PScore(estimated, reference, e.Threshold),
+		CMLc:      cml.Correct,
+		CMLt:      cml.Total,
+		AMLc:      aml.Correct,
+		AMLt:      aml.Total,
+		InfoGain:  InformationGain(estimated, reference, e.Bins),
+		Tempo:     TempoAccuracy(bpm, BPM(reference), e.Tolerance),
+	}
+}
+
+// Returns the F-measure, precision and recall of the estimated beats i.e. the harmonic mean of the proportion
+// of estimated beats that match a reference beat and the proportion of reference beats that are matched, where
+// each reference beat is matched to at most one estimated beat within the tolerance window.
+func FMeasure(estimated, reference []time.Duration, window time.Duration) (float64, float64, float64) {
+	if len(estimated) == 0 || len(reference) == 0 {
+		return 0, 0, 0
+	}
+
+	// ... match the closest pairs first
+	type pair struct {
+		e  int
+		r  int
+		dt time.Duration
+	}
+
+	pairs := []pair{}
+	for i, r := range reference {
+		for j, t := range estimated {
+			if dt := abs(t - r); dt <= window {
+				pairs = append(pairs, pair{e: j, r: i, dt: dt})
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].dt < pairs[j].dt })
+
+	matched := 0
+	used := map[int]bool{}
+	found := map[int]bool{}
+	for _, p := range pairs {
+		if !used[p.e] && !found[p.r] {
+			used[p.e] = true
+			found[p.r] = true
+			matched++
+		}
+	}
+
+	precision := float64(matched) / float64(len(estimated))
+	recall := float64(matched) / float64(len(reference))
+	if matched == 0 {
+		return 0, precision, recall
+	}
+
+	return 2 * precision * recall / (precision + recall), precision, recall
+}
+
+// Returns the Cemgil accuracy of the estimated beats i.e. the sum over the reference beats of a Gaussian
+// error function of the distance to the nearest estimated beat, normalized by the mean of the number of
+// estimated and reference beats.
+func Cemgil(estimated, reference []time.Duration, sigma time.Duration) float64 {
+	if len(estimated) == 0 || len(reference) == 0 || sigma <= 0 {
+		return 0
+	}
+
+	s := sigma.Seconds()
+	sum := 0.0
+	for _, r := range reference {
+		dt := abs(estimated[nearest(estimated, r)] - r).Seconds()
+		sum += math.Exp(-(dt * dt) / (2 * s * s))
+	}
+
+	return sum / (float64(len(estimated)+len(reference)) / 2)
+}
+
+// Returns the P-score of the estimated beats i.e. the cross-correlation of the estimated and reference beats
+// (as impulse trains sampled at 100Hz) within a tolerance of threshold times the median reference beat
+// interval, normalized by the larger of the number of estimated and reference beats.
+func PScore(estimated, reference []time.Duration, threshold float64) float64 {
+	if len(estimated) == 0 || len(reference) < 2 {
+		return 0
+	}
+
+	sample := func(t time.Duration) int {
+		return int(math.Round(t.Seconds() * 100))
+	}
+
+	w := int(math.Round(threshold * median(reference).Seconds() * 100))
+	sum := 0
+	for _, r := range reference {
+		for _, t := range estimated {
+			if d := sample(t) - sample(r); d >= -w && d <= w {
+				sum++
+			}
+		}
+	}
+
+	N := len(estimated)
+	if len(reference) > N {
+		N = len(reference)
+	}
+
+	return float64(sum) / float64(N)
+}
+
+// Returns the 'correct metrical level' continuity accuracy of the estimated beats. A reference beat is
+// correctly tracked if the nearest estimated beat (not already matched) is within the phase tolerance of the
+// reference beat and the estimated beat interval is within the period tolerance of the reference beat
+// interval (both as a fraction of the reference beat interval).
+func CML(estimated, reference []time.Duration, phase, period float64) Continuity {
+	return continuity(estimated, reference, phase, period)
+}
+
+// Returns the 'allowed metrical levels' continuity accuracy of the estimated beats i.e. the best of the CML
+// continuity against the reference beats, the off-beats, the reference beats at double the tempo and the odd
+// and even reference beats at half the tempo.
+func AML(estimated, reference []time.Duration, phase, period float64) Continuity {
+	best := Continuity{}
+	for _, variation := range variations(reference) {
+		c := continuity(estimated, variation, phase, period)
+		if c.Total > best.Total || (c.Total == best.Total && c.Correct > best.Correct) {
+			best = c
+		}
+	}
+
+	return best
+}
+
+// Returns the information gain (in bits) of the estimated beats i.e. the Kullback-Leibler divergence of
+// the histogram of the beat errors (relative to the local reference beat interval) from a uniform histogram.
+// The beat error histogram is calculated for the estimated beats relative to the reference beats and for the
+// reference beats relative to the estimated beats and the higher entropy is used (so that an estimate at
+// double or half the tempo is penalized).
+func InformationGain(estimated, reference []time.Duration, bins int) float64 {
+	if len(estimated) < 2 || len(reference) < 2 || bins < 2 {
+		return 0
+	}
+
+	forward := entropy(errors(estimated, reference), bins)
+	backward := entropy(errors(reference, estimated), bins)
+
+	return math.Log2(float64(bins)) - math.Max(forward, backward)
+}
+
+// Returns the tempo accuracy of the estimated tempo relative to the reference tempo (both in BPM).
+func TempoAccuracy(estimated, reference float64, tolerance float64) Tempo {
+	tempo := Tempo{
+		Estimated: estimated,
+		Reference: reference,
+	}
+
+	if estimated <= 0 || reference <= 0 {
+		return tempo
+	}
+
+	octaves := []struct {
+		ratio  float64
+		octave string
+	}{
+		{1, "1"}, {2, "2"}, {0.5, "1/2"}, {3, "3"}, {1.0 / 3.0, "1/3"},
+	}
+
+	for _, o := range octaves {
+		bpm := o.ratio * reference
+		if math.Abs(estimated-bpm) <= tolerance*bpm {
+			tempo.Accuracy1 = o.ratio == 1
+			tempo.Accuracy2 = true
+			tempo.Octave = o.octave
+			break
+		}
+	}
+
+	return tempo
+}
+
+// Returns the tempo (in BPM) of the median beat interval, or 0 if there are fewer than two beats.
+func BPM(beats []time.Duration) float64 {
+	if len(beats) < 2 {
+		return 0
+	}
+
+	if interval := median(beats); interval > 0 {
+		return 60.0 / interval.Seconds()
+	}
+
+	return 0
+}
+
+func continuity(estimated, reference []time.Duration, phase, period float64) Continuity {
+	if len(estimated) < 2 || len(reference) < 2 {
+		return Continuity{}
+	}
+
+	used := make([]bool, len(estimated))
+	correct := make([]bool, len(reference))
+
+	for i, r := range reference {
+		j := nearest(estimated, r)
+		if used[j] {
+			continue
+		}
+
+		ibi := interval(reference, i).Seconds()
+		if abs(estimated[j]-r).Seconds() > phase*ibi {
+			continue
+		}
+
+		if math.Abs(interval(estimated, j).Seconds()-ibi) > period*ibi {
+			continue
+		}
+
+		used[j] = true
+		correct[i] = true
+	}
+
+	longest := 0
+	total := 0
+	run := 0
+	for _, ok := range correct {
+		if ok {
+			run++
+			total++
+		} else {
+			run = 0
+		}
+
+		if run > longest {
+			longest = run
+		}
+	}
+
+	N := float64(len(reference))
+
+	return Continuity{
+		Correct: float64(longest) / N,
+		Total:   float64(total) / N,
+	}
+}
+
+// Returns the allowed metrical variations of the reference beats i.e. the reference beats, the off-beats,
+// the beats at double tempo and the odd and even beats at half tempo.
+func variations(reference []time.Duration) [][]time.Duration {
+	offbeats := []time.Duration{}
+	double := []time.Duration{}
+	odd := []time.Duration{}
+	even := []time.Duration{}
+
+	for i, r := range reference {
+		double = append(double, r)
+		if i+1 < len(reference) {
+			midpoint := r + (reference[i+1]-r)/2
+			offbeats = append(offbeats, midpoint)
+			double = append(double, midpoint)
+		}
+
+		if i%2 == 0 {
+			odd = append(odd, r)
+		} else {
+			even = append(even, r)
+		}
+	}
+
+	return [][]time.Duration{reference, offbeats, double, odd, even}
+}
+
+// Returns the error of each beat relative to the nearest target beat, as a fraction of the target beat
+// interval on the same side as the beat (-0.5 to 0.5).
+func errors(beats, targets []time.Duration) []float64 {
+	list := []float64{}
+	for _, t := range beats {
+		j := nearest(targets, t)
+		dt := t - targets[j]
+
+		var interval time.Duration
+		switch {
+		case dt >= 0 && j+1 < len(targets):
+			interval = targets[j+1] - targets[j]
+		case dt >= 0:
+			interval = targets[j] - targets[j-1]
+		case j > 0:
+			interval = targets[j] - targets[j-1]
+		default:
+			interval = targets[j+1] - targets[j]
+		}
+
+		if interval > 0 {
+			list = append(list, math.Max(-0.5, math.Min(0.5, dt.Seconds()/interval.Seconds())))
+		}
+	}
+
+	return list
+}
+
+// Returns the entropy (in bits) of the histogram of the beat errors, with the bins centred on zero and the
+// errors at ±0.5 wrapped into the same bin.
+func entropy(errors []float64, bins int) float64 {
+	if len(errors) == 0 {
+		return math.Log2(float64(bins))
+	}
+
+	histogram := make([]int, bins)
+	for _, e := range errors {
+		bin := int(math.Floor(e*float64(bins) + 0.5))
+		histogram[((bin%bins)+bins)%bins]++
+	}
+
+	H := 0.0
+	for _, n := range histogram {
+		if n > 0 {
+			p := float64(n) / float64(len(errors))
+			H -= p * math.Log2(p)
+		}
+	}
+
+	return H
+}
+
+// Returns the beats at or after the skip time.
+func trim(beats []time.Duration, skip time.Duration) []time.Duration {
+	list := []time.Duration{}
+	for _, t := range beats {
+		if t >= skip {
+			list = append(list, t)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+
+	return list
+}
+
+// Returns the index of the beat nearest to t (the beats are assumed to be sorted).
+func nearest(beats []time.Duration, t time.Duration) int {
+	ix := sort.Search(len(beats), func(i int) bool { return beats[i] >= t })
+
+	switch {
+	case ix == 0:
+		return 0
+	case ix == len(beats):
+		return len(beats) - 1
+	case beats[ix]-t < t-beats[ix-1]:
+		return ix
+	default:
+		return ix - 1
+	}
+}
+
+// Returns the beat interval at a beat i.e. the interval to the previous beat or, for the first beat, to the
+// next beat.
+func interval(beats []time.Duration, i int) time.Duration {
+	if i > 0 {
+		return beats[i] - beats[i-1]
+	}
+
+	return beats[1] - beats[0]
+}
+
+func median(list []time.Duration) time.Duration {
+	intervals := make([]time.Duration, len(list)-1)
+	for i := 1; i < len(list); i++ {
+		intervals[i-1] = list[i] - list[i-1]
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+
+	N := len(intervals)
+	if N%2 == 0 {
+		return (intervals[N/2-1] + intervals[N/2]) / 2
+	}
+
+	return intervals[N/2]
+}
+
+func abs(t time.Duration) time.Duration {
+	if t < 0 {
+		return -t
+	}
+
+	return t
+}
@@ -0,0 +1,255 @@
+package evaluate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestEvaluateWithExactBeats(t *testing.T) {
+	reference := grid(500*time.Millisecond, 500*time.Millisecond, 16)
+	beats := taps2beats.Beats{BPM: 120, Beats: beatsAt(reference)}
+
+	evaluation := NewEvaluator().Evaluate(beats, reference)
+
+	for _, v := range []struct {
+		metric   string
+		value    float64
+		expected float64
+	}{
+		{"F-measure", evaluation.FMeasure, 1.0},
+		{"precision", evaluation.Precision, 1.0},
+		{"recall", evaluation.Recall, 1.0},
+		{"Cemgil", evaluation.Cemgil, 1.0},
+		{"P-score", evaluation.PScore, 1.0},
+		{"CMLc", evaluation.CMLc, 1.0},
+		{"CMLt", evaluation.CMLt, 1.0},
+		{"AMLc", evaluation.AMLc, 1.0},
+		{"AMLt", evaluation.AMLt, 1.0},
+		{"information gain", evaluation.InfoGain, math.Log2(41)},
+	} {
+		if math.Abs(v.value-v.expected) > 0.0001 {
+			t.Errorf("Incorrect %v - expected:%v, got:%v", v.metric, v.expected, v.value)
+		}
+	}
+
+	if !evaluation.Tempo.Accuracy1 || !evaluation.Tempo.Accuracy2 || evaluation.Tempo.Octave != "1" {
+		t.Errorf("Incorrect tempo accuracy - expected:%v, got:%v", Tempo{120, 120, true, true, "1"}, evaluation.Tempo)
+	}
+}
+
+func TestFMeasure(t *testing.T) {
+	reference := grid(1*time.Second, 500*time.Millisecond, 8)
+	estimated := []time.Duration{}
+	for i, r := range reference {
+		switch {
+		case i%4 == 0:
+			estimated = append(estimated, r+100*time.Millisecond) // outside the window
+		default:
+			estimated = append(estimated, r-50*time.Millisecond)
+		}
+	}
+	estimated = append(estimated, 6*time.Second)
+
+	f, p, r := FMeasure(estimated, reference, 70*time.Millisecond)
+
+	if math.Abs(p-6.0/9.0) > 0.0001 {
+		t.Errorf("Incorrect precision - expected:%v, got:%v", 6.0/9.0, p)
+	}
+
+	if math.Abs(r-6.0/8.0) > 0.0001 {
+		t.Errorf("Incorrect recall - expected:%v, got:%v", 6.0/8.0, r)
+	}
+
+	if expected := 2 * (6.0 / 9.0) * (6.0 / 8.0) / (6.0/9.0 + 6.0/8.0); math.Abs(f-expected) > 0.0001 {
+		t.Errorf("Incorrect F-measure - expected:%v, got:%v", expected, f)
+	}
+}
+
+func TestFMeasureWithDoubleMatch(t *testing.T) {
+	reference := []time.Duration{1000 * time.Millisecond}
+	estimated := []time.Duration{980 * time.Millisecond, 1010 * time.Millisecond}
+
+	_, p, r := FMeasure(estimated, reference, 70*time.Millisecond)
+
+	if p != 0.5 || r != 1.0 {
+		t.Errorf("Incorrect precision/recall - expected:%v/%v, got:%v/%v", 0.5, 1.0, p, r)
+	}
+}
+
+func TestCemgil(t *testing.T) {
+	reference := grid(1*time.Second, 500*time.Millisecond, 8)
+	estimated := shift(reference, 40*time.Millisecond)
+
+	if c := Cemgil(estimated, reference, 40*time.Millisecond); math.Abs(c-math.Exp(-0.5)) > 0.0001 {
+		t.Errorf("Incorrect Cemgil accuracy - expected:%v, got:%v", math.Exp(-0.5), c)
+	}
+}
+
+func TestPScore(t *testing.T) {
+	reference := grid(1*time.Second, 500*time.Millisecond, 8)
+
+	// ... 90ms is within 0.2 x 500ms
+	if p := PScore(shift(reference, 90*time.Millisecond), reference, 0.2); p != 1.0 {
+		t.Errorf("Incorrect P-score - expected:%v, got:%v", 1.0, p)
+	}
+
+	// ... 110ms is not
+	if p := PScore(shift(reference, 110*time.Millisecond), reference, 0.2); p != 0.0 {
+		t.Errorf("Incorrect P-score - expected:%v, got:%v", 0.0, p)
+	}
+}
+
+func TestContinuityAtDoubleTempo(t *testing.T) {
+	reference := grid(1*time.Second, 500*time.Millisecond, 16)
+	estimated := grid(1*time.Second, 250*time.Millisecond, 31)
+
+	cml := CML(estimated, reference, 0.175, 0.175)
+	aml := AML(estimated, reference, 0.175, 0.175)
+
+	if cml.Correct != 0 || cml.Total != 0 {
+		t.Errorf("Incorrect CML - expected:%v, got:%v", Continuity{}, cml)
+	}
+
+	if aml.Correct != 1 || aml.Total != 1 {
+		t.Errorf("Incorrect AML - expected:%v, got:%v", Continuity{1, 1}, aml)
+	}
+}
+
+func TestContinuityOffBeat(t *testing.T) {
+	reference := grid(1*time.Second, 500*time.Millisecond, 16)
+	estimated := shift(reference, 250*time.Millisecond)[:15]
+
+	cml := CML(estimated, reference, 0.175, 0.175)
+	aml := AML(estimated, reference, 0.175, 0.175)
+
+	if cml.Total != 0 {
+		t.Errorf("Incorrect CMLt - expected:%v, got:%v", 0.0, cml.Total)
+	}
+
+	if aml.Correct != 1 || aml.Total != 1 {
+		t.Errorf("Incorrect AML - expected:%v, got:%v", Continuity{1, 1}, aml)
+	}
+}
+
+func TestContinuityWithGap(t *testing.T) {
+	reference := grid(1*time.Second, 500*time.Millisecond, 16)
+	estimated := append(append([]time.Duration{}, reference[:4]...), reference[6:]...)
+
+	cml := CML(estimated, reference, 0.175, 0.175)
+
+	// ... beats 5 and 6 are missed and the estimated beat interval at beat 7 is 1.5s, so the longest run
+	//     is beats 8-16
+	if expected := 9.0 / 16.0; math.Abs(cml.Correct-expected) > 0.0001 {
+		t.Errorf("Incorrect CMLc - expected:%v, got:%v", expected, cml.Correct)
+	}
+
+	if expected := 13.0 / 16.0; math.Abs(cml.Total-expected) > 0.0001 {
+		t.Errorf("Incorrect CMLt - expected:%v, got:%v", expected, cml.Total)
+	}
+}
+
+func TestInformationGain(t *testing.T) {
+	reference := grid(1*time.Second, 500*time.Millisecond, 16)
+
+	exact := InformationGain(reference, reference, 41)
+	shifted := InformationGain(shift(reference, 100*time.Millisecond), reference, 41)
+
+	if math.Abs(exact-math.Log2(41)) > 0.0001 {
+		t.Errorf("Incorrect information gain - expected:%v, got:%v", math.Log2(41), exact)
+	}
+
+	// ... a constant offset is still perfectly informative
+	if math.Abs(shifted-math.Log2(41)) > 0.0001 {
+		t.Errorf("Incorrect information gain - expected:%v, got:%v", math.Log2(41), shifted)
+	}
+
+	// ... beats drifting uniformly through the beat interval are uninformative
+	reference = grid(1*time.Second, 500*time.Millisecond, 42)
+	drifting := grid(1*time.Second, 500*time.Millisecond+500*time.Millisecond/41, 41)
+
+	if g := InformationGain(drifting, reference, 41); g > 1.0 {
+		t.Errorf("Incorrect information gain - expected:<%v, got:%v", 1.0, g)
+	}
+}
+
+func TestTempoAccuracy(t *testing.T) {
+	tests := []struct {
+		estimated float64
+		expected  Tempo
+	}{
+		{120, Tempo{120, 120, true, true, "1"}},
+		{124, Tempo{124, 120, true, true, "1"}},
+		{126, Tempo{126, 120, false, false, ""}},
+		{240, Tempo{240, 120, false, true, "2"}},
+		{60, Tempo{60, 120, false, true, "1/2"}},
+		{360, Tempo{360, 120, false, true, "3"}},
+		{40, Tempo{40, 120, false, true, "1/3"}},
+		{180, Tempo{180, 120, false, false, ""}},
+	}
+
+	for _, test := range tests {
+		if tempo := TempoAccuracy(test.estimated, 120, 0.04); tempo != test.expected {
+			t.Errorf("Incorrect tempo accuracy - expected:%v, got:%v", test.expected, tempo)
+		}
+	}
+}
+
+func TestEvaluateWithSkip(t *testing.T) {
+	reference := grid(1*time.Second, 500*time.Millisecond, 16)
+	estimated := append(grid(0, 330*time.Millisecond, 15), reference[8:]...)
+
+	evaluator := NewEvaluator()
+	evaluator.Skip = 5 * time.Second
+
+	evaluation := evaluator.Evaluate(taps2beats.Beats{Beats: beatsAt(estimated)}, reference)
+
+	if evaluation.Beats != 8 || evaluation.Reference != 8 {
+		t.Errorf("Incorrect number of beats - expected:%v/%v, got:%v/%v", 8, 8, evaluation.Beats, evaluation.Reference)
+	}
+
+	if evaluation.FMeasure != 1.0 {
+		t.Errorf("Incorrect F-measure - expected:%v, got:%v", 1.0, evaluation.FMeasure)
+	}
+
+	if !evaluation.Tempo.Accuracy1 {
+		t.Errorf("Incorrect tempo accuracy - expected:%v, got:%v", true, evaluation.Tempo.Accuracy1)
+	}
+}
+
+func TestEvaluateWithNoBeats(t *testing.T) {
+	evaluation := NewEvaluator().Evaluate(taps2beats.Beats{}, grid(1*time.Second, 500*time.Millisecond, 8))
+
+	if evaluation.FMeasure != 0 || evaluation.Cemgil != 0 || evaluation.PScore != 0 || evaluation.AMLt != 0 || evaluation.InfoGain != 0 {
+		t.Errorf("Incorrect evaluation - expected:all zero, got:%+v", evaluation)
+	}
+}
+
+func grid(start, interval time.Duration, N int) []time.Duration {
+	beats := []time.Duration{}
+	for i := 0; i < N; i++ {
+		beats = append(beats, start+time.Duration(i)*interval)
+	}
+
+	return beats
+}
+
+func shift(beats []time.Duration, dt time.Duration) []time.Duration {
+	list := []time.Duration{}
+	for _, t := range beats {
+		list = append(list, t+dt)
+	}
+
+	return list
+}
+
+func beatsAt(times []time.Duration) []taps2beats.Beat {
+	beats := []taps2beats.Beat{}
+	for _, t := range times {
+		beats = append(beats, taps2beats.Beat{At: t, Mean: t, Taps: []time.Duration{t}})
+	}
+
+	return beats
+}
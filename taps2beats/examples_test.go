@@ -64,7 +64,7 @@ func ExampleTaps2Beats() {
 		{4.517911093, 5.069403016, 5.586174007, 6.108568986, 6.578649068, 7.147523957, 7.681606914, 8.262110780},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.1)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.1)
 
 	fmt.Printf("%v", beats)
 
@@ -97,7 +97,7 @@ func ExampleBeats_Quantize() {
 		{4.517911093, 5.069403016, 5.586174007, 6.108568986, 6.578649068, 7.147523957, 7.681606914, 8.262110780},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.1)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.1)
 
 	beats.Quantize()
 
@@ -132,7 +132,7 @@ func ExampleBeats_Interpolate() {
 		{4.517911093, 5.069403016, 5.586174007, 6.108568986, 6.578649068, 7.147523957, 7.681606914, 8.262110780},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.1)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.1)
 	start := 1 * time.Second
 	end := 10 * time.Second
 
@@ -178,7 +178,7 @@ func ExampleBeats_Round() {
 		{4.517911093, 5.069403016, 5.586174007, 6.108568986, 6.578649068, 7.147523957, 7.681606914, 8.262110780},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.1)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.1)
 
 	beats.Round(1 * time.Millisecond)
 
@@ -213,7 +213,7 @@ func ExampleBeats_Sub() {
 		{4.517911093, 5.069403016, 5.586174007, 6.108568986, 6.578649068, 7.147523957, 7.681606914, 8.262110780},
 	}
 
-	beats := Taps2Beats(Floats2Seconds(taps), 0.1)
+	beats := Taps2Beats(NewTapSetFromFloats(taps), 0.1)
 
 	beats.Sub(129 * time.Millisecond)
 
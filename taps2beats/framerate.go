@@ -0,0 +1,207 @@
+package taps2beats
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A video frame rate, as a rational number of frames per second (e.g. 30000/1001 for 29.97fps) and
+// whether timecode is drop-frame (only for the 29.97 and 59.94 NTSC rates).
+//
+// Frames are converted to and from times exactly (to the nanosecond) using the rational rate and timecode
+// counts frames at the nominal (integer) rate i.e. 00:10:00:00 at 29.97fps is frame 18000 (600.6s) and
+// 00:10:00;00 (drop-frame) is frame 17982 (599.9994s). A zero (or otherwise invalid) frame rate has no
+// frames i.e. every time is frame 0, every frame is at 0s and the timecode is --:--:--:--.
+type FrameRate struct {
+	Num       int64
+	Den       int64
+	DropFrame bool
+}
+
+// Parses a frame rate as either a rational (e.g. 30000/1001) or decimal (e.g. 25 or 29.97) number of
+// frames per second. The NTSC rates 23.976, 29.97, 47.952 and 59.94 are converted to the exact rational
+// rate (i.e. N*1000/1001).
+func ParseFrameRate(s string, dropFrame bool) (FrameRate, error) {
+	var r FrameRate
+
+	if tokens := strings.Split(s, "/"); len(tokens) == 2 {
+		num, err := strconv.ParseInt(strings.TrimSpace(tokens[0]), 10, 64)
+		if err != nil {
+			return r, fmt.Errorf("invalid frame rate (%v)", s)
+		}
+
+		den, err := strconv.ParseInt(strings.TrimSpace(tokens[1]), 10, 64)
+		if err != nil {
+			return r, fmt.Errorf("invalid frame rate (%v)", s)
+		}
+
+		r = FrameRate{Num: num, Den: den}
+	} else {
+		fps, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return r, fmt.Errorf("invalid frame rate (%v)", s)
+		}
+
+		nominal := math.Round(fps)
+		if fps == nominal {
+			r = FrameRate{Num: int64(nominal), Den: 1}
+		} else if math.Abs(fps-nominal*1000/1001) < 0.005 {
+			r = FrameRate{Num: int64(nominal) * 1000, Den: 1001}
+		} else {
+			return r, fmt.Errorf("unsupported frame rate (%v)", s)
+		}
+	}
+
+	if r.Num <= 0 || r.Den <= 0 {
+		return r, fmt.Errorf("invalid frame rate (%v)", s)
+	}
+
+	if dropFrame {
+		if !r.dropFrameRate() {
+			return r, fmt.Errorf("drop-frame timecode requires a 29.97 or 59.94 frame rate (%v)", s)
+		}
+
+		r.DropFrame = true
+	}
+
+	return r, nil
+}
+
+// Returns the frame rate as frames per second.
+func (r FrameRate) FPS() float64 {
+	return float64(r.Num) / float64(r.Den)
+}
+
+// Returns the frame duration.
+func (r FrameRate) FrameDuration() time.Duration {
+	return r.Time(1)
+}
+
+// Returns the frame nearest to a time, with halves rounded away from zero.
+func (r FrameRate) Frame(t time.Duration) int64 {
+	if !r.valid() {
+		return 0
+	}
+
+	return round(int64(t)*r.Num, r.Den*int64(time.Second))
+}
+
+// Returns the time of a frame, rounded to the nearest nanosecond.
+func (r FrameRate) Time(frame int64) time.Duration {
+	if !r.valid() {
+		return 0
+	}
+
+	return time.Duration(round(frame*r.Den*int64(time.Second), r.Num))
+}
+
+// Formats a frame as hh:mm:ss:ff timecode (hh:mm:ss;ff for drop-frame timecode, which skips frame numbers
+// 0 and 1 (0-3 for 59.94) at the start of every minute except every tenth minute). Negative frames are
+// formatted with a leading '-'.
+func (r FrameRate) Timecode(frame int64) string {
+	if frame < 0 {
+		return "-" + r.Timecode(-frame)
+	}
+
+	nominal := r.nominal()
+	if nominal <= 0 {
+		return "--:--:--:--"
+	}
+
+	if r.DropFrame {
+		drop := nominal / 15
+		perMinute := nominal*60 - drop
+		perTenMinutes := nominal*600 - drop*9
+
+		D := frame / perTenMinutes
+		M := frame % perTenMinutes
+		if M > drop {
+			frame += drop*9*D + drop*((M-drop)/perMinute)
+		} else {
+			frame += drop * 9 * D
+		}
+	}
+
+	ff := frame % nominal
+	ss := (frame / nominal) % 60
+	mm := (frame / (nominal * 60)) % 60
+	hh := frame / (nominal * 3600)
+
+	separator := ":"
+	if r.DropFrame {
+		separator = ";"
+	}
+
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", hh, mm, ss, separator, ff)
+}
+
+// Parses hh:mm:ss:ff (or hh:mm:ss;ff drop-frame) timecode as a frame number. Timecode with a ';' (or '.')
+// frame separator is drop-frame timecode, as is all timecode for a drop-frame rate.
+func (r FrameRate) ParseTimecode(s string) (int64, error) {
+	if strings.HasPrefix(s, "-") {
+		frame, err := r.ParseTimecode(s[1:])
+		return -frame, err
+	}
+
+	var hh, mm, ss, ff int64
+	var separator rune
+	if _, err := fmt.Sscanf(s, "%d:%d:%d%c%d", &hh, &mm, &ss, &separator, &ff); err != nil {
+		return 0, fmt.Errorf("invalid timecode (%v)", s)
+	}
+
+	nominal := r.nominal()
+	if nominal <= 0 {
+		return 0, fmt.Errorf("invalid frame rate (%v/%v)", r.Num, r.Den)
+	}
+
+	if mm > 59 || ss > 59 || ff >= nominal || (separator != ':' && separator != ';' && separator != '.') {
+		return 0, fmt.Errorf("invalid timecode (%v)", s)
+	}
+
+	frame := nominal*(3600*hh+60*mm+ss) + ff
+	if r.DropFrame || separator == ';' || separator == '.' {
+		if !r.dropFrameRate() {
+			return 0, fmt.Errorf("drop-frame timecode requires a 29.97 or 59.94 frame rate (%v)", s)
+		}
+
+		drop := nominal / 15
+		if ss == 0 && mm%10 != 0 && ff < drop {
+			return 0, fmt.Errorf("invalid drop-frame timecode (%v)", s)
+		}
+
+		minutes := 60*hh + mm
+		frame -= drop * (minutes - minutes/10)
+	}
+
+	return frame, nil
+}
+
+func (r FrameRate) String() string {
+	if r.Den == 1 {
+		return fmt.Sprintf("%d", r.Num)
+	}
+
+	return fmt.Sprintf("%.3g", r.FPS())
+}
+
+// Returns true for a positive rational frame rate.
+func (r FrameRate) valid() bool {
+	return r.Num > 0 && r.Den > 0
+}
+
+// Returns the nominal (integer) frame rate used for timecode.
+func (r FrameRate) nominal() int64 {
+	if !r.valid() {
+		return 0
+	}
+
+	return int64(math.Round(r.FPS()))
+}
+
+// Returns true for the NTSC 29.97 and 59.94 rates, which are the only rates with drop-frame timecode.
+func (r FrameRate) dropFrameRate() bool {
+	return r.Den == 1001 && (r.nominal() == 30 || r.nominal() == 60)
+}
@@ -0,0 +1,149 @@
+package taps2beats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFrameRate(t *testing.T) {
+	tests := []struct {
+		s        string
+		drop     bool
+		expected FrameRate
+	}{
+		{"25", false, FrameRate{25, 1, false}},
+		{"24", false, FrameRate{24, 1, false}},
+		{"23.976", false, FrameRate{24000, 1001, false}},
+		{"29.97", true, FrameRate{30000, 1001, true}},
+		{"59.94", true, FrameRate{60000, 1001, true}},
+		{"30000/1001", false, FrameRate{30000, 1001, false}},
+	}
+
+	for _, test := range tests {
+		r, err := ParseFrameRate(test.s, test.drop)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %v (%v)", test.s, err)
+		}
+
+		if r != test.expected {
+			t.Errorf("Incorrect frame rate for %v - expected:%v, got:%v", test.s, test.expected, r)
+		}
+	}
+
+	invalid := []struct {
+		s    string
+		drop bool
+	}{
+		{"twenty five", false},
+		{"27.5", false},
+		{"0", false},
+		{"25", true},
+		{"23.976", true},
+		{"30/0", false},
+	}
+
+	for _, test := range invalid {
+		if _, err := ParseFrameRate(test.s, test.drop); err == nil {
+			t.Errorf("Expected error for frame rate %v (drop frame:%v)", test.s, test.drop)
+		}
+	}
+}
+
+func TestFrame(t *testing.T) {
+	r := FrameRate{Num: 25, Den: 1}
+
+	tests := []struct {
+		t     time.Duration
+		frame int64
+		shift time.Duration
+	}{
+		{0, 0, 0},
+		{1 * time.Second, 25, 0},
+		{1010 * time.Millisecond, 25, -10 * time.Millisecond},
+		{1030 * time.Millisecond, 26, 10 * time.Millisecond},
+		{-100 * time.Millisecond, -3, -20 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		if frame := r.Frame(test.t); frame != test.frame {
+			t.Errorf("Incorrect frame for %v - expected:%v, got:%v", test.t, test.frame, frame)
+		}
+
+		if at := r.Time(test.frame); at != test.t+test.shift {
+			t.Errorf("Incorrect time for frame %v - expected:%v, got:%v", test.frame, test.t+test.shift, at)
+		}
+	}
+}
+
+func TestTimecode(t *testing.T) {
+	tests := []struct {
+		rate     FrameRate
+		frame    int64
+		expected string
+	}{
+		{FrameRate{25, 1, false}, 0, "00:00:00:00"},
+		{FrameRate{25, 1, false}, 90061, "01:00:02:11"},
+		{FrameRate{30000, 1001, false}, 1800, "00:01:00:00"},
+		{FrameRate{30000, 1001, true}, 1799, "00:00:59;29"},
+		{FrameRate{30000, 1001, true}, 1800, "00:01:00;02"},
+		{FrameRate{30000, 1001, true}, 17982, "00:10:00;00"},
+		{FrameRate{30000, 1001, true}, 107892, "01:00:00;00"},
+		{FrameRate{60000, 1001, true}, 3600, "00:01:00;04"},
+		{FrameRate{}, 3600, "--:--:--:--"},
+	}
+
+	for _, test := range tests {
+		if tc := test.rate.Timecode(test.frame); tc != test.expected {
+			t.Errorf("Incorrect timecode for frame %v at %v - expected:%v, got:%v", test.frame, test.rate, test.expected, tc)
+		}
+	}
+}
+
+func TestParseTimecode(t *testing.T) {
+	tests := []struct {
+		rate     FrameRate
+		timecode string
+		frame    int64
+		at       time.Duration
+	}{
+		{FrameRate{25, 1, false}, "00:00:01:12", 37, 1480 * time.Millisecond},
+		{FrameRate{30000, 1001, false}, "00:10:00:00", 18000, 600600 * time.Millisecond},
+		{FrameRate{30000, 1001, false}, "00:10:00;00", 17982, 599999400 * time.Microsecond},
+		{FrameRate{30000, 1001, true}, "00:01:00;02", 1800, 60060 * time.Millisecond},
+		{FrameRate{30000, 1001, true}, "01:00:00;00", 107892, 3599996400 * time.Microsecond},
+		{FrameRate{60000, 1001, true}, "00:01:00;04", 3600, 60060 * time.Millisecond},
+		{FrameRate{25, 1, false}, "-00:00:01:00", -25, -1 * time.Second},
+	}
+
+	for _, test := range tests {
+		frame, err := test.rate.ParseTimecode(test.timecode)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %v (%v)", test.timecode, err)
+		}
+
+		if frame != test.frame {
+			t.Errorf("Incorrect frame for %v at %v - expected:%v, got:%v", test.timecode, test.rate, test.frame, frame)
+		}
+
+		if at := test.rate.Time(frame); at != test.at {
+			t.Errorf("Incorrect time for %v at %v - expected:%v, got:%v", test.timecode, test.rate, test.at, at)
+		}
+	}
+
+	invalid := []struct {
+		rate     FrameRate
+		timecode string
+	}{
+		{FrameRate{25, 1, false}, "00:00:01:25"},
+		{FrameRate{25, 1, false}, "00:00:01;12"},
+		{FrameRate{30000, 1001, true}, "00:01:00;00"},
+		{FrameRate{25, 1, false}, "00:61:00:00"},
+		{FrameRate{25, 1, false}, "1.5"},
+	}
+
+	for _, test := range invalid {
+		if _, err := test.rate.ParseTimecode(test.timecode); err == nil {
+			t.Errorf("Expected error for timecode %v at %v", test.timecode, test.rate)
+		}
+	}
+}
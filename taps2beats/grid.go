@@ -0,0 +1,127 @@
+package taps2beats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Beat grid for a set of beats i.e. the beats in time order with the position of each beat on the grid,
+// allowing for missing beats, and the bar lines from the first downbeat.
+//
+// Bars are numbered from the first downbeat, with the beats before the first downbeat in bar 0.
+type Grid struct {
+	Beats       []Beat        // beats in time order
+	Positions   []int         // grid position of each beat, relative to the first beat
+	Period      time.Duration // beat interval i.e. the median interval between beats (0 for a single beat without a BPM)
+	BeatsPerBar int           // beats per bar
+	Downbeat    int           // grid position of the first bar line (0 to BeatsPerBar-1)
+}
+
+// Places the beats on a beat grid, with the grid position of each beat estimated from the median beat
+// interval (so that a missing beat leaves a gap in the grid). The downbeat is the index of the first
+// downbeat in the list of beats - an index outside the list of beats is taken as a grid position.
+func (beats Beats) Grid(beatsPerBar, downbeat int) (*Grid, error) {
+	if beatsPerBar <= 0 {
+		return nil, fmt.Errorf("invalid beats per bar (%v)", beatsPerBar)
+	}
+
+	if len(beats.Beats) == 0 {
+		return nil, fmt.Errorf("no beats")
+	}
+
+	list := make([]Beat, len(beats.Beats))
+	copy(list, beats.Beats)
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].At < list[j].At })
+
+	period := time.Duration(0)
+	if N := len(list); N > 1 {
+		intervals := make([]time.Duration, N-1)
+		for i := 1; i < N; i++ {
+			intervals[i-1] = list[i].At - list[i-1].At
+		}
+
+		sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+
+		if M := len(intervals); M%2 == 0 {
+			period = (intervals[M/2-1] + intervals[M/2]) / 2
+		} else {
+			period = intervals[M/2]
+		}
+	} else if beats.BPM > 0 {
+		period = time.Minute / time.Duration(beats.BPM)
+	}
+
+	positions := make([]int, len(list))
+	for i := 1; i < len(list); i++ {
+		step := 1
+		if period > 0 {
+			step = int(math.Round(float64(list[i].At-list[i-1].At) / float64(period)))
+		}
+
+		if step < 1 {
+			step = 1
+		}
+
+		positions[i] = positions[i-1] + step
+	}
+
+	first := downbeat
+	if downbeat >= 0 && downbeat < len(positions) {
+		first = positions[downbeat]
+	}
+
+	return &Grid{
+		Beats:       list,
+		Positions:   positions,
+		Period:      period,
+		BeatsPerBar: beatsPerBar,
+		Downbeat:    ((first % beatsPerBar) + beatsPerBar) % beatsPerBar,
+	}, nil
+}
+
+// Returns the 1-based bar and beat in the bar for a grid position.
+func (g Grid) Bar(position int) (int, int) {
+	p := position - g.Downbeat
+	bar := int(math.Floor(float64(p)/float64(g.BeatsPerBar))) + 1
+
+	return bar, ((p%g.BeatsPerBar)+g.BeatsPerBar)%g.BeatsPerBar + 1
+}
+
+// Returns the grid positions of the bar lines from the first downbeat to the last beat.
+func (g Grid) Bars() []int {
+	list := []int{}
+	last := g.Positions[len(g.Positions)-1]
+
+	for p := g.Downbeat; p <= last; p += g.BeatsPerBar {
+		list = append(list, p)
+	}
+
+	return list
+}
+
+// Returns the time of a grid position, interpolated linearly between the beats (and extrapolated from
+// the beat interval before the first and after the last beat).
+func (g Grid) At(position int) time.Duration {
+	N := len(g.Positions)
+
+	if position <= g.Positions[0] {
+		return g.Beats[0].At - time.Duration(g.Positions[0]-position)*g.Period
+	}
+
+	if position >= g.Positions[N-1] {
+		return g.Beats[N-1].At + time.Duration(position-g.Positions[N-1])*g.Period
+	}
+
+	i := sort.SearchInts(g.Positions, position)
+	if g.Positions[i] == position {
+		return g.Beats[i].At
+	}
+
+	p0, p1 := g.Positions[i-1], g.Positions[i]
+	t0, t1 := g.Beats[i-1].At, g.Beats[i].At
+
+	return t0 + time.Duration(float64(t1-t0)*float64(position-p0)/float64(p1-p0))
+}
@@ -0,0 +1,103 @@
+package taps2beats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGrid(t *testing.T) {
+	beats := Beats{
+		Beats: []Beat{
+			{At: 1500 * time.Millisecond},
+			{At: 500 * time.Millisecond},
+			{At: 1000 * time.Millisecond},
+			{At: 2500 * time.Millisecond},
+			{At: 3000 * time.Millisecond},
+		},
+	}
+
+	g, err := beats.Grid(4, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if expected := []int{0, 1, 2, 4, 5}; !reflect.DeepEqual(g.Positions, expected) {
+		t.Errorf("Incorrect positions - expected:%v, got:%v", expected, g.Positions)
+	}
+
+	if g.Beats[0].At != 500*time.Millisecond || g.Beats[4].At != 3000*time.Millisecond {
+		t.Errorf("Incorrect beat order - got:%v", g.Beats)
+	}
+
+	if g.Period != 500*time.Millisecond {
+		t.Errorf("Incorrect period - expected:%v, got:%v", 500*time.Millisecond, g.Period)
+	}
+
+	if g.Downbeat != 1 {
+		t.Errorf("Incorrect downbeat - expected:%v, got:%v", 1, g.Downbeat)
+	}
+
+	if expected := []int{1, 5}; !reflect.DeepEqual(g.Bars(), expected) {
+		t.Errorf("Incorrect bar lines - expected:%v, got:%v", expected, g.Bars())
+	}
+
+	tests := []struct {
+		position int
+		bar      int
+		beat     int
+		at       time.Duration
+	}{
+		{-1, 0, 3, 0},
+		{0, 0, 4, 500 * time.Millisecond},
+		{1, 1, 1, 1000 * time.Millisecond},
+		{3, 1, 3, 2000 * time.Millisecond},
+		{5, 2, 1, 3000 * time.Millisecond},
+		{6, 2, 2, 3500 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		bar, beat := g.Bar(test.position)
+		if bar != test.bar || beat != test.beat {
+			t.Errorf("Incorrect bar for position %v - expected:%v.%v, got:%v.%v", test.position, test.bar, test.beat, bar, beat)
+		}
+
+		if at := g.At(test.position); at != test.at {
+			t.Errorf("Incorrect time for position %v - expected:%v, got:%v", test.position, test.at, at)
+		}
+	}
+}
+
+func TestGridDownbeatOutsideBeats(t *testing.T) {
+	beats := Beats{
+		BPM:   120,
+		Beats: []Beat{{At: 500 * time.Millisecond}},
+	}
+
+	g, err := beats.Grid(3, -1)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if g.Period != 500*time.Millisecond {
+		t.Errorf("Incorrect period - expected:%v, got:%v", 500*time.Millisecond, g.Period)
+	}
+
+	if g.Downbeat != 2 {
+		t.Errorf("Incorrect downbeat - expected:%v, got:%v", 2, g.Downbeat)
+	}
+
+	if bar, beat := g.Bar(0); bar != 0 || beat != 2 {
+		t.Errorf("Incorrect bar - expected:%v.%v, got:%v.%v", 0, 2, bar, beat)
+	}
+}
+
+func TestGridWithInvalidSettings(t *testing.T) {
+	if _, err := (Beats{}).Grid(4, 0); err == nil {
+		t.Errorf("Expected error for no beats")
+	}
+
+	if _, err := (Beats{Beats: []Beat{{At: time.Second}}}).Grid(0, 0); err == nil {
+		t.Errorf("Expected error for invalid beats per bar")
+	}
+}
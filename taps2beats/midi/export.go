@@ -0,0 +1,260 @@
+package midi
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Selects how the beats are converted to tempo events.
+type TempoMap int
+
+const (
+	Auto     TempoMap = iota // constant tempo if the beats are evenly spaced (e.g. quantized), otherwise per beat
+	Constant                 // a single tempo event for the average beat interval
+	PerBeat                  // a tempo event for every beat interval
+)
+
+// Settings for exporting a set of beats as a Standard MIDI File.
+//
+// The exported file has a 'conductor' track with the time signature, tempo and bar marker events
+// and (optionally) a second track with a note for every beat. Beats are placed on consecutive
+// beat ticks, so that the beats line up with the bars and beats in a DAW, and the tempo before
+// the first beat is set so that the first beat falls on a bar line (if it is a downbeat) at the
+// time of the first beat in the audio.
+type Export struct {
+	PPQ         int      // ticks per quarter note
+	BeatsPerBar int      // time signature numerator (0 for no time signature or bar markers)
+	BeatUnit    int      // time signature denominator i.e. 4 for quarter notes, 8 for eighths
+	Downbeat    int      // index of the first downbeat in the list of beats
+	TempoMap    TempoMap // tempo event policy
+	Clicks      bool     // adds a track with a note for every beat
+	Channel     int      // MIDI channel (0-15) for the click notes
+	Note        int      // MIDI note for the clicks
+	Accent      int      // MIDI note for the downbeat clicks
+	Velocity    int      // MIDI velocity for the clicks
+}
+
+// Returns the default export settings i.e. 480 ticks per quarter note, 4/4 time, automatic tempo
+// map and General MIDI woodblock clicks on channel 10 (if enabled).
+func NewExport() Export {
+	return Export{
+		PPQ:         480,
+		BeatsPerBar: 4,
+		BeatUnit:    4,
+		TempoMap:    Auto,
+		Channel:     9,
+		Note:        77,
+		Accent:      76,
+		Velocity:    100,
+	}
+}
+
+// Writes the beats as a type 1 Standard MIDI File.
+//
+// The tempo is stored as integer microseconds per quarter note so the beat times in the MIDI file
+// may differ from the original beat times by up to 0.5µs per beat for a constant tempo map.
+func (x Export) Write(w io.Writer, beats taps2beats.Beats) error {
+	if x.PPQ <= 0 || x.PPQ > 0x7fff {
+		return fmt.Errorf("invalid ticks per quarter note (%v)", x.PPQ)
+	}
+
+	unit := x.BeatUnit
+	if unit == 0 {
+		unit = 4
+	}
+
+	if unit&(unit-1) != 0 || unit > 64 || (x.PPQ*4)%unit != 0 {
+		return fmt.Errorf("invalid beat unit (%v)", x.BeatUnit)
+	}
+
+	if len(beats.Beats) == 0 {
+		return fmt.Errorf("no beats")
+	}
+
+	list := make([]time.Duration, len(beats.Beats))
+	for i, b := range beats.Beats {
+		list[i] = b.At
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+
+	if list[0] < 0 {
+		return fmt.Errorf("first beat (%v) is before 0s", list[0])
+	}
+
+	tpb := x.PPQ * 4 / unit // ticks per beat
+	quarters := 4.0 / float64(unit)
+
+	// ... beat interval (taken from the BPM for a single beat)
+	period := time.Duration(0)
+	if N := len(list); N > 1 {
+		period = median(list)
+	} else if beats.BPM > 0 {
+		period = time.Minute / time.Duration(beats.BPM)
+	} else {
+		return fmt.Errorf("insufficient data")
+	}
+
+	// ... number of beats between consecutive beats, allowing for missing beats
+	steps := make([]int, len(list))
+	for i := 1; i < len(list); i++ {
+		steps[i] = int(math.Round(float64(list[i]-list[i-1]) / float64(period)))
+		if steps[i] < 1 {
+			steps[i] = 1
+		}
+	}
+
+	// ... beat positions relative to the first beat
+	positions := make([]int, len(list))
+	for i := 1; i < len(list); i++ {
+		positions[i] = positions[i-1] + steps[i]
+	}
+
+	// ... lead-in beats before the first beat, chosen so that the first downbeat is on a bar line (the
+	//     lead-in is squeezed into a few microseconds if the first beat is at 0s and is not a downbeat)
+	downbeat := 0
+	if x.BeatsPerBar > 0 {
+		if x.Downbeat >= 0 && x.Downbeat < len(positions) {
+			downbeat = mod(positions[x.Downbeat], x.BeatsPerBar)
+		} else {
+			downbeat = mod(x.Downbeat, x.BeatsPerBar)
+		}
+	}
+
+	lead := int(math.Round(float64(list[0]) / float64(period)))
+	if lead == 0 && list[0] > 0 {
+		lead = 1
+	}
+
+	if x.BeatsPerBar > 0 {
+		for mod(lead+downbeat, x.BeatsPerBar) != 0 {
+			lead++
+		}
+	}
+
+	ticks := make([]int, len(list))
+	for i, p := range positions {
+		ticks[i] = (lead + p) * tpb
+	}
+
+	mpq := func(interval time.Duration, beats int) int {
+		v := math.Round(float64(interval.Microseconds()) / float64(beats) / quarters)
+		if v < 1 {
+			return 1
+		} else if v > 0xffffff {
+			return 0xffffff
+		}
+
+		return int(v)
+	}
+
+	tempo := func(tick int, mpq int) event {
+		return meta(tick, metaTempo, []byte{byte(mpq >> 16), byte(mpq >> 8), byte(mpq)})
+	}
+
+	conductor := track{
+		meta(0, metaTrackName, []byte("taps2beats")),
+	}
+
+	if x.BeatsPerBar > 0 {
+		dd := byte(math.Round(math.Log2(float64(unit))))
+		conductor = append(conductor, meta(0, metaTimeSignature, []byte{byte(x.BeatsPerBar), dd, 24, 8}))
+	}
+
+	// ... tempo map
+	constant := x.TempoMap == Constant
+	if x.TempoMap == Auto {
+		constant = true
+		for i := 1; i < len(list); i++ {
+			if d := list[i] - list[i-1] - time.Duration(steps[i])*period; d > time.Millisecond || d < -time.Millisecond {
+				constant = false
+				break
+			}
+		}
+	}
+
+	average := mpq(period, 1)
+	if N := len(list); N > 1 {
+		average = mpq(list[N-1]-list[0], (ticks[N-1]-ticks[0])/tpb)
+	}
+
+	if lead > 0 {
+		conductor = append(conductor, tempo(0, mpq(list[0], lead)))
+	}
+
+	if constant {
+		if lead == 0 || mpq(list[0], lead) != average {
+			conductor = append(conductor, tempo(ticks[0], average))
+		}
+	} else {
+		for i := 0; i < len(list)-1; i++ {
+			conductor = append(conductor, tempo(ticks[i], mpq(list[i+1]-list[i], steps[i+1])))
+		}
+
+		conductor = append(conductor, tempo(ticks[len(list)-1], average))
+	}
+
+	// ... bar markers
+	if x.BeatsPerBar > 0 {
+		bar := 1
+		length := x.BeatsPerBar * tpb
+		end := ticks[len(ticks)-1]
+		for tick := (ticks[0] + length - 1) / length * length; tick <= end; tick += length {
+			conductor = append(conductor, meta(tick, metaMarker, []byte(fmt.Sprintf("Bar %d", bar))))
+			bar++
+		}
+	}
+
+	tracks := []track{conductor}
+
+	// ... clicks
+	if x.Clicks {
+		clicks := track{
+			meta(0, metaTrackName, []byte("clicks")),
+		}
+
+		duration := tpb / 4
+		if duration < 1 {
+			duration = 1
+		}
+
+		for _, tick := range ticks {
+			key := x.Note
+			if x.BeatsPerBar > 0 && (tick/tpb)%x.BeatsPerBar == 0 {
+				key = x.Accent
+			}
+
+			clicks = append(clicks, note(tick, noteOn, x.Channel, key, x.Velocity))
+			clicks = append(clicks, note(tick+duration, noteOff, x.Channel, key, 0))
+		}
+
+		tracks = append(tracks, clicks)
+	}
+
+	return write(w, x.PPQ, tracks)
+}
+
+func median(list []time.Duration) time.Duration {
+	intervals := make([]time.Duration, len(list)-1)
+	for i := 1; i < len(list); i++ {
+		intervals[i-1] = list[i] - list[i-1]
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+
+	N := len(intervals)
+	if N%2 == 0 {
+		return (intervals[N/2-1] + intervals[N/2]) / 2
+	}
+
+	return intervals[N/2]
+}
+
+func mod(i, n int) int {
+	return ((i % n) + n) % n
+}
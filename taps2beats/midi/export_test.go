@@ -0,0 +1,321 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+type decoded struct {
+	tick int
+	data []byte
+}
+
+func TestExportWithConstantTempo(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:   120,
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0, 2.5, 3.0, 3.5, 4.0, 4.5),
+	}
+
+	export := NewExport()
+	export.Clicks = true
+
+	var b bytes.Buffer
+	if err := export.Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	ppq, tracks := parse(b.Bytes(), t)
+	if ppq != 480 {
+		t.Errorf("Incorrect PPQ - expected:%v, got:%v", 480, ppq)
+	}
+
+	if len(tracks) != 2 {
+		t.Fatalf("Incorrect number of tracks - expected:%v, got:%v", 2, len(tracks))
+	}
+
+	// ... 4 beat lead-in so that the first beat is on the first bar line
+	tempos := filter(tracks[0], 0xff, metaTempo)
+	expected := []decoded{
+		{0, []byte{0xff, metaTempo, 3, 0x01, 0xe8, 0x48}},
+		{1920, []byte{0xff, metaTempo, 3, 0x07, 0xa1, 0x20}},
+	}
+
+	if !reflect.DeepEqual(tempos, expected) {
+		t.Errorf("Incorrect tempo map\n   expected:%v\n   got:     %v", expected, tempos)
+	}
+
+	markers := filter(tracks[0], 0xff, metaMarker)
+	if len(markers) != 3 {
+		t.Fatalf("Incorrect number of markers - expected:%v, got:%v", 3, len(markers))
+	}
+
+	for i, m := range markers {
+		if m.tick != 1920*(i+1) {
+			t.Errorf("Incorrect marker %d tick - expected:%v, got:%v", i+1, 1920*(i+1), m.tick)
+		}
+	}
+
+	if s := string(markers[1].data[3:]); s != "Bar 2" {
+		t.Errorf("Incorrect marker - expected:%v, got:%v", "Bar 2", s)
+	}
+
+	clicks := filter(tracks[1], noteOn|9, -1)
+	if len(clicks) != len(beats.Beats) {
+		t.Fatalf("Incorrect number of clicks - expected:%v, got:%v", len(beats.Beats), len(clicks))
+	}
+
+	for i, c := range clicks {
+		at := seconds(c.tick, ppq, tempos)
+		if math.Abs(at-beats.Beats[i].At.Seconds()) > 0.000005 {
+			t.Errorf("Incorrect click %d time - expected:%v, got:%v", i+1, beats.Beats[i].At.Seconds(), at)
+		}
+
+		key := 77
+		if i%4 == 0 {
+			key = 76
+		}
+
+		if int(c.data[1]) != key {
+			t.Errorf("Incorrect click %d note - expected:%v, got:%v", i+1, key, c.data[1])
+		}
+	}
+}
+
+func TestExportWithPerBeatTempo(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(1.02, 1.51, 2.03, 2.50, 3.55, 4.04),
+	}
+
+	export := NewExport()
+	export.Clicks = true
+	export.Downbeat = 1
+
+	var b bytes.Buffer
+	if err := export.Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	ppq, tracks := parse(b.Bytes(), t)
+	tempos := filter(tracks[0], 0xff, metaTempo)
+	clicks := filter(tracks[1], noteOn|9, -1)
+
+	if len(tempos) != 7 {
+		t.Errorf("Incorrect number of tempo events - expected:%v, got:%v", 7, len(tempos))
+	}
+
+	// ... beat 5 is missing so the clicks are on beats 1,2,3,4,6,7 of the tick grid
+	steps := []int{1, 1, 1, 2, 1}
+	for i, s := range steps {
+		if dt := clicks[i+1].tick - clicks[i].tick; dt != s*ppq {
+			t.Errorf("Incorrect click %d tick - expected:%v, got:%v", i+2, s*ppq, dt)
+		}
+	}
+
+	if clicks[1].tick%(4*ppq) != 0 {
+		t.Errorf("Downbeat is not on a bar line (tick %v)", clicks[1].tick)
+	}
+
+	for i, c := range clicks {
+		at := seconds(c.tick, ppq, tempos)
+		if math.Abs(at-beats.Beats[i].At.Seconds()) > 0.000005 {
+			t.Errorf("Incorrect click %d time - expected:%v, got:%v", i+1, beats.Beats[i].At.Seconds(), at)
+		}
+	}
+}
+
+func TestExportWithConfiguration(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.0, 0.25, 0.5, 0.75, 1.0, 1.25, 1.5),
+	}
+
+	export := NewExport()
+	export.PPQ = 96
+	export.BeatsPerBar = 6
+	export.BeatUnit = 8
+	export.TempoMap = Constant
+
+	var b bytes.Buffer
+	if err := export.Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	ppq, tracks := parse(b.Bytes(), t)
+	if ppq != 96 {
+		t.Errorf("Incorrect PPQ - expected:%v, got:%v", 96, ppq)
+	}
+
+	if len(tracks) != 1 {
+		t.Errorf("Incorrect number of tracks - expected:%v, got:%v", 1, len(tracks))
+	}
+
+	signature := filter(tracks[0], 0xff, metaTimeSignature)
+	if len(signature) != 1 || !bytes.Equal(signature[0].data[3:], []byte{6, 3, 24, 8}) {
+		t.Errorf("Incorrect time signature - got:%v", signature)
+	}
+
+	// ... eighth note beats at 0.25s => 500000µs per quarter note
+	tempos := filter(tracks[0], 0xff, metaTempo)
+	expected := []decoded{
+		{0, []byte{0xff, metaTempo, 3, 0x07, 0xa1, 0x20}},
+	}
+
+	if !reflect.DeepEqual(tempos, expected) {
+		t.Errorf("Incorrect tempo map\n   expected:%v\n   got:     %v", expected, tempos)
+	}
+
+	markers := filter(tracks[0], 0xff, metaMarker)
+	if len(markers) != 2 || markers[0].tick != 0 || markers[1].tick != 6*48 {
+		t.Errorf("Incorrect markers - got:%v", markers)
+	}
+}
+
+func TestExportWithInvalidSettings(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0),
+	}
+
+	if err := NewExport().Write(&bytes.Buffer{}, taps2beats.Beats{}); err == nil {
+		t.Errorf("Expected error for no beats")
+	}
+
+	export := NewExport()
+	export.PPQ = 0
+	if err := export.Write(&bytes.Buffer{}, beats); err == nil {
+		t.Errorf("Expected error for invalid PPQ")
+	}
+
+	export = NewExport()
+	export.BeatUnit = 3
+	if err := export.Write(&bytes.Buffer{}, beats); err == nil {
+		t.Errorf("Expected error for invalid beat unit")
+	}
+}
+
+func TestVLQ(t *testing.T) {
+	tests := []struct {
+		value    int
+		expected []byte
+	}{
+		{0, []byte{0x00}},
+		{0x40, []byte{0x40}},
+		{0x7f, []byte{0x7f}},
+		{0x80, []byte{0x81, 0x00}},
+		{0x2000, []byte{0xc0, 0x00}},
+		{0x3fff, []byte{0xff, 0x7f}},
+		{0x100000, []byte{0xc0, 0x80, 0x00}},
+		{0x0fffffff, []byte{0xff, 0xff, 0xff, 0x7f}},
+	}
+
+	for _, test := range tests {
+		if v := vlq(test.value); !bytes.Equal(v, test.expected) {
+			t.Errorf("Incorrect VLQ for %x - expected:%x, got:%x", test.value, test.expected, v)
+		}
+	}
+}
+
+func beatsAt(seconds ...float64) []taps2beats.Beat {
+	beats := []taps2beats.Beat{}
+	for _, s := range seconds {
+		beats = append(beats, taps2beats.Beat{At: taps2beats.Seconds(s)})
+	}
+
+	return beats
+}
+
+// Minimal SMF decoder for checking the exported files (no running status, no sysex).
+func parse(b []byte, t *testing.T) (int, [][]decoded) {
+	if len(b) < 14 || string(b[0:4]) != "MThd" {
+		t.Fatalf("Invalid SMF header")
+	}
+
+	if format := binary.BigEndian.Uint16(b[8:10]); format != 1 {
+		t.Fatalf("Incorrect SMF format - expected:%v, got:%v", 1, format)
+	}
+
+	N := int(binary.BigEndian.Uint16(b[10:12]))
+	ppq := int(binary.BigEndian.Uint16(b[12:14]))
+	tracks := [][]decoded{}
+
+	b = b[14:]
+	for i := 0; i < N; i++ {
+		if len(b) < 8 || string(b[0:4]) != "MTrk" {
+			t.Fatalf("Invalid track header")
+		}
+
+		size := int(binary.BigEndian.Uint32(b[4:8]))
+		chunk := b[8 : 8+size]
+		b = b[8+size:]
+
+		events := []decoded{}
+		tick := 0
+		for len(chunk) > 0 {
+			delta := 0
+			for {
+				v := chunk[0]
+				chunk = chunk[1:]
+				delta = delta<<7 | int(v&0x7f)
+				if v&0x80 == 0 {
+					break
+				}
+			}
+
+			tick += delta
+
+			var length int
+			if chunk[0] == 0xff {
+				length = 3 + int(chunk[2])
+			} else {
+				length = 3
+			}
+
+			events = append(events, decoded{tick, chunk[:length]})
+			chunk = chunk[length:]
+		}
+
+		if last := events[len(events)-1]; last.data[0] != 0xff || last.data[1] != metaEndOfTrack {
+			t.Errorf("Missing end of track")
+		}
+
+		tracks = append(tracks, events)
+	}
+
+	return ppq, tracks
+}
+
+func filter(events []decoded, status byte, kind int) []decoded {
+	list := []decoded{}
+	for _, e := range events {
+		if e.data[0] == status && (kind < 0 || int(e.data[1]) == kind) {
+			if status&0xf0 == noteOn && e.data[2] == 0 {
+				continue
+			}
+
+			list = append(list, e)
+		}
+	}
+
+	return list
+}
+
+func seconds(tick, ppq int, tempos []decoded) float64 {
+	t := 0.0
+	last := 0
+	mpq := 500000
+
+	for _, e := range tempos {
+		if e.tick >= tick {
+			break
+		}
+
+		t += float64(e.tick-last) * float64(mpq) / float64(ppq) / 1000000.0
+		last = e.tick
+		mpq = int(e.data[3])<<16 | int(e.data[4])<<8 | int(e.data[5])
+	}
+
+	return t + float64(tick-last)*float64(mpq)/float64(ppq)/1000000.0
+}
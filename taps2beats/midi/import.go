@@ -0,0 +1,324 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// Settings for reading 'taps' from the note-on events in a Standard MIDI File e.g. taps recorded
+// from an e-drum pad or MIDI keyboard in a DAW.
+//
+// The note-on events are optionally split into loops at the marker events or at a fixed loop length,
+// with the 'taps' in each loop relative to the start of the loop i.e. the loops are treated as repetitions
+// of the same section of the source audio (as for taps2beats.TapSet.Split).
+type Import struct {
+	Channel    int           // MIDI channel (0-15) of the 'taps' or -1 for all channels
+	Notes      []int         // MIDI notes of the 'taps' (all notes if empty)
+	Markers    bool          // starts a new loop at every marker event
+	LoopLength time.Duration // starts a new loop every LoopLength (0 for no fixed loop length)
+}
+
+// A note-on event, converted to seconds from the start of the file.
+type noteEvent struct {
+	at      time.Duration
+	channel int
+	note    int
+}
+
+// A tempo change, in microseconds per quarter note.
+type tempoEvent struct {
+	tick int
+	mpq  int
+}
+
+// Returns the default import settings i.e. all note-on events on all channels as a single loop.
+func NewImport() Import {
+	return Import{
+		Channel: -1,
+	}
+}
+
+// Reads the note-on events from a Standard MIDI File (format 0 or 1) as 'taps' for Taps2Beats. The
+// event times are converted to seconds using the tempo map of the file. Loops without any 'taps' are
+// omitted.
+func (i Import) Read(r io.Reader) ([][]time.Duration, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, markers, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	// ... loop start times
+	starts := []time.Duration{0}
+	if i.Markers {
+		for _, m := range markers {
+			if m > starts[len(starts)-1] {
+				starts = append(starts, m)
+			}
+		}
+	}
+
+	if i.LoopLength > 0 && len(notes) > 0 {
+		last := notes[len(notes)-1].at
+		for t := i.LoopLength; t <= last; t += i.LoopLength {
+			starts = append(starts, t)
+		}
+	}
+
+	sort.Slice(starts, func(p, q int) bool { return starts[p] < starts[q] })
+
+	loops := make([][]time.Duration, len(starts))
+	for _, n := range notes {
+		if i.Channel >= 0 && n.channel != i.Channel {
+			continue
+		}
+
+		if len(i.Notes) > 0 && !contains(i.Notes, n.note) {
+			continue
+		}
+
+		k := sort.Search(len(starts), func(j int) bool { return starts[j] > n.at }) - 1
+		loops[k] = append(loops[k], n.at-starts[k])
+	}
+
+	taps := [][]time.Duration{}
+	for _, loop := range loops {
+		if len(loop) > 0 {
+			taps = append(taps, loop)
+		}
+	}
+
+	return taps, nil
+}
+
+// Utility function to read the 'taps' from a Standard MIDI File in a byte slice.
+func (i Import) Decode(b []byte) ([][]time.Duration, error) {
+	return i.Read(bytes.NewReader(b))
+}
+
+// Decodes the note-on and marker events from all the tracks in a Standard MIDI File, with the event
+// ticks converted to time using the tempo map (or the SMPTE time division).
+func decode(b []byte) ([]noteEvent, []time.Duration, error) {
+	if len(b) < 14 || string(b[0:4]) != "MThd" {
+		return nil, nil, fmt.Errorf("not a MIDI file")
+	}
+
+	size := int(binary.BigEndian.Uint32(b[4:8]))
+	if size < 6 || 8+size > len(b) {
+		return nil, nil, fmt.Errorf("invalid MIDI header")
+	}
+
+	format := binary.BigEndian.Uint16(b[8:10])
+	ntracks := int(binary.BigEndian.Uint16(b[10:12]))
+	division := binary.BigEndian.Uint16(b[12:14])
+
+	if format > 1 {
+		return nil, nil, fmt.Errorf("unsupported MIDI file format (%v)", format)
+	}
+
+	if division == 0 {
+		return nil, nil, fmt.Errorf("invalid MIDI time division")
+	}
+
+	type tick struct {
+		tick    int
+		channel int
+		note    int
+	}
+
+	ticks := []tick{}
+	markers := []int{}
+	tempos := []tempoEvent{}
+
+	chunks := b[8+size:]
+	n := 0
+	for n < ntracks && len(chunks) >= 8 {
+		id := string(chunks[0:4])
+		size := int(binary.BigEndian.Uint32(chunks[4:8]))
+		if 8+size > len(chunks) {
+			return nil, nil, fmt.Errorf("truncated MIDI track")
+		}
+
+		chunk := chunks[8 : 8+size]
+		chunks = chunks[8+size:]
+
+		if id != "MTrk" {
+			continue
+		}
+
+		n++
+
+		t := 0
+		status := byte(0)
+		for len(chunk) > 0 {
+			delta, k := readVLQ(chunk)
+			if k == 0 {
+				return nil, nil, fmt.Errorf("invalid MIDI event delta time")
+			}
+
+			t += delta
+			chunk = chunk[k:]
+			if len(chunk) == 0 {
+				return nil, nil, fmt.Errorf("truncated MIDI event")
+			}
+
+			// ... running status
+			if chunk[0]&0x80 != 0 {
+				status = chunk[0]
+				chunk = chunk[1:]
+			} else if status == 0 || status >= 0xf0 {
+				return nil, nil, fmt.Errorf("invalid MIDI running status")
+			}
+
+			switch {
+			case status == 0xff:
+				if len(chunk) < 1 {
+					return nil, nil, fmt.Errorf("truncated MIDI meta event")
+				}
+
+				kind := chunk[0]
+				length, k := readVLQ(chunk[1:])
+				if k == 0 || 1+k+length > len(chunk) {
+					return nil, nil, fmt.Errorf("truncated MIDI meta event")
+				}
+
+				data := chunk[1+k : 1+k+length]
+				chunk = chunk[1+k+length:]
+				status = 0
+
+				switch {
+				case kind == metaTempo && length == 3:
+					tempos = append(tempos, tempoEvent{t, int(data[0])<<16 | int(data[1])<<8 | int(data[2])})
+
+				case kind == metaMarker:
+					markers = append(markers, t)
+
+				case kind == metaEndOfTrack:
+					chunk = nil
+				}
+
+			case status == 0xf0 || status == 0xf7:
+				length, k := readVLQ(chunk)
+				if k == 0 || k+length > len(chunk) {
+					return nil, nil, fmt.Errorf("truncated MIDI sysex event")
+				}
+
+				chunk = chunk[k+length:]
+				status = 0
+
+			default:
+				length := 2
+				if kind := status & 0xf0; kind == 0xc0 || kind == 0xd0 {
+					length = 1
+				}
+
+				if len(chunk) < length {
+					return nil, nil, fmt.Errorf("truncated MIDI event")
+				}
+
+				if status&0xf0 == noteOn && chunk[1] > 0 {
+					ticks = append(ticks, tick{t, int(status & 0x0f), int(chunk[0])})
+				}
+
+				chunk = chunk[length:]
+			}
+		}
+	}
+
+	if n < ntracks {
+		return nil, nil, fmt.Errorf("missing MIDI tracks (expected %v, got %v)", ntracks, n)
+	}
+
+	sort.SliceStable(tempos, func(i, j int) bool { return tempos[i].tick < tempos[j].tick })
+	sort.SliceStable(ticks, func(i, j int) bool { return ticks[i].tick < ticks[j].tick })
+	sort.Ints(markers)
+
+	convert := converter(division, tempos)
+
+	notes := make([]noteEvent, len(ticks))
+	for i, t := range ticks {
+		notes[i] = noteEvent{
+			at:      convert(t.tick),
+			channel: t.channel,
+			note:    t.note,
+		}
+	}
+
+	times := make([]time.Duration, len(markers))
+	for i, m := range markers {
+		times[i] = convert(m)
+	}
+
+	return notes, times, nil
+}
+
+// Returns a function that converts ticks to time for the time division and tempo map. Ticks are
+// converted to time directly for an SMPTE time division (which ignores the tempo map).
+func converter(division uint16, tempos []tempoEvent) func(int) time.Duration {
+	if division&0x8000 != 0 {
+		fps := float64(-int8(division >> 8))
+		if fps == 29 {
+			fps = 29.97
+		}
+
+		resolution := float64(division & 0xff)
+
+		return func(tick int) time.Duration {
+			return time.Duration(float64(tick) / (fps * resolution) * float64(time.Second))
+		}
+	}
+
+	ppq := float64(division)
+
+	return func(tick int) time.Duration {
+		t := 0.0
+		last := 0
+		mpq := 500000 // ... default tempo is 120 BPM
+
+		for _, e := range tempos {
+			if e.tick >= tick {
+				break
+			}
+
+			t += float64(e.tick-last) * float64(mpq) / ppq
+			last = e.tick
+			mpq = e.mpq
+		}
+
+		t += float64(tick-last) * float64(mpq) / ppq
+
+		return time.Duration(t * float64(time.Microsecond))
+	}
+}
+
+// Decodes a MIDI variable length quantity, returning the value and the number of bytes (0 if invalid).
+func readVLQ(b []byte) (int, int) {
+	v := 0
+	for i := 0; i < len(b) && i < 4; i++ {
+		v = v<<7 | int(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+
+	return 0, 0
+}
+
+func contains(list []int, v int) bool {
+	for _, u := range list {
+		if u == v {
+			return true
+		}
+	}
+
+	return false
+}
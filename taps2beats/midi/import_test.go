@@ -0,0 +1,117 @@
+package midi
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Format 0 file at 96 PPQ with running status, a note-on with zero velocity, a marker at 0.75s and
+// a tempo change from 120 BPM to 60 BPM at 1s.
+var smf = []byte{
+	'M', 'T', 'h', 'd', 0, 0, 0, 6, 0, 0, 0, 1, 0, 96,
+	'M', 'T', 'r', 'k', 0, 0, 0, 56,
+	0x00, 0xff, 0x51, 0x03, 0x07, 0xa1, 0x20,
+	0x00, 0x90, 0x24, 0x64,
+	0x30, 0x24, 0x00,
+	0x30, 0x24, 0x64,
+	0x00, 0x91, 0x26, 0x64,
+	0x30, 0xff, 0x06, 0x04, 'L', 'o', 'o', 'p',
+	0x30, 0xff, 0x51, 0x03, 0x0f, 0x42, 0x40,
+	0x00, 0x90, 0x24, 0x64,
+	0x60, 0x24, 0x64,
+	0x00, 0xc0, 0x05,
+	0x00, 0xf0, 0x03, 0x7e, 0x7f, 0xf7,
+	0x00, 0xff, 0x2f, 0x00,
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Import
+		expected [][]time.Duration
+	}{
+		{"all", NewImport(), [][]time.Duration{ms(0, 500, 500, 1000, 2000)}},
+		{"channel", Import{Channel: 0}, [][]time.Duration{ms(0, 500, 1000, 2000)}},
+		{"notes", Import{Channel: -1, Notes: []int{38}}, [][]time.Duration{ms(500)}},
+		{"markers", Import{Channel: -1, Markers: true}, [][]time.Duration{ms(0, 500, 500), ms(250, 1250)}},
+		{"loop length", Import{Channel: 0, LoopLength: time.Second}, [][]time.Duration{ms(0, 500), ms(0), ms(0)}},
+	}
+
+	for _, test := range tests {
+		taps, err := test.settings.Decode(smf)
+		if err != nil {
+			t.Fatalf("%s: unexpected error (%v)", test.name, err)
+		}
+
+		if !reflect.DeepEqual(taps, test.expected) {
+			t.Errorf("%s: incorrect taps\n   expected:%v\n   got:     %v", test.name, test.expected, taps)
+		}
+	}
+}
+
+func TestImportExportedBeats(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(1.02, 1.51, 2.03, 2.50, 3.55, 4.04, 4.52, 5.07),
+	}
+
+	export := NewExport()
+	export.Clicks = true
+
+	var b bytes.Buffer
+	if err := export.Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	taps, err := NewImport().Decode(b.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if len(taps) != 1 || len(taps[0]) != len(beats.Beats) {
+		t.Fatalf("Incorrect taps - expected:%v, got:%v", len(beats.Beats), taps)
+	}
+
+	for i, tap := range taps[0] {
+		if dt := tap - beats.Beats[i].At; dt > 5*time.Microsecond || dt < -5*time.Microsecond {
+			t.Errorf("Incorrect tap %d - expected:%v, got:%v", i+1, beats.Beats[i].At, tap)
+		}
+	}
+
+	// ... bar markers split the beats into bars (with beat 5 missing from the second bar)
+	taps, err = Import{Channel: -1, Markers: true}.Decode(b.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if len(taps) != 3 || len(taps[0]) != 4 || len(taps[1]) != 3 || len(taps[2]) != 1 {
+		t.Errorf("Incorrect loops - got:%v", taps)
+	}
+}
+
+func TestImportWithInvalidFile(t *testing.T) {
+	invalid := [][]byte{
+		[]byte("RIFF...."),
+		smf[:20],
+		append(append([]byte{}, smf[:22]...), 0x00, 0x24, 0x64),
+		{'M', 'T', 'h', 'd', 0, 0, 0, 6, 0, 2, 0, 1, 0, 96},
+	}
+
+	for i, b := range invalid {
+		if _, err := NewImport().Decode(b); err == nil {
+			t.Errorf("Expected error for invalid file %d", i+1)
+		}
+	}
+}
+
+func ms(values ...int) []time.Duration {
+	list := []time.Duration{}
+	for _, v := range values {
+		list = append(list, time.Duration(v)*time.Millisecond)
+	}
+
+	return list
+}
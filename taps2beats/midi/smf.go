@@ -0,0 +1,117 @@
+// Standard MIDI File (SMF) functions for exporting the estimated beats as a tempo map for a DAW and
+// for using the notes recorded in a DAW (e.g. from an e-drum pad) as a source of 'taps'.
+//
+// The SMF implementation is a minimal pure Go writer for type 1 (multi-track) files, with the
+// time division expressed as ticks per quarter note, and a reader for format 0 and 1 files.
+package midi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// MIDI meta event types.
+const (
+	metaTrackName     = 0x03
+	metaMarker        = 0x06
+	metaEndOfTrack    = 0x2f
+	metaTempo         = 0x51
+	metaTimeSignature = 0x58
+)
+
+// MIDI channel message status bytes (without the channel).
+const (
+	noteOff = 0x80
+	noteOn  = 0x90
+)
+
+// A single MIDI event at an absolute tick.
+type event struct {
+	tick  int
+	order int // sort order for events at the same tick (meta events first, note-offs before note-ons)
+	data  []byte
+}
+
+type track []event
+
+func meta(tick int, kind byte, data []byte) event {
+	b := []byte{0xff, kind}
+	b = append(b, vlq(len(data))...)
+	b = append(b, data...)
+
+	return event{
+		tick:  tick,
+		order: 0,
+		data:  b,
+	}
+}
+
+func note(tick int, status byte, channel, key, velocity int) event {
+	order := 2
+	if status == noteOff {
+		order = 1
+	}
+
+	return event{
+		tick:  tick,
+		order: order,
+		data:  []byte{status | byte(channel&0x0f), byte(key & 0x7f), byte(velocity & 0x7f)},
+	}
+}
+
+// Writes a type 1 SMF with the supplied tracks. The events in each track are sorted by tick
+// and an end-of-track event is appended to each track.
+func write(w io.Writer, ppq int, tracks []track) error {
+	b := bufio.NewWriter(w)
+
+	b.WriteString("MThd")
+	binary.Write(b, binary.BigEndian, uint32(6))
+	binary.Write(b, binary.BigEndian, uint16(1))
+	binary.Write(b, binary.BigEndian, uint16(len(tracks)))
+	binary.Write(b, binary.BigEndian, uint16(ppq))
+
+	for _, t := range tracks {
+		events := make([]event, len(t))
+		copy(events, t)
+
+		sort.SliceStable(events, func(i, j int) bool {
+			if events[i].tick == events[j].tick {
+				return events[i].order < events[j].order
+			}
+
+			return events[i].tick < events[j].tick
+		})
+
+		chunk := []byte{}
+		tick := 0
+		for _, e := range events {
+			chunk = append(chunk, vlq(e.tick-tick)...)
+			chunk = append(chunk, e.data...)
+			tick = e.tick
+		}
+
+		chunk = append(chunk, 0x00, 0xff, metaEndOfTrack, 0x00)
+
+		b.WriteString("MTrk")
+		binary.Write(b, binary.BigEndian, uint32(len(chunk)))
+		b.Write(chunk)
+	}
+
+	return b.Flush()
+}
+
+// Encodes an integer as a MIDI variable length quantity.
+func vlq(v int) []byte {
+	if v < 0 {
+		v = 0
+	}
+
+	b := []byte{byte(v & 0x7f)}
+	for v >>= 7; v > 0; v >>= 7 {
+		b = append([]byte{byte(v&0x7f) | 0x80}, b...)
+	}
+
+	return b
+}
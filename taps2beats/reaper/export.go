@@ -0,0 +1,252 @@
+// Package reaper exports beats as Reaper project markers and tempo maps i.e. a region/marker CSV file
+// for the Region/Marker Manager and the tempo envelope of an RPP project file.
+package reaper
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Selects how the beats are converted to tempo envelope points.
+type TempoMap int
+
+const (
+	Auto     TempoMap = iota // constant tempo if the beats are evenly spaced (e.g. quantized), otherwise per beat
+	Constant                 // a single tempo point for the average beat interval
+	PerBeat                  // a tempo point for every beat interval i.e. a piecewise constant tempo
+)
+
+// Selects where the markers are placed.
+type Markers int
+
+const (
+	Bars  Markers = iota // a marker at every bar line
+	Beats                // a marker at every beat
+)
+
+// Settings for exporting a set of beats as Reaper markers and a Reaper tempo envelope.
+//
+// As for a MIDI export, the beats are placed on consecutive beats of the project grid and the tempo
+// before the first beat is set so that the first downbeat falls on a bar line. Bars are numbered from
+// the first downbeat (beats before the first downbeat are in bar 0).
+//
+// Marker and region names are formatted from the Name template, with {bar} replaced by the bar number,
+// {beat} by the beat in the bar and {n} by the marker (or region) number.
+type Export struct {
+	BeatsPerBar int      // time signature numerator
+	Downbeat    int      // index of the first downbeat in the list of beats
+	Markers     Markers  // marker placement
+	Name        string   // marker name template (defaults to 'Bar {bar}' for bars and '{bar}.{beat}' for beats)
+	Regions     bool     // adds a region for every bar
+	TempoMap    TempoMap // tempo envelope policy
+}
+
+// Beat grid for a set of beats, with the number of beats on the project grid before the first beat.
+type grid struct {
+	*taps2beats.Grid
+	lead int
+}
+
+// Returns the default export settings i.e. 4/4 time, a marker at every bar line and an automatic
+// tempo map.
+func NewExport() Export {
+	return Export{
+		BeatsPerBar: 4,
+		Markers:     Bars,
+		TempoMap:    Auto,
+	}
+}
+
+// Writes the bar (or beat) markers and (optionally) bar regions as a Reaper region/marker CSV file, for
+// importing with the Region/Marker Manager. Times are formatted as (h:)m:ss.fff.
+func (x Export) WriteMarkers(w io.Writer, beats taps2beats.Beats) error {
+	g, err := x.grid(beats)
+	if err != nil {
+		return err
+	}
+
+	name := x.Name
+	if name == "" && x.Markers == Beats {
+		name = "{bar}.{beat}"
+	} else if name == "" {
+		name = "Bar {bar}"
+	}
+
+	format := func(n, position int) string {
+		bar, beat := g.Bar(position)
+
+		return strings.NewReplacer(
+			"{bar}", fmt.Sprintf("%d", bar),
+			"{beat}", fmt.Sprintf("%d", beat),
+			"{n}", fmt.Sprintf("%d", n)).Replace(name)
+	}
+
+	fmt.Fprintln(w, "#,Name,Start,End,Length")
+
+	if x.Markers == Beats {
+		for i, p := range g.Positions {
+			fmt.Fprintf(w, "M%d,%s,%s,,\n", i+1, quote(format(i+1, p)), timestamp(g.Beats[i].At))
+		}
+	} else {
+		for i, p := range g.Bars() {
+			fmt.Fprintf(w, "M%d,%s,%s,,\n", i+1, quote(format(i+1, p)), timestamp(g.At(p)))
+		}
+	}
+
+	if x.Regions {
+		for i, p := range g.Bars() {
+			start := g.At(p)
+			end := g.At(p + g.BeatsPerBar)
+
+			fmt.Fprintf(w, "R%d,%s,%s,%s,%s\n", i+1, quote(format(i+1, p)), timestamp(start), timestamp(end), timestamp(end-start))
+		}
+	}
+
+	return nil
+}
+
+// Writes the tempo map as an RPP project file fragment i.e. the project TEMPO line and a TEMPOENVEX
+// tempo envelope with square (i.e. stepped) tempo points, for pasting into the REAPER_PROJECT block of
+// an RPP file. The time signature is set on the first point.
+func (x Export) WriteTempoEnvelope(w io.Writer, beats taps2beats.Beats) error {
+	g, err := x.grid(beats)
+	if err != nil {
+		return err
+	}
+
+	type point struct {
+		at  time.Duration
+		bpm float64
+	}
+
+	bpm := func(interval time.Duration, beats int) float64 {
+		return 60.0 * float64(beats) / interval.Seconds()
+	}
+
+	list := make([]time.Duration, len(g.Beats))
+	for i, b := range g.Beats {
+		list[i] = b.At
+	}
+
+	N := len(list)
+
+	average := bpm(g.Period, 1)
+	if N > 1 {
+		average = bpm(list[N-1]-list[0], g.Positions[N-1])
+	}
+
+	constant := x.TempoMap == Constant
+	if x.TempoMap == Auto {
+		constant = true
+		for i := 1; i < N; i++ {
+			steps := g.Positions[i] - g.Positions[i-1]
+			if d := list[i] - list[i-1] - time.Duration(steps)*g.Period; d > time.Millisecond || d < -time.Millisecond {
+				constant = false
+				break
+			}
+		}
+	}
+
+	points := []point{}
+	if g.lead > 0 {
+		points = append(points, point{0, bpm(list[0], g.lead)})
+	}
+
+	if constant {
+		points = append(points, point{list[0], average})
+	} else {
+		for i := 0; i < N-1; i++ {
+			points = append(points, point{list[i], bpm(list[i+1]-list[i], g.Positions[i+1]-g.Positions[i])})
+		}
+
+		points = append(points, point{list[N-1], average})
+	}
+
+	fmt.Fprintf(w, "TEMPO %s %d 4\n", number(points[0].bpm), g.BeatsPerBar)
+	fmt.Fprintln(w, "<TEMPOENVEX")
+	fmt.Fprintln(w, "  ACT 1 -1")
+	fmt.Fprintln(w, "  VIS 1 0 1")
+	fmt.Fprintln(w, "  LANEHEIGHT 0 0")
+	fmt.Fprintln(w, "  ARM 0")
+	fmt.Fprintln(w, "  DEFSHAPE 1 -1 -1")
+
+	for i, p := range points {
+		if i == 0 {
+			fmt.Fprintf(w, "  PT %.12f %s 1 %d 1\n", p.at.Seconds(), number(p.bpm), g.BeatsPerBar+4*65536)
+		} else if p.bpm != points[i-1].bpm {
+			fmt.Fprintf(w, "  PT %.12f %s 1\n", p.at.Seconds(), number(p.bpm))
+		}
+	}
+
+	fmt.Fprintln(w, ">")
+
+	return nil
+}
+
+// Places the beats on the project grid, allowing for missing beats, with a lead-in chosen so that the
+// first downbeat falls on a bar line.
+func (x Export) grid(beats taps2beats.Beats) (*grid, error) {
+	g, err := beats.Grid(x.BeatsPerBar, x.Downbeat)
+	if err != nil {
+		return nil, err
+	}
+
+	first := g.Beats[0].At
+	if first < 0 {
+		return nil, fmt.Errorf("first beat (%v) is before 0s", first)
+	}
+
+	if g.Period <= 0 {
+		return nil, fmt.Errorf("insufficient data")
+	}
+
+	lead := int(math.Round(float64(first) / float64(g.Period)))
+	if lead == 0 && first > 0 {
+		lead = 1
+	}
+
+	for (lead+g.Downbeat)%g.BeatsPerBar != 0 {
+		lead++
+	}
+
+	if lead > 0 && first == 0 {
+		return nil, fmt.Errorf("first beat is at 0s but is not a downbeat")
+	}
+
+	return &grid{g, lead}, nil
+}
+
+// Formats a time as (h:)m:ss.fff.
+func timestamp(t time.Duration) string {
+	ms := t.Round(time.Millisecond).Milliseconds()
+	h := ms / 3600000
+	m := (ms / 60000) % 60
+	s := (ms / 1000) % 60
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d.%03d", h, m, s, ms%1000)
+	}
+
+	return fmt.Sprintf("%d:%02d.%03d", m, s, ms%1000)
+}
+
+// Formats a BPM to 10 decimal places (as Reaper does), without the trailing zeroes.
+func number(v float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.10f", v), "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+// Quotes a marker name if it contains a comma or quote.
+func quote(s string) string {
+	if strings.ContainsAny(s, ",\"") {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+
+	return s
+}
@@ -0,0 +1,219 @@
+package reaper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestWriteMarkersOnBars(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:   120,
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0, 2.5, 3.0, 3.5, 4.0, 4.5),
+	}
+
+	export := NewExport()
+	export.Regions = true
+
+	var b bytes.Buffer
+	if err := export.WriteMarkers(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"#,Name,Start,End,Length",
+		"M1,Bar 1,0:00.500,,",
+		"M2,Bar 2,0:02.500,,",
+		"M3,Bar 3,0:04.500,,",
+		"R1,Bar 1,0:00.500,0:02.500,0:02.000",
+		"R2,Bar 2,0:02.500,0:04.500,0:02.000",
+		"R3,Bar 3,0:04.500,0:06.500,0:02.000",
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteMarkersOnBeats(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(1.02, 1.51, 2.03, 2.50, 3.55, 4.04),
+	}
+
+	export := NewExport()
+	export.Markers = Beats
+	export.Downbeat = 1
+
+	var b bytes.Buffer
+	if err := export.WriteMarkers(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	// ... beat 1 is a pickup and the beat at 3.05s is missing
+	expected := []string{
+		"#,Name,Start,End,Length",
+		"M1,0.4,0:01.020,,",
+		"M2,1.1,0:01.510,,",
+		"M3,1.2,0:02.030,,",
+		"M4,1.3,0:02.500,,",
+		"M5,2.1,0:03.550,,",
+		"M6,2.2,0:04.040,,",
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteMarkersWithName(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.0, 0.5, 1.0, 1.5, 2.0, 2.5, 3.0, 3.5),
+	}
+
+	export := NewExport()
+	export.Name = "Verse, bar {bar} ({n})"
+
+	var b bytes.Buffer
+	if err := export.WriteMarkers(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"#,Name,Start,End,Length",
+		`M1,"Verse, bar 1 (1)",0:00.000,,`,
+		`M2,"Verse, bar 2 (2)",0:02.000,,`,
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteTempoEnvelopeWithConstantTempo(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:   120,
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0, 2.5, 3.0, 3.5, 4.0, 4.5),
+	}
+
+	var b bytes.Buffer
+	if err := NewExport().WriteTempoEnvelope(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	// ... 4 beat lead-in in 0.5s so that the first beat is on the first bar line
+	expected := []string{
+		"TEMPO 480 4 4",
+		"<TEMPOENVEX",
+		"  ACT 1 -1",
+		"  VIS 1 0 1",
+		"  LANEHEIGHT 0 0",
+		"  ARM 0",
+		"  DEFSHAPE 1 -1 -1",
+		"  PT 0.000000000000 480 1 262148 1",
+		"  PT 0.500000000000 120 1",
+		">",
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteTempoEnvelopeWithPerBeatTempo(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.0, 0.5, 1.0, 1.6, 2.0, 2.5),
+	}
+
+	var b bytes.Buffer
+	if err := NewExport().WriteTempoEnvelope(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"TEMPO 120 4 4",
+		"<TEMPOENVEX",
+		"  ACT 1 -1",
+		"  VIS 1 0 1",
+		"  LANEHEIGHT 0 0",
+		"  ARM 0",
+		"  DEFSHAPE 1 -1 -1",
+		"  PT 0.000000000000 120 1 262148 1",
+		"  PT 1.000000000000 100 1",
+		"  PT 1.600000000000 150 1",
+		"  PT 2.000000000000 120 1",
+		">",
+	}
+
+	compare(b.String(), expected, t)
+
+	// ... constant tempo map
+	export := NewExport()
+	export.TempoMap = Constant
+
+	b.Reset()
+	if err := export.WriteTempoEnvelope(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if s := b.String(); strings.Count(s, "  PT ") != 1 || !strings.Contains(s, "TEMPO 120 4 4") {
+		t.Errorf("Incorrect constant tempo envelope\n%v", s)
+	}
+}
+
+func TestExportWithInvalidBeats(t *testing.T) {
+	export := NewExport()
+
+	if err := export.WriteMarkers(&bytes.Buffer{}, taps2beats.Beats{}); err == nil {
+		t.Errorf("Expected error for no beats")
+	}
+
+	if err := export.WriteTempoEnvelope(&bytes.Buffer{}, taps2beats.Beats{Beats: beatsAt(1.0)}); err == nil {
+		t.Errorf("Expected error for a single beat without a BPM")
+	}
+
+	export.Downbeat = 1
+	if err := export.WriteTempoEnvelope(&bytes.Buffer{}, taps2beats.Beats{Beats: beatsAt(0.0, 0.5, 1.0)}); err == nil {
+		t.Errorf("Expected error for a first beat at 0s that is not a downbeat")
+	}
+
+	export = NewExport()
+	export.BeatsPerBar = 0
+	if err := export.WriteMarkers(&bytes.Buffer{}, taps2beats.Beats{Beats: beatsAt(0.5, 1.0)}); err == nil {
+		t.Errorf("Expected error for invalid beats per bar")
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	tests := []struct {
+		t        time.Duration
+		expected string
+	}{
+		{0, "0:00.000"},
+		{1500 * time.Millisecond, "0:01.500"},
+		{62*time.Second + 345*time.Millisecond, "1:02.345"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03.000"},
+	}
+
+	for _, test := range tests {
+		if s := timestamp(test.t); s != test.expected {
+			t.Errorf("Incorrect timestamp for %v - expected:%v, got:%v", test.t, test.expected, s)
+		}
+	}
+}
+
+func beatsAt(seconds ...float64) []taps2beats.Beat {
+	beats := []taps2beats.Beat{}
+	for _, s := range seconds {
+		beats = append(beats, taps2beats.Beat{At: taps2beats.Seconds(s)})
+	}
+
+	return beats
+}
+
+func compare(s string, expected []string, t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Incorrect number of lines - expected:%v, got:%v\n%v", len(expected), len(lines), s)
+	}
+
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Incorrect line %d\n   expected:%v\n   got:     %v", i+1, expected[i], lines[i])
+		}
+	}
+}
@@ -10,7 +10,7 @@ func TestReindexWithNoBeats(t *testing.T) {
 	expected := []Beat{}
 	beats := []Beat{}
 
-	if err := reindex(beats); err != nil {
+	if err := reindex(beats, Tempo{}); err != nil {
 		t.Fatalf("Unexpected error (%v)", err)
 	}
 
@@ -28,7 +28,7 @@ func TestReindexWithOneBeat(t *testing.T) {
 		{At: Seconds(4.523694381), Mean: Seconds(4.523694381), Variance: Seconds(0.000391722), Taps: seconds(bins[0]...)},
 	}
 
-	if err := reindex(beats); err != nil {
+	if err := reindex(beats, Tempo{}); err != nil {
 		t.Fatalf("Unexpected error (%v)", err)
 	}
 
@@ -48,7 +48,7 @@ func TestReindexWithTwoBeats(t *testing.T) {
 		{At: Seconds(8.210333335), Mean: Seconds(8.210333335), Variance: Seconds(0.001217297), Taps: seconds(bins[7]...)},
 	}
 
-	if err := reindex(beats); err != nil {
+	if err := reindex(beats, Tempo{}); err != nil {
 		t.Fatalf("Unexpected error (%v)", err)
 	}
 
@@ -99,7 +99,7 @@ func TestReindexWithThreeBeats(t *testing.T) {
 			p = append(p, clone(beats[8+ix-1]))
 		}
 
-		err := reindex(p)
+		err := reindex(p, Tempo{})
 		if err != nil {
 			t.Fatalf("[%d] unexpected error (%v)", i+1, err)
 		}
@@ -155,7 +155,7 @@ func TestReindexWithFourBeats(t *testing.T) {
 			p = append(p, clone(beats[8+ix-1]))
 		}
 
-		err := reindex(p)
+		err := reindex(p, Tempo{})
 		if err != nil {
 			t.Fatalf("[%d] unexpected error (%v)", i+1, err)
 		}
@@ -183,7 +183,7 @@ func TestReindexWithCombinations(t *testing.T) {
 			p = append(p, clone(beats[8+ix-1]))
 		}
 
-		err := reindex(p)
+		err := reindex(p, Tempo{})
 		if err != nil {
 			t.Fatalf("[%v] unexpected error (%v)", v, err)
 		}
@@ -219,7 +219,7 @@ func TestReindexWithPathologicalData(t *testing.T) {
 		Beat{At: Seconds(11.0)},
 	}
 
-	if err := reindex(beats); err == nil {
+	if err := reindex(beats, Tempo{}); err == nil {
 		t.Fatalf("Expected error, got %v", err)
 	}
 }
@@ -0,0 +1,123 @@
+package rekordbox
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Rekordbox collection XML file i.e. the subset of the rekordbox XML schema used for the beat grid of
+// a track. The TRACK attributes and position marks (cues and loops) are retained as is, so that the
+// metadata of an existing collection entry is preserved.
+type collection struct {
+	XMLName    xml.Name `xml:"DJ_PLAYLISTS"`
+	Version    string   `xml:"Version,attr"`
+	Product    product
+	Collection struct {
+		Entries int     `xml:"Entries,attr"`
+		Tracks  []track `xml:"TRACK"`
+	} `xml:"COLLECTION"`
+	Playlists struct {
+		Node node `xml:"NODE"`
+	} `xml:"PLAYLISTS"`
+}
+
+type product struct {
+	XMLName xml.Name `xml:"PRODUCT"`
+	Name    string   `xml:"Name,attr"`
+	Version string   `xml:"Version,attr"`
+	Company string   `xml:"Company,attr"`
+}
+
+type track struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Tempo []tempo    `xml:"TEMPO"`
+	Marks []element  `xml:"POSITION_MARK"`
+}
+
+type tempo struct {
+	Inizio  string `xml:"Inizio,attr"`
+	Bpm     string `xml:"Bpm,attr"`
+	Metro   string `xml:"Metro,attr"`
+	Battito int    `xml:"Battito,attr"`
+}
+
+type element struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type node struct {
+	Type  string `xml:"Type,attr"`
+	Name  string `xml:"Name,attr"`
+	Count int    `xml:"Count,attr"`
+}
+
+// Returns the track attribute value (or "" if the track does not have the attribute).
+func (t track) get(name string) string {
+	for _, a := range t.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// Sets (or adds) a track attribute.
+func (t *track) set(name, value string) {
+	for i, a := range t.Attrs {
+		if a.Name.Local == name {
+			t.Attrs[i].Value = value
+			return
+		}
+	}
+
+	t.Attrs = append(t.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+// Reads a track entry from a rekordbox collection XML file, matching the track on the TrackID or Name
+// (or the only track in the collection if the track is not specified).
+func read(r io.Reader, id string) (track, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return track{}, err
+	}
+
+	doc := collection{}
+	if err := xml.Unmarshal(bytes, &doc); err != nil {
+		return track{}, err
+	}
+
+	tracks := doc.Collection.Tracks
+	if id == "" {
+		if len(tracks) != 1 {
+			return track{}, fmt.Errorf("collection has %v tracks (the track must be selected by TrackID or Name)", len(tracks))
+		}
+
+		return tracks[0], nil
+	}
+
+	for _, t := range tracks {
+		if t.get("TrackID") == id || strings.EqualFold(t.get("Name"), id) {
+			return t, nil
+		}
+	}
+
+	return track{}, fmt.Errorf("no track '%v' in collection", id)
+}
+
+// Returns the rekordbox Location URL for a file.
+func location(file string) (string, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+
+	u := url.URL{Scheme: "file", Host: "localhost", Path: filepath.ToSlash(path)}
+
+	return u.String(), nil
+}
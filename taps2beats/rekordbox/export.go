@@ -0,0 +1,173 @@
+// Package rekordbox exports beats as the beat grid of a track in a rekordbox collection XML file, for
+// importing into rekordbox (File > Import > Import Collection).
+package rekordbox
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Selects how the beats are converted to TEMPO entries.
+type TempoMap int
+
+const (
+	Auto     TempoMap = iota // constant tempo if the beats are evenly spaced (e.g. quantized), otherwise per beat
+	Constant                 // a single TEMPO entry for the average beat interval
+	PerBeat                  // a TEMPO entry wherever the BPM changes
+)
+
+// Settings for exporting a set of beats as a rekordbox collection XML file with a single TRACK.
+//
+// The beat grid is written as TEMPO entries with the start (Inizio) of each grid segment, the BPM for the
+// segment and the position (Battito) in the bar of the beat at the start of the segment. Evenly spaced beats
+// have a single TEMPO entry, otherwise there is a new entry wherever the BPM (rounded to the 0.01 BPM precision
+// of rekordbox) changes. The TRACK attributes (location and metadata) and position marks are taken from the
+// matching entry in an existing collection if specified, otherwise the location is taken from the source audio.
+type Export struct {
+	BeatsPerBar int      // time signature numerator
+	Downbeat    int      // index of the first downbeat in the list of beats
+	TempoMap    TempoMap // TEMPO entry policy
+	Collection  string   // existing collection XML file from which to take the track (optional)
+	Track       string   // TrackID or Name of the track in the collection (optional for a single track collection)
+	Audio       string   // source audio file for the track Name and Location (ignored for a collection)
+	Version     string   // taps2beats version for the PRODUCT element
+}
+
+// Returns the default export settings i.e. 4/4 time and an automatic tempo map.
+func NewExport() Export {
+	return Export{
+		BeatsPerBar: 4,
+		TempoMap:    Auto,
+	}
+}
+
+// Writes the beats as a rekordbox collection XML file.
+func (x Export) Write(w io.Writer, beats taps2beats.Beats) error {
+	tempos, average, err := x.grid(beats)
+	if err != nil {
+		return err
+	}
+
+	t := track{}
+	if x.Collection != "" {
+		f, err := os.Open(x.Collection)
+		if err != nil {
+			return err
+		}
+
+		t, err = read(f, x.Track)
+		f.Close()
+
+		if err != nil {
+			return err
+		}
+	} else {
+		t.set("TrackID", "1")
+
+		if x.Audio != "" {
+			loc, err := location(x.Audio)
+			if err != nil {
+				return err
+			}
+
+			t.set("Name", strings.TrimSuffix(filepath.Base(x.Audio), filepath.Ext(x.Audio)))
+			t.set("Location", loc)
+		}
+	}
+
+	t.set("AverageBpm", average)
+	t.Tempo = tempos
+
+	doc := collection{
+		Version: "1.0.0",
+		Product: product{
+			Name:    "taps2beats",
+			Version: x.Version,
+			Company: "transcriptaze",
+		},
+	}
+
+	doc.Collection.Entries = 1
+	doc.Collection.Tracks = []track{t}
+	doc.Playlists.Node = node{Type: "0", Name: "ROOT"}
+
+	bytes, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, xml.Header)
+	w.Write(bytes)
+	fmt.Fprintln(w)
+
+	return nil
+}
+
+// Converts the beats to TEMPO entries, returning the entries and the average BPM over all the beats.
+func (x Export) grid(beats taps2beats.Beats) ([]tempo, string, error) {
+	g, err := beats.Grid(x.BeatsPerBar, x.Downbeat)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if g.Period <= 0 {
+		return nil, "", fmt.Errorf("insufficient data")
+	}
+
+	list := make([]time.Duration, len(g.Beats))
+	for i, b := range g.Beats {
+		list[i] = b.At
+	}
+
+	N := len(list)
+	positions := g.Positions
+
+	battito := func(i int) int {
+		_, beat := g.Bar(positions[i])
+
+		return beat
+	}
+
+	bpm := func(interval time.Duration, beats int) string {
+		return fmt.Sprintf("%.2f", 60.0*float64(beats)/interval.Seconds())
+	}
+
+	metro := fmt.Sprintf("%d/4", x.BeatsPerBar)
+
+	average := fmt.Sprintf("%.2f", float64(beats.BPM))
+	if N > 1 {
+		average = bpm(list[N-1]-list[0], positions[N-1])
+	}
+
+	constant := x.TempoMap == Constant
+	if x.TempoMap == Auto {
+		constant = true
+		for i := 1; i < N; i++ {
+			if d := list[i] - list[i-1] - time.Duration(positions[i]-positions[i-1])*g.Period; d > time.Millisecond || d < -time.Millisecond {
+				constant = false
+				break
+			}
+		}
+	}
+
+	if constant || N == 1 {
+		return []tempo{{fmt.Sprintf("%.3f", list[0].Seconds()), average, metro, battito(0)}}, average, nil
+	}
+
+	tempos := []tempo{}
+	for i := 0; i < N-1; i++ {
+		b := bpm(list[i+1]-list[i], positions[i+1]-positions[i])
+		if len(tempos) == 0 || tempos[len(tempos)-1].Bpm != b {
+			tempos = append(tempos, tempo{fmt.Sprintf("%.3f", list[i].Seconds()), b, metro, battito(i)})
+		}
+	}
+
+	return tempos, average, nil
+}
@@ -0,0 +1,200 @@
+package rekordbox
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+const existing = `<?xml version="1.0" encoding="UTF-8"?>
+<DJ_PLAYLISTS Version="1.0.0">
+  <PRODUCT Name="rekordbox" Version="6.6.4" Company="AlphaTheta"/>
+  <COLLECTION Entries="2">
+    <TRACK TrackID="7" Name="Song" Artist="Someone" Location="file://localhost/music/song.mp3" AverageBpm="100.00">
+      <TEMPO Inizio="0.100" Bpm="100.00" Metro="4/4" Battito="1"/>
+      <POSITION_MARK Name="Intro" Type="0" Start="12.000" Num="-1"/>
+      <POSITION_MARK Name="Loop" Type="4" Start="16.000" End="20.000" Num="1"/>
+    </TRACK>
+    <TRACK TrackID="8" Name="Other"/>
+  </COLLECTION>
+</DJ_PLAYLISTS>`
+
+func TestWriteConstantGrid(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:   120,
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0, 2.5, 3.0),
+	}
+
+	export := NewExport()
+	export.Version = "v0.0.0"
+
+	var b bytes.Buffer
+	if err := export.Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<DJ_PLAYLISTS Version="1.0.0">`,
+		`  <PRODUCT Name="taps2beats" Version="v0.0.0" Company="transcriptaze"></PRODUCT>`,
+		`  <COLLECTION Entries="1">`,
+		`    <TRACK TrackID="1" AverageBpm="120.00">`,
+		`      <TEMPO Inizio="0.500" Bpm="120.00" Metro="4/4" Battito="1"></TEMPO>`,
+		`    </TRACK>`,
+		`  </COLLECTION>`,
+		`  <PLAYLISTS>`,
+		`    <NODE Type="0" Name="ROOT" Count="0"></NODE>`,
+		`  </PLAYLISTS>`,
+		`</DJ_PLAYLISTS>`,
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWritePerBeatGrid(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(1.0, 1.5, 2.0, 2.4, 2.8, 3.8, 4.2),
+	}
+
+	export := NewExport()
+	export.BeatsPerBar = 3
+	export.Downbeat = 1
+
+	tempos, average, err := export.grid(beats)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	// ... the beat at 3.3s is missing
+	expected := []tempo{
+		{"1.000", "120.00", "3/4", 3},
+		{"2.000", "150.00", "3/4", 2},
+		{"2.800", "120.00", "3/4", 1},
+		{"3.800", "150.00", "3/4", 3},
+	}
+
+	if average != "131.25" {
+		t.Errorf("Incorrect average BPM - expected:%v, got:%v", "131.25", average)
+	}
+
+	if len(tempos) != len(expected) {
+		t.Fatalf("Incorrect TEMPO entries - expected:%v, got:%v", expected, tempos)
+	}
+
+	for i := range expected {
+		if tempos[i] != expected[i] {
+			t.Errorf("Incorrect TEMPO entry %d - expected:%+v, got:%+v", i+1, expected[i], tempos[i])
+		}
+	}
+}
+
+func TestWriteToExistingCollection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rekordbox")
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "collection.xml")
+	if err := ioutil.WriteFile(file, []byte(existing), 0644); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	beats := taps2beats.Beats{
+		BPM:   120,
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0),
+	}
+
+	export := NewExport()
+	export.Collection = file
+	export.Track = "song"
+	export.Audio = "ignored.wav"
+	export.Version = "v0.0.0"
+
+	var b bytes.Buffer
+	if err := export.Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<DJ_PLAYLISTS Version="1.0.0">`,
+		`  <PRODUCT Name="taps2beats" Version="v0.0.0" Company="transcriptaze"></PRODUCT>`,
+		`  <COLLECTION Entries="1">`,
+		`    <TRACK TrackID="7" Name="Song" Artist="Someone" Location="file://localhost/music/song.mp3" AverageBpm="120.00">`,
+		`      <TEMPO Inizio="0.500" Bpm="120.00" Metro="4/4" Battito="1"></TEMPO>`,
+		`      <POSITION_MARK Name="Intro" Type="0" Start="12.000" Num="-1"></POSITION_MARK>`,
+		`      <POSITION_MARK Name="Loop" Type="4" Start="16.000" End="20.000" Num="1"></POSITION_MARK>`,
+		`    </TRACK>`,
+		`  </COLLECTION>`,
+		`  <PLAYLISTS>`,
+		`    <NODE Type="0" Name="ROOT" Count="0"></NODE>`,
+		`  </PLAYLISTS>`,
+		`</DJ_PLAYLISTS>`,
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestReadTrack(t *testing.T) {
+	tests := []struct {
+		track    string
+		expected string
+		err      bool
+	}{
+		{"7", "Song", false},
+		{"Other", "Other", false},
+		{"", "", true},
+		{"9", "", true},
+	}
+
+	for _, test := range tests {
+		tr, err := read(strings.NewReader(existing), test.track)
+		if test.err && err == nil {
+			t.Errorf("Expected error for track '%v'", test.track)
+		} else if !test.err && err != nil {
+			t.Errorf("Unexpected error for track '%v' (%v)", test.track, err)
+		} else if !test.err && tr.get("Name") != test.expected {
+			t.Errorf("Incorrect track for '%v' - expected:%v, got:%v", test.track, test.expected, tr.get("Name"))
+		}
+	}
+}
+
+func TestWriteWithInsufficientData(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5),
+	}
+
+	var b bytes.Buffer
+	if err := NewExport().Write(&b, beats); err == nil {
+		t.Errorf("Expected error for a single beat without a BPM")
+	}
+}
+
+func beatsAt(seconds ...float64) []taps2beats.Beat {
+	beats := []taps2beats.Beat{}
+	for _, s := range seconds {
+		beats = append(beats, taps2beats.Beat{At: taps2beats.Seconds(s)})
+	}
+
+	return beats
+}
+
+func compare(s string, expected []string, t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Incorrect number of lines - expected:%v, got:%v\n%v", len(expected), len(lines), s)
+	}
+
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Incorrect line %d\n   expected:%v\n   got:     %v", i+1, expected[i], lines[i])
+		}
+	}
+}
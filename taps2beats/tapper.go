@@ -0,0 +1,282 @@
+package taps2beats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Real-time 'tap tempo' engine that incrementally estimates the beats, BPM and confidence from 'taps'
+// supplied one at a time (e.g. from a UI event loop) rather than as a complete set of loops.
+//
+// Each 'tap' is assigned to the nearest tapped beat (or starts a new beat if it is not within half a beat
+// of an existing beat) and the running mean and variance of the beat are updated in place, so the 'taps'
+// are never re-clustered from scratch. A Tapper is safe for concurrent use from multiple goroutines.
+type Tapper struct {
+	mutex      sync.Mutex
+	forgetting float64
+	weight     float64
+	loops      int
+	beats      []*cluster
+	m          float64
+	confidence float64
+}
+
+// Running weighted mean and variance of the 'taps' assigned to a single beat.
+type cluster struct {
+	taps  []time.Duration
+	count int
+	sumw  float64
+	sumw2 float64
+	mean  float64
+	m2    float64
+}
+
+// Default tolerance (in seconds) for assigning a 'tap' to an existing beat before the BPM can
+// be estimated i.e. half the beat period at MaxBPM.
+var tolerance = 30.0 / float64(MaxBPM)
+
+// Creates a new Tapper with the forgetting factor used to discount the 'taps' from earlier loops (as
+// for Taps2Beats).
+func NewTapper(forgetting float64) *Tapper {
+	return &Tapper{
+		forgetting: forgetting,
+		weight:     1.0,
+		beats:      []*cluster{},
+	}
+}
+
+// Adds a 'tap' at the supplied time (relative to the start of the music) and updates the estimated
+// beats, BPM and confidence.
+func (t *Tapper) Tap(at time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.loops == 0 {
+		t.loops = 1
+	}
+
+	x := at.Seconds()
+	window := tolerance
+	if t.m > 0 {
+		window = t.m / 2.0
+	}
+
+	ix := -1
+	for i, b := range t.beats {
+		if d := math.Abs(b.mean - x); d < window && (ix < 0 || d < math.Abs(t.beats[ix].mean-x)) {
+			ix = i
+		}
+	}
+
+	if ix < 0 {
+		t.beats = append(t.beats, &cluster{})
+		ix = len(t.beats) - 1
+	}
+
+	t.beats[ix].add(at, t.weight)
+
+	sort.SliceStable(t.beats, func(i, j int) bool { return t.beats[i].mean < t.beats[j].mean })
+
+	t.merge()
+	t.estimate()
+}
+
+// Marks the start of a new loop. The weights of the 'taps' from subsequent loops are adjusted
+// according to the forgetting factor.
+func (t *Tapper) Restart() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.beats) == 0 {
+		t.loops = 1
+		return
+	}
+
+	t.loops++
+
+	switch {
+	case t.forgetting > 0.0:
+		f := 1.0 - t.forgetting
+		for _, b := range t.beats {
+			b.sumw *= f
+			b.sumw2 *= f * f
+			b.m2 *= f
+		}
+
+	case t.forgetting < 0.0:
+		t.weight *= 1.0 + t.forgetting
+	}
+}
+
+// Discards all 'taps' and estimates.
+func (t *Tapper) Reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.weight = 1.0
+	t.loops = 0
+	t.beats = []*cluster{}
+	t.m = 0
+	t.confidence = 0
+}
+
+// Returns the number of loops.
+func (t *Tapper) Loops() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.loops
+}
+
+// Returns the current BPM estimate, or 0 if there are insufficient 'taps' to estimate the BPM.
+func (t *Tapper) BPM() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.m <= 0 {
+		return 0
+	}
+
+	return 60.0 / t.m
+}
+
+// Returns a heuristic confidence (0.0 to 1.0) for the current estimate, based on how well the beats
+// fit a constant BPM and on how many 'taps' have been assigned to each beat.
+func (t *Tapper) Confidence() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.confidence
+}
+
+// Returns a snapshot of the currently estimated beats.
+func (t *Tapper) Beats() Beats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	beats := t.snapshot()
+	result := Beats{
+		Beats: beats,
+	}
+
+	if t.m > 0 {
+		result.BPM, result.Offset = bpm(beats, Tempo{})
+	}
+
+	if len(beats) > 0 {
+		variance := 0.0
+		for _, b := range beats {
+			variance += b.Variance.Seconds()
+		}
+
+		variance = variance / float64(len(beats))
+
+		result.Variance = &variance
+	}
+
+	return result
+}
+
+func (t *Tapper) snapshot() []Beat {
+	beats := make([]Beat, len(t.beats))
+	for i, b := range t.beats {
+		beats[i] = Beat{
+			At:       Seconds(b.mean),
+			Mean:     Seconds(b.mean),
+			Variance: Seconds(b.variance()),
+			Taps:     append([]time.Duration{}, b.taps...),
+		}
+	}
+
+	return beats
+}
+
+// Merges adjacent beats that have drifted to within the tolerance window of each other.
+func (t *Tapper) merge() {
+	if t.m <= 0 {
+		return
+	}
+
+	merged := []*cluster{}
+	for _, b := range t.beats {
+		if N := len(merged); N > 0 && b.mean-merged[N-1].mean < t.m/2.0 {
+			merged[N-1].combine(b)
+		} else {
+			merged = append(merged, b)
+		}
+	}
+
+	t.beats = merged
+}
+
+// Refits the BPM and offset to the current beats and updates the confidence.
+func (t *Tapper) estimate() {
+	t.m = 0
+	t.confidence = 0
+
+	if len(t.beats) < 2 {
+		return
+	}
+
+	beats := t.snapshot()
+	m, c, err := fit(beats, Tempo{})
+	if err != nil || m <= 0 {
+		return
+	}
+
+	t.m = m
+
+	sumsq := 0.0
+	taps := 0
+	for _, b := range beats {
+		dt := b.At.Seconds() - (float64(b.beat)*m + c)
+		sumsq += dt * dt
+		taps += len(b.Taps)
+	}
+
+	rms := math.Sqrt(sumsq / float64(len(beats)))
+	quality := math.Max(0.0, 1.0-4.0*rms/m)
+	support := 1.0 - 1.0/math.Sqrt(float64(taps)/float64(len(beats)))
+
+	t.confidence = quality * support
+}
+
+func (b *cluster) add(at time.Duration, w float64) {
+	x := at.Seconds()
+
+	b.taps = append(b.taps, at)
+	b.count++
+	b.sumw += w
+	b.sumw2 += w * w
+
+	delta := x - b.mean
+	b.mean += delta * w / b.sumw
+	b.m2 += w * delta * (x - b.mean)
+}
+
+func (b *cluster) combine(q *cluster) {
+	sumw := b.sumw + q.sumw
+	delta := q.mean - b.mean
+
+	b.taps = append(b.taps, q.taps...)
+	b.count += q.count
+	b.m2 += q.m2 + delta*delta*b.sumw*q.sumw/sumw
+	b.mean += delta * q.sumw / sumw
+	b.sumw = sumw
+	b.sumw2 += q.sumw2
+}
+
+// Returns the unbiased weighted variance of the 'taps' i.e. with the weights treated as reliability weights,
+// so that the variance is unchanged when the forgetting factor scales all the weights of a beat and reduces
+// to the sample variance when the weights are all equal.
+func (b *cluster) variance() float64 {
+	if b.count > 1 && b.sumw > 0 {
+		if d := b.sumw - b.sumw2/b.sumw; d > 0 {
+			return b.m2 / d
+		}
+	}
+
+	return 0
+}
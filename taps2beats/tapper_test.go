@@ -0,0 +1,174 @@
+package taps2beats
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTapper(t *testing.T) {
+	expected := []Beat{beats[8], beats[9], beats[10], beats[11], beats[12], beats[13], beats[14], beats[15]}
+
+	tapper := NewTapper(0.0)
+	for _, row := range Floats2Seconds(taps) {
+		tapper.Restart()
+		for _, tap := range row {
+			tapper.Tap(tap)
+		}
+	}
+
+	if bpm := tapper.BPM(); math.Abs(bpm-114.0) > 0.5 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%.2f", 114, bpm)
+	}
+
+	if confidence := tapper.Confidence(); confidence < 0.5 || confidence > 1.0 {
+		t.Errorf("Invalid confidence - expected:0.5..1.0, got:%.3f", confidence)
+	}
+
+	if loops := tapper.Loops(); loops != len(taps) {
+		t.Errorf("Incorrect number of loops - expected:%v, got:%v", len(taps), loops)
+	}
+
+	beats := tapper.Beats()
+	if beats.BPM != 114 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%v", 114, beats.BPM)
+	}
+
+	if len(beats.Beats) != len(expected) {
+		t.Fatalf("Invalid result\n   expected: %v beats\n   got:      %v beats", len(expected), len(beats.Beats))
+	}
+
+	for i, v := range expected {
+		if math.Abs(beats.Beats[i].At.Seconds()-v.At.Seconds()) >= 0.0011 {
+			t.Errorf("Invalid beat %d 'at' - expected:%v, got:%v", i+1, v.At, beats.Beats[i].At)
+		}
+
+		if math.Abs(beats.Beats[i].Variance.Seconds()-v.Variance.Seconds()) >= 0.0011 {
+			t.Errorf("Invalid beat %d 'variance' - expected:%v, got:%v", i+1, v.Variance, beats.Beats[i].Variance)
+		}
+
+		if len(beats.Beats[i].Taps) != len(v.Taps) {
+			t.Errorf("Invalid beat %d 'taps' - expected:%v, got:%v", i+1, len(v.Taps), len(beats.Beats[i].Taps))
+		}
+	}
+}
+
+func TestTapperWithForgetting(t *testing.T) {
+	reference := Taps2Beats(NewTapSetFromFloats(taps), 0.1)
+
+	tapper := NewTapper(0.1)
+	for _, row := range Floats2Seconds(taps) {
+		tapper.Restart()
+		for _, tap := range row {
+			tapper.Tap(tap)
+		}
+	}
+
+	beats := tapper.Beats()
+	if len(beats.Beats) != len(reference.Beats) {
+		t.Fatalf("Invalid result\n   expected: %v beats\n   got:      %v beats", len(reference.Beats), len(beats.Beats))
+	}
+
+	for i, v := range reference.Beats {
+		if math.Abs(beats.Beats[i].At.Seconds()-v.At.Seconds()) >= 0.0011 {
+			t.Errorf("Invalid beat %d 'at' - expected:%v, got:%v", i+1, v.At, beats.Beats[i].At)
+		}
+	}
+}
+
+func TestTapperVariance(t *testing.T) {
+	tests := []struct {
+		forgetting float64
+		loops      [][]float64
+		expected   float64
+	}{
+		{0.0, [][]float64{{1.0}, {1.02}, {1.04}}, 0.0004},
+		{0.5, [][]float64{{1.0}, {1.02}}, 0.0002},
+		{0.5, [][]float64{{1.0}, {1.02}, {1.04}}, 0.000371429},
+	}
+
+	for _, test := range tests {
+		tapper := NewTapper(test.forgetting)
+		for _, row := range test.loops {
+			tapper.Restart()
+			for _, tap := range row {
+				tapper.Tap(Seconds(tap))
+			}
+		}
+
+		beats := tapper.Beats()
+		if len(beats.Beats) != 1 {
+			t.Fatalf("Invalid result - expected:1 beat, got:%v", beats)
+		}
+
+		if v := beats.Beats[0].Variance.Seconds(); math.Abs(v-test.expected) > 0.000001 {
+			t.Errorf("Incorrect variance for forgetting factor %v - expected:%v, got:%v", test.forgetting, test.expected, v)
+		}
+	}
+}
+
+func TestTapperWithInsufficientData(t *testing.T) {
+	tapper := NewTapper(0.0)
+
+	tapper.Tap(4524 * time.Millisecond)
+	tapper.Tap(4530 * time.Millisecond)
+
+	if bpm := tapper.BPM(); bpm != 0 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%v", 0, bpm)
+	}
+
+	if confidence := tapper.Confidence(); confidence != 0 {
+		t.Errorf("Incorrect confidence - expected:%v, got:%v", 0, confidence)
+	}
+
+	if beats := tapper.Beats(); len(beats.Beats) != 1 || len(beats.Beats[0].Taps) != 2 {
+		t.Errorf("Invalid result - expected:1 beat with 2 taps, got:%v", beats)
+	}
+
+	tapper.Reset()
+
+	if beats := tapper.Beats(); len(beats.Beats) != 0 {
+		t.Errorf("Invalid result after reset - expected:0 beats, got:%v", len(beats.Beats))
+	}
+}
+
+func TestTapperConcurrency(t *testing.T) {
+	tapper := NewTapper(0.0)
+	done := make(chan bool)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, row := range Floats2Seconds(taps) {
+			tapper.Restart()
+			for _, tap := range row {
+				tapper.Tap(tap)
+			}
+		}
+		close(done)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				tapper.Beats()
+				tapper.BPM()
+				tapper.Confidence()
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if bpm := tapper.BPM(); math.Abs(bpm-114.0) > 0.5 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%.2f", 114, bpm)
+	}
+}
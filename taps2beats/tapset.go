@@ -0,0 +1,283 @@
+package taps2beats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A set of 'taps' for Taps2Beats i.e. a list of loops, each with the 'taps' for a single pass through
+// the music and the metadata used to weight the 'taps' and compensate for the latency of the tapper.
+// All times are durations, with the time base of the source retained so that the 'taps' can be
+// converted back to the source units.
+type TapSet struct {
+	Loops    []Loop
+	TimeBase TimeBase
+}
+
+// A single loop of 'taps'. The 'taps' are relative to the start of the loop and are adjusted for the
+// loop latency before being clustered i.e. a 'tap' at t is clustered as Start + t - Latency. 'Taps'
+// after the end of the loop (if specified) are discarded.
+//
+// The weight of each 'tap' is the product of the loop weight, the 'tap' weight and the forgetting
+// factor weighting of the loop. A zero loop (or 'tap') weight is unspecified and is treated as 1.0, so
+// that a Loop (or Tap) literal without weights is weighted equally - a loop or 'tap' that should not
+// contribute to the beats should be omitted rather than weighted as 0.
+type Loop struct {
+	ID      string        // identifies the loop e.g. the take or row number
+	Tapper  string        // identifies the person (or device) that tapped the loop
+	Start   time.Duration // start of the loop in the source audio i.e. the 'taps' are relative to Start
+	End     time.Duration // end of the loop in the source audio (0 if not specified)
+	Latency time.Duration // delay between the beat and the 'tap'
+	Weight  float64       // relative weight of the loop (0 for 1.0)
+	Labels  []string      // free-form labels e.g. 'verse' or 'practice'
+	Taps    []Tap
+}
+
+// A single 'tap', the relative weight (e.g. a confidence or MIDI velocity) of the 'tap' and an
+// optional label e.g. the name of the Audacity label or MIDI note.
+type Tap struct {
+	At     time.Duration
+	Weight float64 // relative weight of the 'tap' (0 for 1.0)
+	Label  string
+}
+
+// Creates a TapSet from a list of loops of 'taps', with all the loops and 'taps' weighted equally.
+func NewTapSet(taps [][]time.Duration) TapSet {
+	set := TapSet{
+		Loops: make([]Loop, len(taps)),
+	}
+
+	for i, row := range taps {
+		loop := Loop{
+			ID:     strconv.Itoa(i + 1),
+			Weight: 1.0,
+			Taps:   make([]Tap, len(row)),
+		}
+
+		for j, t := range row {
+			loop.Taps[j] = Tap{At: t, Weight: 1.0}
+		}
+
+		set.Loops[i] = loop
+	}
+
+	return set
+}
+
+// Creates a TapSet from a list of loops of 'taps' in seconds.
+func NewTapSetFromFloats(taps [][]float64) TapSet {
+	return NewTapSet(Floats2Seconds(taps))
+}
+
+// Creates a TapSet from a list of loops of 'taps' as audio sample offsets at the sample rate.
+func NewTapSetFromSamples(samples [][]int64, rate int) (TapSet, error) {
+	timebase := TimeBase{Units: UnitSamples, SampleRate: rate}
+	if rate <= 0 {
+		return TapSet{}, fmt.Errorf("invalid sample rate (%v)", rate)
+	}
+
+	return newTapSet(samples, timebase), nil
+}
+
+// Creates a TapSet from a list of loops of 'taps' as MIDI ticks, with ppq ticks per quarter note and
+// a fixed tempo (the duration of a quarter note e.g. 500ms for the MIDI default of 120 BPM).
+func NewTapSetFromTicks(ticks [][]int64, ppq int, tempo time.Duration) (TapSet, error) {
+	timebase := TimeBase{Units: UnitTicks, PPQ: ppq, Tempo: tempo}
+	if ppq <= 0 {
+		return TapSet{}, fmt.Errorf("invalid PPQ (%v)", ppq)
+	} else if tempo <= 0 {
+		return TapSet{}, fmt.Errorf("invalid tempo (%v)", tempo)
+	}
+
+	return newTapSet(ticks, timebase), nil
+}
+
+func newTapSet(values [][]int64, timebase TimeBase) TapSet {
+	taps := make([][]time.Duration, len(values))
+	for i, row := range values {
+		taps[i] = make([]time.Duration, len(row))
+		for j, v := range row {
+			taps[i][j] = timebase.Duration(v)
+		}
+	}
+
+	set := NewTapSet(taps)
+	set.TimeBase = timebase
+
+	return set
+}
+
+// Returns a TapSet with only the loops for which keep returns true.
+func (set TapSet) Filter(keep func(loop Loop) bool) TapSet {
+	filtered := TapSet{
+		Loops:    []Loop{},
+		TimeBase: set.TimeBase,
+	}
+
+	for _, loop := range set.Loops {
+		if keep(loop) {
+			filtered.Loops = append(filtered.Loops, loop)
+		}
+	}
+
+	return filtered
+}
+
+// Returns a TapSet with the loops of this set followed by the loops of the other sets e.g. to combine
+// the 'taps' from multiple files or tappers. The merged set retains the time base of this set.
+func (set TapSet) Merge(sets ...TapSet) TapSet {
+	merged := TapSet{
+		Loops:    append([]Loop{}, set.Loops...),
+		TimeBase: set.TimeBase,
+	}
+
+	for _, s := range sets {
+		merged.Loops = append(merged.Loops, s.Loops...)
+	}
+
+	return merged
+}
+
+// Returns a TapSet with all the loops shifted by dt i.e. with the loop start (and end, if specified)
+// offset by dt.
+func (set TapSet) Shift(dt time.Duration) TapSet {
+	shifted := TapSet{
+		Loops:    make([]Loop, len(set.Loops)),
+		TimeBase: set.TimeBase,
+	}
+
+	for i, loop := range set.Loops {
+		loop.Start += dt
+		if loop.End > 0 {
+			loop.End += dt
+		}
+
+		shifted.Loops[i] = loop
+	}
+
+	return shifted
+}
+
+// Returns a TapSet with the loop weights discounted by a 'forgetting factor' i.e. for a positive factor, each
+// loop is weighted as (1 - forgetting) times less accurate than the following loop and for a negative factor,
+// each loop is weighted as (1 + forgetting) times less accurate than the preceding loop. Unlike the forgetting
+// factor for Taps2Beats, which applies to all the loops, this allows e.g. each file in a multi-file session to
+// be discounted separately.
+func (set TapSet) Forget(forgetting float64) TapSet {
+	discounted := TapSet{
+		Loops:    make([]Loop, len(set.Loops)),
+		TimeBase: set.TimeBase,
+	}
+
+	N := len(set.Loops)
+	for i, loop := range set.Loops {
+		switch {
+		case forgetting > 0.0:
+			loop.Weight = weight(loop.Weight) * math.Pow(1.0-forgetting, float64(N-1-i))
+
+		case forgetting < 0.0:
+			loop.Weight = weight(loop.Weight) * math.Pow(1.0+forgetting, float64(i))
+		}
+
+		discounted.Loops[i] = loop
+	}
+
+	return discounted
+}
+
+// Returns a TapSet with each loop split into consecutive loops of the specified length (e.g. to split a
+// single long recording of a repeated section into loops). The split loops all have the same Start as the
+// original loop (i.e. they are repetitions of the same section of the source audio, as for the loops from
+// midi.Import.LoopLength), the 'taps' in each split loop are relative to the start of the split loop and
+// the split loops are identified as <id>.1, <id>.2, etc. The 'taps' after the end of the original loop and
+// empty split loops are discarded.
+func (set TapSet) Split(length time.Duration) (TapSet, error) {
+	if length <= 0 {
+		return TapSet{}, fmt.Errorf("invalid loop length (%v)", length)
+	}
+
+	split := TapSet{
+		Loops:    []Loop{},
+		TimeBase: set.TimeBase,
+	}
+
+	for _, loop := range set.Loops {
+		loops := map[int64]*Loop{}
+		keys := []int64{}
+
+		for _, tap := range loop.Taps {
+			if loop.End > 0 && loop.Start+tap.At > loop.End {
+				continue
+			}
+
+			k := int64(tap.At / length)
+			if tap.At < 0 {
+				k = (int64(tap.At) - int64(length) + 1) / int64(length)
+			}
+
+			l, ok := loops[k]
+			if !ok {
+				l = &Loop{
+					Tapper:  loop.Tapper,
+					Start:   loop.Start,
+					End:     loop.Start + length,
+					Latency: loop.Latency,
+					Weight:  loop.Weight,
+					Labels:  loop.Labels,
+					Taps:    []Tap{},
+				}
+
+				loops[k] = l
+				keys = append(keys, k)
+			}
+
+			tap.At -= time.Duration(k) * length
+			l.Taps = append(l.Taps, tap)
+		}
+
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+		for i, k := range keys {
+			l := loops[k]
+			l.ID = fmt.Sprintf("%v.%v", loop.ID, i+1)
+			split.Loops = append(split.Loops, *l)
+		}
+	}
+
+	return split, nil
+}
+
+// Returns the adjusted 'taps' (i.e. offset by the loop start and latency, without the 'taps' after the
+// loop end) and the combined loop and 'tap' weights.
+func (set TapSet) taps() ([][]time.Duration, [][]float64) {
+	taps := make([][]time.Duration, len(set.Loops))
+	weighting := make([][]float64, len(set.Loops))
+
+	for i, loop := range set.Loops {
+		taps[i] = []time.Duration{}
+		weighting[i] = []float64{}
+
+		for _, tap := range loop.Taps {
+			if loop.End > 0 && loop.Start+tap.At > loop.End {
+				continue
+			}
+
+			taps[i] = append(taps[i], loop.Start+tap.At-loop.Latency)
+			weighting[i] = append(weighting[i], weight(loop.Weight)*weight(tap.Weight))
+		}
+	}
+
+	return taps, weighting
+}
+
+// Returns a loop or 'tap' weight, with a zero (i.e. unspecified) weight treated as 1.0.
+func weight(w float64) float64 {
+	if w == 0 {
+		return 1.0
+	}
+
+	return w
+}
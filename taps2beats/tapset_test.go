@@ -0,0 +1,252 @@
+package taps2beats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewTapSet(t *testing.T) {
+	set := NewTapSet([][]time.Duration{seconds(0.5, 1.0), seconds(0.52)})
+
+	expected := TapSet{
+		Loops: []Loop{
+			{ID: "1", Weight: 1.0, Taps: []Tap{{At: Seconds(0.5), Weight: 1.0}, {At: Seconds(1.0), Weight: 1.0}}},
+			{ID: "2", Weight: 1.0, Taps: []Tap{{At: Seconds(0.52), Weight: 1.0}}},
+		},
+	}
+
+	if !reflect.DeepEqual(set, expected) {
+		t.Errorf("Incorrect tap set\n   expected:%+v\n   got:     %+v", expected, set)
+	}
+}
+
+func TestNewTapSetFromSamples(t *testing.T) {
+	set, err := NewTapSetFromSamples([][]int64{{22050, 44100}, {-11025}}, 44100)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := [][]time.Duration{
+		{500 * time.Millisecond, 1 * time.Second},
+		{-250 * time.Millisecond},
+	}
+
+	compareTaps(set, expected, t)
+
+	if set.TimeBase != (TimeBase{Units: UnitSamples, SampleRate: 44100}) {
+		t.Errorf("Incorrect time base - got:%+v", set.TimeBase)
+	}
+
+	if v := set.TimeBase.Value(750 * time.Millisecond); v != 33075 {
+		t.Errorf("Incorrect sample - expected:%v, got:%v", 33075, v)
+	}
+
+	if _, err := NewTapSetFromSamples([][]int64{{1}}, 0); err == nil {
+		t.Errorf("Expected error for invalid sample rate")
+	}
+}
+
+func TestNewTapSetFromTicks(t *testing.T) {
+	set, err := NewTapSetFromTicks([][]int64{{0, 480, 720}}, 480, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := [][]time.Duration{
+		{0, 500 * time.Millisecond, 750 * time.Millisecond},
+	}
+
+	compareTaps(set, expected, t)
+
+	if v := set.TimeBase.Value(1250 * time.Millisecond); v != 1200 {
+		t.Errorf("Incorrect tick - expected:%v, got:%v", 1200, v)
+	}
+
+	if _, err := NewTapSetFromTicks([][]int64{{1}}, 480, 0); err == nil {
+		t.Errorf("Expected error for invalid tempo")
+	}
+}
+
+func TestTapSetFilter(t *testing.T) {
+	set := NewTapSetFromFloats([][]float64{{0.5, 1.0}, {0.52}, {0.49, 1.01}})
+	set.Loops[1].Tapper = "bob"
+
+	filtered := set.Filter(func(loop Loop) bool { return loop.Tapper != "bob" })
+
+	if len(filtered.Loops) != 2 || filtered.Loops[0].ID != "1" || filtered.Loops[1].ID != "3" {
+		t.Errorf("Incorrect filtered loops - got:%+v", filtered.Loops)
+	}
+
+	if len(set.Loops) != 3 {
+		t.Errorf("Filter modified original set - got:%+v", set.Loops)
+	}
+}
+
+func TestTapSetMerge(t *testing.T) {
+	a := NewTapSetFromFloats([][]float64{{0.5, 1.0}})
+	b := NewTapSetFromFloats([][]float64{{0.52}, {0.49}})
+	b.Loops[0].ID = "b1"
+	b.Loops[1].ID = "b2"
+
+	merged := a.Merge(b)
+
+	if len(merged.Loops) != 3 || merged.Loops[0].ID != "1" || merged.Loops[1].ID != "b1" || merged.Loops[2].ID != "b2" {
+		t.Errorf("Incorrect merged loops - got:%+v", merged.Loops)
+	}
+
+	if len(a.Loops) != 1 {
+		t.Errorf("Merge modified original set - got:%+v", a.Loops)
+	}
+}
+
+func TestTapSetShift(t *testing.T) {
+	set := NewTapSetFromFloats([][]float64{{0.5, 1.0}})
+	set.Loops[0].End = 2 * time.Second
+
+	shifted := set.Shift(10 * time.Second)
+
+	if shifted.Loops[0].Start != 10*time.Second || shifted.Loops[0].End != 12*time.Second {
+		t.Errorf("Incorrect shifted loop - got:%+v", shifted.Loops[0])
+	}
+
+	compareTaps(shifted, [][]time.Duration{{10500 * time.Millisecond, 11 * time.Second}}, t)
+
+	if set.Loops[0].Start != 0 {
+		t.Errorf("Shift modified original set - got:%+v", set.Loops[0])
+	}
+}
+
+func TestTapSetForget(t *testing.T) {
+	set := NewTapSetFromFloats([][]float64{{0.5}, {0.5}, {0.5}})
+
+	tests := []struct {
+		forgetting float64
+		expected   []float64
+	}{
+		{0.0, []float64{1.0, 1.0, 1.0}},
+		{0.5, []float64{0.25, 0.5, 1.0}},
+		{-0.5, []float64{1.0, 0.5, 0.25}},
+	}
+
+	for _, test := range tests {
+		discounted := set.Forget(test.forgetting)
+		for i, loop := range discounted.Loops {
+			if loop.Weight != test.expected[i] {
+				t.Errorf("Incorrect weight for loop %d with forgetting %v - expected:%v, got:%v", i+1, test.forgetting, test.expected[i], loop.Weight)
+			}
+		}
+	}
+
+	if set.Loops[0].Weight != 1.0 {
+		t.Errorf("Forget modified original set - got:%+v", set.Loops[0])
+	}
+}
+
+func TestTapSetSplit(t *testing.T) {
+	set := NewTapSetFromFloats([][]float64{{0.5, 1.0, 2.5, 3.0, 8.5, 9.5}})
+	set.Loops[0].Start = 10 * time.Second
+	set.Loops[0].End = 19 * time.Second
+
+	split, err := set.Split(2 * time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []Loop{
+		{ID: "1.1", Start: 10 * time.Second, End: 12 * time.Second, Weight: 1.0, Taps: []Tap{{At: Seconds(0.5), Weight: 1.0}, {At: Seconds(1.0), Weight: 1.0}}},
+		{ID: "1.2", Start: 10 * time.Second, End: 12 * time.Second, Weight: 1.0, Taps: []Tap{{At: Seconds(0.5), Weight: 1.0}, {At: Seconds(1.0), Weight: 1.0}}},
+		{ID: "1.3", Start: 10 * time.Second, End: 12 * time.Second, Weight: 1.0, Taps: []Tap{{At: Seconds(0.5), Weight: 1.0}}},
+	}
+
+	if !reflect.DeepEqual(split.Loops, expected) {
+		t.Errorf("Incorrect split loops\n   expected:%+v\n   got:     %+v", expected, split.Loops)
+	}
+
+	// ... the split loops are repetitions of the same section of the source audio
+	taps, _ := split.taps()
+	for i, row := range taps {
+		if row[0] != Seconds(10.5) {
+			t.Errorf("Incorrect split loop %d 'taps' - expected:%v, got:%v", i+1, Seconds(10.5), row)
+		}
+	}
+
+	if _, err := set.Split(0); err == nil {
+		t.Errorf("Expected error for invalid loop length")
+	}
+}
+
+func TestTapSetAdjustments(t *testing.T) {
+	set := TapSet{
+		Loops: []Loop{
+			{
+				Weight: 1.0,
+				Taps:   []Tap{{At: Seconds(0.5), Weight: 1.0}, {At: Seconds(1.0), Weight: 1.0}, {At: Seconds(1.5), Weight: 1.0}},
+			},
+			{
+				Start:   10 * time.Second,
+				End:     11200 * time.Millisecond,
+				Latency: 70 * time.Millisecond,
+				Weight:  0.5,
+				Taps:    []Tap{{At: Seconds(0.57), Weight: 2.0}, {At: Seconds(1.07), Weight: 1.0}, {At: Seconds(1.57), Weight: 1.0}},
+			},
+		},
+	}
+
+	taps, weighting := set.taps()
+
+	expected := [][]time.Duration{
+		seconds(0.5, 1.0, 1.5),
+		seconds(10.5, 11.0),
+	}
+
+	if len(taps) != len(expected) {
+		t.Fatalf("Incorrect taps - expected:%v, got:%v", expected, taps)
+	}
+
+	for i := range expected {
+		if len(taps[i]) != len(expected[i]) {
+			t.Fatalf("Incorrect loop %d taps - expected:%v, got:%v", i+1, expected[i], taps[i])
+		}
+
+		for j := range expected[i] {
+			if dt := taps[i][j] - expected[i][j]; dt > time.Microsecond || dt < -time.Microsecond {
+				t.Errorf("Incorrect loop %d tap %d - expected:%v, got:%v", i+1, j+1, expected[i][j], taps[i][j])
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(weighting, [][]float64{{1.0, 1.0, 1.0}, {1.0, 0.5}}) {
+		t.Errorf("Incorrect weights - got:%v", weighting)
+	}
+}
+
+func TestTaps2BeatsWithLatency(t *testing.T) {
+	set := NewTapSetFromFloats(taps)
+	reference := Taps2Beats(set, 0.0)
+
+	for i := range set.Loops {
+		set.Loops[i].Latency = 100 * time.Millisecond
+	}
+
+	beats := Taps2Beats(set, 0.0)
+
+	if len(beats.Beats) != len(reference.Beats) {
+		t.Fatalf("Incorrect number of beats - expected:%v, got:%v", len(reference.Beats), len(beats.Beats))
+	}
+
+	for i, b := range beats.Beats {
+		expected := reference.Beats[i].At - 100*time.Millisecond
+		if dt := b.At - expected; dt > time.Microsecond || dt < -time.Microsecond {
+			t.Errorf("Incorrect beat %d - expected:%v, got:%v", i+1, expected, b.At)
+		}
+	}
+}
+
+func compareTaps(set TapSet, expected [][]time.Duration, t *testing.T) {
+	taps, _ := set.taps()
+
+	if !reflect.DeepEqual(taps, expected) {
+		t.Errorf("Incorrect taps\n   expected:%v\n   got:     %v", expected, taps)
+	}
+}
@@ -0,0 +1,136 @@
+package tapsio
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Reads 'taps' from an Audacity label file (tab separated start, end and label) and writes beats as an
+// Audacity label track, with a point label at every beat.
+//
+// The start time of each label is used as a 'tap'. Audacity exports all the label tracks in a project to a
+// single file, one after the other, so each label track (detected by a start time earlier than the previous
+// label) is a separate loop. If a loop label is specified, a label matching the loop label starts a new loop
+// and the 'taps' following the loop label are relative to the loop label.
+//
+// The beat labels are either the beat numbers or (for Labels 'bars') bar:beat, with the bars numbered from
+// the position of each beat on the beat grid.
+type Audacity struct {
+	LoopLabel   string // label that starts a new loop (ignored if blank)
+	Labels      string // 'beats' or 'bars'
+	BeatsPerBar int    // beats per bar for 'bars' labels
+	Downbeat    int    // index of the first downbeat for 'bars' labels
+}
+
+// Returns true if every line is an Audacity label (or an Audacity spectral selection line) and at least one
+// label is a point label or has a non-numeric label i.e. is not just a line of three tab separated 'taps'.
+func IsAudacity(bytes []byte) bool {
+	re := regexp.MustCompile(`^([0-9.eE+-]+)\t([0-9.eE+-]+)(\t.*)?$`)
+	labels := 0
+	distinct := false
+
+	for _, line := range strings.Split(string(bytes), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "\\") {
+			continue
+		}
+
+		match := re.FindStringSubmatch(line)
+		if match == nil {
+			return false
+		}
+
+		labels++
+
+		label := strings.TrimPrefix(match[3], "\t")
+		if _, err := strconv.ParseFloat(strings.TrimSpace(label), 64); err != nil || match[1] == match[2] {
+			distinct = true
+		}
+	}
+
+	return labels > 0 && distinct
+}
+
+func (a Audacity) Read(r io.Reader) (taps2beats.TapSet, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	return a.parse(bytes)
+}
+
+func (a Audacity) parse(bytes []byte) (taps2beats.TapSet, error) {
+	data := [][]float64{}
+	row := []float64{}
+	origin := 0.0
+	last := math.Inf(-1)
+
+	for i, line := range strings.Split(string(bytes), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "\\") {
+			continue
+		}
+
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 2 {
+			return taps2beats.TapSet{}, fmt.Errorf("invalid label at line %d (%s)", i+1, line)
+		}
+
+		start, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if err != nil {
+			return taps2beats.TapSet{}, fmt.Errorf("invalid label start time at line %d (%v)", i+1, fields[0])
+		}
+
+		label := ""
+		if len(fields) > 2 {
+			label = strings.TrimSpace(fields[2])
+		}
+
+		if start < last || (a.LoopLabel != "" && strings.EqualFold(label, a.LoopLabel)) {
+			if len(row) > 0 {
+				data = append(data, row)
+			}
+
+			row = []float64{}
+			origin = 0.0
+		}
+
+		last = start
+
+		if a.LoopLabel != "" && strings.EqualFold(label, a.LoopLabel) {
+			origin = start
+			continue
+		}
+
+		row = append(row, start-origin)
+	}
+
+	if len(row) > 0 {
+		data = append(data, row)
+	}
+
+	return taps2beats.NewTapSetFromFloats(data), nil
+}
+
+func (a Audacity) Write(w io.Writer, beats taps2beats.Beats) error {
+	var g *taps2beats.Grid
+	if a.Labels == "bars" {
+		g, _ = beats.Grid(a.BeatsPerBar, a.Downbeat)
+	}
+
+	for i, b := range beats.Beats {
+		t := b.At.Seconds()
+
+		fmt.Fprintf(w, "%.6f\t%.6f\t%s\n", t, t, label(g, i))
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package tapsio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestIsAudacity(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{"4.5\t4.5\tA\n5.0\t5.0\tB\n", true},
+		{"4.5\t4.5\n", true},
+		{"4.5\t4.6\t4.7\n", false},
+		{"4.5 5.0 5.5\n", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if v := IsAudacity([]byte(test.content)); v != test.expected {
+			t.Errorf("Incorrect IsAudacity for %q - expected:%v, got:%v", test.content, test.expected, v)
+		}
+	}
+}
+
+func TestReadAudacityWithLoopLabel(t *testing.T) {
+	labels := strings.Join([]string{
+		"10.0\t10.0\tloop",
+		"10.5\t10.5\t1",
+		"11.0\t11.0\t2",
+		"20.0\t20.0\tLOOP",
+		"20.51\t20.51\t1",
+	}, "\n")
+
+	set, err := Audacity{LoopLabel: "loop"}.Read(strings.NewReader(labels))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if len(set.Loops) != 2 || len(set.Loops[0].Taps) != 2 || len(set.Loops[1].Taps) != 1 {
+		t.Fatalf("Incorrect loops - got:%+v", set.Loops)
+	}
+
+	if dt := set.Loops[1].Taps[0].At.Seconds() - 0.51; dt > 0.000001 || dt < -0.000001 {
+		t.Errorf("Incorrect relative tap - expected:%v, got:%v", 0.51, set.Loops[1].Taps[0].At)
+	}
+}
+
+func TestWriteAudacity(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0, 1.5),
+	}
+
+	var b bytes.Buffer
+	if err := (Audacity{Labels: "bars", BeatsPerBar: 2}).Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"0.500000\t0.500000\t1:1",
+		"1.000000\t1.000000\t1:2",
+		"1.500000\t1.500000\t2:1",
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteAudacityWithDownbeat(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.5, 3.0),
+	}
+
+	var b bytes.Buffer
+	if err := (Audacity{Labels: "bars", BeatsPerBar: 3, Downbeat: 1}).Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"0.500000\t0.500000\t0:3",
+		"1.000000\t1.000000\t1:1",
+		"1.500000\t1.500000\t1:2",
+		"2.500000\t2.500000\t2:1",
+		"3.000000\t3.000000\t2:2",
+	}
+
+	compare(b.String(), expected, t)
+}
@@ -0,0 +1,323 @@
+package tapsio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Reads 'taps' from and writes beats (or 'taps') as a CSV (or TSV) table.
+//
+// The columns for the loop, time and (optional) weight of each 'tap' are either mapped explicitly (by header
+// name or 1-based column number) or identified from the header names. Without a header or a mapping, the
+// columns are assumed to be time (1 column), loop and time (2 columns) or loop, time and weight (3 or more
+// columns). A mapping for any column other than 'loop', 'time' and 'weight' is an error. The 'taps' are
+// grouped into loops by the loop id, in the order in which the loops first appear, and the weights are saved
+// with the 'taps' for clustering ('taps' with a zero weight are discarded).
+//
+// The beats are written with a row for each beat (beat, bar, at, mean, variance, taps and source), with the
+// times and variance in seconds. The source is 'tapped' for a beat estimated from the 'taps' or 'interpolated'
+// for a beat without any 'taps'. For Taps, the 'taps' are written with a row for each 'tap' (tap, loop, beat
+// and residual) ordered by loop and time.
+type CSV struct {
+	Delimiter   rune              // field delimiter (defaults to ',')
+	Columns     map[string]string // maps 'loop', 'time' and 'weight' to a header name or column number (optional)
+	Taps        bool              // writes the 'taps' rather than the beats
+	BeatsPerBar int               // beats per bar for the bar numbers (0 for no bar numbers)
+	Downbeat    int               // index of the first downbeat for the bar numbers
+}
+
+func (c CSV) Read(r io.Reader) (taps2beats.TapSet, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = c.delimiter()
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	if len(records) == 0 {
+		return taps2beats.TapSet{}, nil
+	}
+
+	// ... header?
+	var header []string
+	numeric := false
+	for _, field := range records[0] {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err == nil {
+			numeric = true
+		}
+	}
+
+	if !numeric {
+		header = records[0]
+		records = records[1:]
+	}
+
+	loop, tap, weight, err := c.columns(header, records)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	set := taps2beats.TapSet{}
+	loops := map[string]int{}
+
+	for i, record := range records {
+		line := i + 1
+		if header != nil {
+			line++
+		}
+
+		if tap >= len(record) {
+			return taps2beats.TapSet{}, fmt.Errorf("missing time at line %d", line)
+		}
+
+		t, err := strconv.ParseFloat(strings.TrimSpace(record[tap]), 64)
+		if err != nil {
+			return taps2beats.TapSet{}, fmt.Errorf("invalid time at line %d (%v)", line, record[tap])
+		}
+
+		w := 1.0
+		if weight >= 0 && weight < len(record) && strings.TrimSpace(record[weight]) != "" {
+			if w, err = strconv.ParseFloat(strings.TrimSpace(record[weight]), 64); err != nil || w < 0 {
+				return taps2beats.TapSet{}, fmt.Errorf("invalid weight at line %d (%v)", line, record[weight])
+			} else if w == 0 {
+				continue
+			}
+		}
+
+		id := ""
+		if loop >= 0 && loop < len(record) {
+			id = strings.TrimSpace(record[loop])
+		}
+
+		ix, ok := loops[id]
+		if !ok {
+			ix = len(set.Loops)
+			loops[id] = ix
+			if id == "" {
+				set.Loops = append(set.Loops, taps2beats.Loop{ID: strconv.Itoa(ix + 1), Weight: 1.0})
+			} else {
+				set.Loops = append(set.Loops, taps2beats.Loop{ID: id, Weight: 1.0})
+			}
+		}
+
+		set.Loops[ix].Taps = append(set.Loops[ix].Taps, taps2beats.Tap{At: taps2beats.Seconds(t), Weight: w})
+	}
+
+	return set, nil
+}
+
+// Resolves the loop, time and weight column indices (-1 if not mapped) from the column mapping, the header
+// or the number of columns.
+func (c CSV) columns(header []string, records [][]string) (int, int, int, error) {
+	find := func(names ...string) int {
+		for i, h := range header {
+			for _, name := range names {
+				if strings.EqualFold(strings.TrimSpace(h), name) {
+					return i
+				}
+			}
+		}
+
+		return -1
+	}
+
+	resolve := func(spec string) (int, error) {
+		if n, err := strconv.Atoi(spec); err == nil {
+			if n < 1 {
+				return -1, fmt.Errorf("invalid column number (%v)", n)
+			}
+
+			return n - 1, nil
+		}
+
+		if ix := find(spec); ix >= 0 {
+			return ix, nil
+		}
+
+		return -1, fmt.Errorf("no column '%v'", spec)
+	}
+
+	loop, tap, weight := -1, -1, -1
+
+	switch {
+	case len(c.Columns) > 0:
+		for key, spec := range c.Columns {
+			ix, err := resolve(spec)
+			if err != nil {
+				return 0, 0, 0, err
+			}
+
+			switch key {
+			case "loop":
+				loop = ix
+			case "time":
+				tap = ix
+			case "weight":
+				weight = ix
+			default:
+				return 0, 0, 0, fmt.Errorf("invalid column (%v)", key)
+			}
+		}
+
+		if tap < 0 {
+			return 0, 0, 0, fmt.Errorf("missing 'time' column mapping")
+		}
+
+	case header != nil:
+		loop = find("loop", "row", "id", "take", "tapper")
+		tap = find("time", "tap", "taps", "at", "onset", "seconds")
+		weight = find("weight", "confidence", "velocity")
+
+		if tap < 0 {
+			return 0, 0, 0, fmt.Errorf("no 'time' column in header (%v)", strings.Join(header, ","))
+		}
+
+	default:
+		N := 0
+		for _, record := range records {
+			if len(record) > N {
+				N = len(record)
+			}
+		}
+
+		switch {
+		case N == 1:
+			tap = 0
+		case N == 2:
+			loop, tap = 0, 1
+		default:
+			loop, tap, weight = 0, 1, 2
+		}
+	}
+
+	return loop, tap, weight, nil
+}
+
+func (c CSV) Write(w io.Writer, beats taps2beats.Beats) error {
+	if c.Taps {
+		return c.writeTaps(w, beats)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = c.delimiter()
+
+	if err := cw.Write([]string{"beat", "bar", "at", "mean", "variance", "taps", "source"}); err != nil {
+		return err
+	}
+
+	g, _ := beats.Grid(c.BeatsPerBar, c.Downbeat)
+	for i, b := range beats.Beats {
+		bar := ""
+		if g != nil {
+			n, _ := g.Bar(g.Positions[i])
+			bar = fmt.Sprintf("%d", n)
+		}
+
+		source := "interpolated"
+		if len(b.Taps) > 0 {
+			source = "tapped"
+		}
+
+		record := []string{
+			fmt.Sprintf("%d", i+1),
+			bar,
+			seconds(b.At),
+			seconds(b.Mean),
+			seconds(b.Variance),
+			fmt.Sprintf("%d", len(b.Taps)),
+			source,
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// Writes the 'taps' as a long-form table, with a row for each 'tap' ordered by loop and time. Each row has
+// the loop (blank if unknown), the beat to which the 'tap' was assigned and the residual i.e. the difference
+// between the 'tap' and the beat, in seconds.
+func (c CSV) writeTaps(w io.Writer, beats taps2beats.Beats) error {
+	type row struct {
+		tap  time.Duration
+		loop int
+		beat int
+	}
+
+	rows := []row{}
+	for i, b := range beats.Beats {
+		for j, t := range b.Taps {
+			loop := -1
+			if j < len(b.Loops) {
+				loop = b.Loops[j]
+			}
+
+			rows = append(rows, row{t, loop, i})
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].loop != rows[j].loop {
+			return rows[i].loop < rows[j].loop
+		}
+
+		return rows[i].tap < rows[j].tap
+	})
+
+	cw := csv.NewWriter(w)
+	cw.Comma = c.delimiter()
+
+	if err := cw.Write([]string{"tap", "loop", "beat", "residual"}); err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		loop := ""
+		if r.loop >= 0 {
+			loop = fmt.Sprintf("%d", r.loop+1)
+		}
+
+		record := []string{
+			seconds(r.tap),
+			loop,
+			fmt.Sprintf("%d", r.beat+1),
+			seconds(r.tap - beats.Beats[r.beat].At),
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func (c CSV) delimiter() rune {
+	if c.Delimiter == 0 {
+		return ','
+	}
+
+	return c.Delimiter
+}
+
+// Returns a time as (shortest representation) seconds.
+func seconds(t time.Duration) string {
+	return strconv.FormatFloat(t.Seconds(), 'f', -1, 64)
+}
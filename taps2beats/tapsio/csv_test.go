@@ -0,0 +1,125 @@
+package tapsio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      CSV
+		content  string
+		expected [][]float64
+		weights  [][]float64
+	}{
+		{"time only", CSV{}, "0.5\n1.0\n1.5\n", [][]float64{{0.5, 1.0, 1.5}}, nil},
+		{"loop and time", CSV{}, "1,0.5\n2,0.52\n1,1.0\n", [][]float64{{0.5, 1.0}, {0.52}}, nil},
+		{"loop, time and weight", CSV{}, "1,0.5,0.5\n1,1.0\n1,1.5,\n", [][]float64{{0.5, 1.0, 1.5}}, [][]float64{{0.5, 1, 1}}},
+		{"header", CSV{}, "take,onset,confidence\nB,0.52,1\nA,0.5,0.25\n", [][]float64{{0.52}, {0.5}}, [][]float64{{1}, {0.25}}},
+		{"zero weight", CSV{}, "1,0.5,0\n1,1.0,0.5\n", [][]float64{{1.0}}, [][]float64{{0.5}}},
+		{"header with comments", CSV{}, "# tapped\nTime\n0.5\n# second\n1.0\n", [][]float64{{0.5, 1.0}}, nil},
+		{"TSV", CSV{Delimiter: '\t'}, "loop\ttime\n1\t0.5\n1\t1.0\n", [][]float64{{0.5, 1.0}}, nil},
+		{"columns by name", CSV{Columns: map[string]string{"loop": "who", "time": "when"}}, "when,who\n0.5,x\n0.51,y\n", [][]float64{{0.5}, {0.51}}, nil},
+		{"columns by number", CSV{Columns: map[string]string{"time": "3", "weight": "1"}}, "0.5,x,4.5\n0.25,y,5.0\n", [][]float64{{4.5, 5.0}}, [][]float64{{0.5, 0.25}}},
+	}
+
+	for _, test := range tests {
+		set, err := test.csv.Read(strings.NewReader(test.content))
+		if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		}
+
+		compareTaps(set, test.expected, t)
+
+		for i, weights := range test.weights {
+			for j, w := range weights {
+				if tap := set.Loops[i].Taps[j]; tap.Weight != w {
+					t.Errorf("%v: incorrect loop %d tap %d weight - expected:%v, got:%v", test.name, i+1, j+1, w, tap.Weight)
+				}
+			}
+		}
+	}
+}
+
+func TestReadCSVWithInvalidData(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     CSV
+		content string
+	}{
+		{"invalid time", CSV{}, "1,0.5\n1,abc\n"},
+		{"missing time", CSV{Columns: map[string]string{"time": "2"}}, "0.5,1.0\n0.6\n"},
+		{"invalid weight", CSV{}, "1,0.5,heavy\n"},
+		{"negative weight", CSV{}, "1,0.5,-1\n"},
+		{"no time column", CSV{}, "loop,weight\n1,0.5\n"},
+		{"unknown column", CSV{Columns: map[string]string{"time": "1", "tempo": "2"}}, "0.5,120\n"},
+		{"unknown column name", CSV{Columns: map[string]string{"time": "onset"}}, "time\n0.5\n"},
+		{"invalid column number", CSV{Columns: map[string]string{"time": "0"}}, "0.5\n"},
+		{"missing time mapping", CSV{Columns: map[string]string{"loop": "1"}}, "1,0.5\n"},
+	}
+
+	for _, test := range tests {
+		if _, err := test.csv.Read(strings.NewReader(test.content)); err == nil {
+			t.Errorf("%v: expected error reading %q", test.name, test.content)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0, 1.5),
+	}
+
+	beats.Beats[0].Taps = []time.Duration{taps2beats.Seconds(0.49), taps2beats.Seconds(0.51)}
+	beats.Beats[0].Loops = []int{1, 0}
+	beats.Beats[0].Variance = taps2beats.Seconds(0.0002)
+	beats.Beats[2].Taps = []time.Duration{taps2beats.Seconds(1.52)}
+	beats.Beats[2].Loops = []int{0}
+
+	tests := []struct {
+		csv      CSV
+		expected []string
+	}{
+		{
+			CSV{},
+			[]string{
+				"beat,bar,at,mean,variance,taps,source",
+				"1,,0.5,0,0.0002,2,tapped",
+				"2,,1,0,0,0,interpolated",
+				"3,,1.5,0,0,1,tapped",
+			},
+		},
+		{
+			CSV{Delimiter: '\t', BeatsPerBar: 2, Downbeat: 1},
+			[]string{
+				"beat\tbar\tat\tmean\tvariance\ttaps\tsource",
+				"1\t0\t0.5\t0\t0.0002\t2\ttapped",
+				"2\t1\t1\t0\t0\t0\tinterpolated",
+				"3\t1\t1.5\t0\t0\t1\ttapped",
+			},
+		},
+		{
+			CSV{Taps: true},
+			[]string{
+				"tap,loop,beat,residual",
+				"0.51,1,1,0.01",
+				"1.52,1,3,0.02",
+				"0.49,2,1,-0.01",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := test.csv.Write(&b, beats); err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		compare(b.String(), test.expected, t)
+	}
+}
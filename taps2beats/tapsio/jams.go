@@ -0,0 +1,226 @@
+package tapsio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Reads 'taps' from and writes beats as a JAMS (JSON Annotated Music Specification) file.
+//
+// Each 'beat' (or 'beat_position') annotation (e.g. from a different annotator) is read as a separate loop.
+// The beats are written as a 'beat' annotation, with the position of each beat in the bar as the value
+// and a confidence estimated from the spread of the 'taps' for the beat, and a 'tempo' annotation.
+type JAMS struct {
+	Version     string // taps2beats version for the annotation metadata
+	BeatsPerBar int    // beats per bar for the beat positions (0 for no beat positions)
+	Downbeat    int    // index of the first downbeat for the beat positions
+}
+
+// JAMS file i.e. the subset of the JAMS schema used for beat and tempo annotations.
+type jams struct {
+	FileMetadata jamsFileMetadata `json:"file_metadata"`
+	Annotations  []jamsAnnotation `json:"annotations"`
+	Sandbox      struct{}         `json:"sandbox"`
+}
+
+type jamsFileMetadata struct {
+	Title       string            `json:"title"`
+	Artist      string            `json:"artist"`
+	Release     string            `json:"release"`
+	Duration    float64           `json:"duration"`
+	Identifiers map[string]string `json:"identifiers"`
+	JamsVersion string            `json:"jams_version"`
+}
+
+type jamsAnnotation struct {
+	Namespace          string                 `json:"namespace"`
+	Data               []jamsObservation      `json:"data"`
+	AnnotationMetadata jamsAnnotationMetadata `json:"annotation_metadata"`
+	Sandbox            struct{}               `json:"sandbox"`
+	Time               float64                `json:"time"`
+	Duration           *float64               `json:"duration"`
+}
+
+type jamsObservation struct {
+	Time       float64     `json:"time"`
+	Duration   float64     `json:"duration"`
+	Value      interface{} `json:"value"`
+	Confidence *float64    `json:"confidence"`
+}
+
+type jamsAnnotationMetadata struct {
+	Curator struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"curator"`
+	Annotator       struct{} `json:"annotator"`
+	Version         string   `json:"version"`
+	Corpus          string   `json:"corpus"`
+	AnnotationTools string   `json:"annotation_tools"`
+	AnnotationRules string   `json:"annotation_rules"`
+	Validation      string   `json:"validation"`
+	DataSource      string   `json:"data_source"`
+}
+
+const jamsVersion = "0.3.4"
+
+// Timing tolerance for the beat confidence (the standard deviation of the 'taps' at which the confidence
+// falls to exp(-1/2) i.e. about 0.6).
+const tolerance = 0.035
+
+// Reads the 'beat' annotations as 'taps', with each annotation as a separate loop.
+func (j JAMS) Read(r io.Reader) (taps2beats.TapSet, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	doc := jams{}
+	if err := json.Unmarshal(bytes, &doc); err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	data := [][]float64{}
+	for _, a := range doc.Annotations {
+		if a.Namespace != "beat" && a.Namespace != "beat_position" {
+			continue
+		}
+
+		row := []float64{}
+		for _, o := range a.Data {
+			row = append(row, o.Time)
+		}
+
+		if len(row) > 0 {
+			data = append(data, row)
+		}
+	}
+
+	if len(data) == 0 {
+		return taps2beats.TapSet{}, fmt.Errorf("no 'beat' annotations")
+	}
+
+	return taps2beats.NewTapSetFromFloats(data), nil
+}
+
+func (j JAMS) Write(w io.Writer, beats taps2beats.Beats) error {
+	seconds := func(t time.Duration) float64 {
+		return math.Round(t.Seconds()*1000000) / 1000000
+	}
+
+	duration := 0.0
+	if N := len(beats.Beats); N > 0 {
+		duration = seconds(beats.Beats[N-1].At)
+	}
+
+	metadata := jamsAnnotationMetadata{
+		Version:         j.Version,
+		AnnotationTools: "taps2beats",
+		DataSource:      "taps",
+	}
+
+	beat := jamsAnnotation{
+		Namespace:          "beat",
+		Data:               []jamsObservation{},
+		AnnotationMetadata: metadata,
+		Duration:           &duration,
+	}
+
+	g, _ := beats.Grid(j.BeatsPerBar, j.Downbeat)
+	sum := 0.0
+	count := 0
+	for i, b := range beats.Beats {
+		var position interface{}
+		if g != nil {
+			_, position = g.Bar(g.Positions[i])
+		}
+
+		c := confidence(b)
+		if c != nil {
+			sum += *c
+			count++
+		}
+
+		beat.Data = append(beat.Data, jamsObservation{
+			Time:       seconds(b.At),
+			Duration:   0.0,
+			Value:      position,
+			Confidence: c,
+		})
+	}
+
+	tempo := jamsAnnotation{
+		Namespace:          "tempo",
+		Data:               []jamsObservation{},
+		AnnotationMetadata: metadata,
+		Duration:           &duration,
+	}
+
+	if beats.BPM > 0 {
+		var c *float64
+		if count > 0 {
+			v := math.Round(1000*sum/float64(count)) / 1000
+			c = &v
+		}
+
+		tempo.Data = append(tempo.Data, jamsObservation{
+			Time:       0.0,
+			Duration:   duration,
+			Value:      float64(beats.BPM),
+			Confidence: c,
+		})
+	}
+
+	doc := jams{
+		FileMetadata: jamsFileMetadata{
+			Duration:    duration,
+			Identifiers: map[string]string{},
+			JamsVersion: jamsVersion,
+		},
+		Annotations: []jamsAnnotation{beat, tempo},
+	}
+
+	bytes, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	w.Write(bytes)
+	fmt.Fprintln(w)
+
+	return nil
+}
+
+// Returns the confidence in a beat estimated from the spread of the 'taps' for the beat, on the same
+// Gaussian basis as the Cemgil beat tracking accuracy measure, or nil if the beat has fewer than two
+// 'taps' (e.g. an interpolated beat).
+func confidence(beat taps2beats.Beat) *float64 {
+	if len(beat.Taps) < 2 {
+		return nil
+	}
+
+	// ... recalculated from the 'taps' because the beat variance is rounded along with the times
+	mean := 0.0
+	for _, t := range beat.Taps {
+		mean += t.Seconds()
+	}
+
+	mean /= float64(len(beat.Taps))
+
+	variance := 0.0
+	for _, t := range beat.Taps {
+		variance += (t.Seconds() - mean) * (t.Seconds() - mean)
+	}
+
+	variance /= float64(len(beat.Taps) - 1)
+
+	c := math.Round(1000*math.Exp(-variance/(2*tolerance*tolerance))) / 1000
+
+	return &c
+}
@@ -0,0 +1,111 @@
+package tapsio
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestReadJAMS(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected [][]float64
+		err      bool
+	}{
+		{
+			`{"annotations": [
+			   {"namespace": "beat", "data": [{"time": 0.5, "value": 1}, {"time": 1.0, "value": 2}]},
+			   {"namespace": "tempo", "data": [{"time": 0.0, "value": 120}]},
+			   {"namespace": "beat_position", "data": [{"time": 0.52}, {"time": 1.01}, {"time": 1.49}]},
+			   {"namespace": "beat", "data": []}
+			 ]}`,
+			[][]float64{{0.5, 1.0}, {0.52, 1.01, 1.49}},
+			false,
+		},
+		{`{"annotations": [{"namespace": "tempo", "data": [{"time": 0.0, "value": 120}]}]}`, nil, true},
+		{`{"annotations": [`, nil, true},
+	}
+
+	for _, test := range tests {
+		set, err := JAMS{}.Read(strings.NewReader(test.content))
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error reading %q", test.content)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		compareTaps(set, test.expected, t)
+	}
+}
+
+func TestWriteJAMS(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:   120,
+		Beats: beatsAt(0.5, 1.0, 1.5),
+	}
+
+	beats.Beats[0].Taps = []time.Duration{
+		taps2beats.Seconds(0.49),
+		taps2beats.Seconds(0.51),
+	}
+
+	var b bytes.Buffer
+	if err := (JAMS{Version: "v0.0.0", BeatsPerBar: 2, Downbeat: 1}).Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	doc := jams{}
+	if err := json.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatalf("Error unmarshalling JAMS (%v)", err)
+	}
+
+	if len(doc.Annotations) != 2 {
+		t.Fatalf("Incorrect annotations - expected:%v, got:%v", 2, len(doc.Annotations))
+	}
+
+	beat := doc.Annotations[0]
+	if beat.Namespace != "beat" || beat.AnnotationMetadata.Version != "v0.0.0" || *beat.Duration != 1.5 {
+		t.Errorf("Incorrect beat annotation - got:%+v", beat)
+	}
+
+	expected := []struct {
+		time       float64
+		position   float64
+		confidence float64
+	}{
+		{0.5, 2, 0.922},
+		{1.0, 1, -1},
+		{1.5, 2, -1},
+	}
+
+	if len(beat.Data) != len(expected) {
+		t.Fatalf("Incorrect beats - expected:%v, got:%v", len(expected), len(beat.Data))
+	}
+
+	for i, e := range expected {
+		o := beat.Data[i]
+		if o.Time != e.time || o.Value != e.position {
+			t.Errorf("Incorrect beat %d - expected:%v@%v, got:%v@%v", i+1, e.position, e.time, o.Value, o.Time)
+		}
+
+		if e.confidence < 0 && o.Confidence != nil {
+			t.Errorf("Incorrect beat %d confidence - expected:%v, got:%v", i+1, nil, *o.Confidence)
+		} else if e.confidence >= 0 && (o.Confidence == nil || *o.Confidence != e.confidence) {
+			t.Errorf("Incorrect beat %d confidence - expected:%v, got:%v", i+1, e.confidence, o.Confidence)
+		}
+	}
+
+	tempo := doc.Annotations[1]
+	if tempo.Namespace != "tempo" || len(tempo.Data) != 1 || tempo.Data[0].Value != 120.0 {
+		t.Errorf("Incorrect tempo annotation - got:%+v", tempo)
+	}
+}
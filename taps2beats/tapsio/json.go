@@ -0,0 +1,226 @@
+package tapsio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Reads 'taps' from a JSON file and writes beats as a versioned JSON document (see taps2beats.Document).
+//
+// A JSON 'taps' file is either the original (version 1) {"taps": [[...],...]} format or a version 2 file with
+// the metadata for each loop and (optionally) each 'tap' e.g.:
+//
+//	{
+//	  "version": 2,
+//	  "loops": [
+//	    {
+//	      "id": "take-1", "tapper": "alice", "start": 12.5, "end": 30.0, "latency": 0.07, "weight": 0.8, "labels": ["verse"],
+//	      "taps": [4.57, { "at": 5.06, "weight": 0.5 }, { "at": 5.60, "confidence": 0.9, "label": "snare" }]
+//	    }
+//	  ]
+//	}
+//
+// All times are in seconds. The loop and 'tap' weights (and 'tap' confidence) default to 1.0 and the
+// weight of each 'tap' is the product of the loop weight, 'tap' weight and 'tap' confidence. Loops and 'taps'
+// with a zero weight are discarded.
+type JSON struct {
+	Tool      string               // name and version of the tool that estimated the beats
+	Options   map[string]string    // options used to estimate the beats
+	Input     string               // input file
+	Checksum  string               // checksum of the input file
+	Precision time.Duration        // precision of the times in the beats document (0 for nanoseconds)
+	TimeBase  *taps2beats.TimeBase // time base of the times in the beats document (defaults to seconds)
+	Indent    string               // indent for prettified JSON (compact if blank)
+}
+
+// A single loop in a version 2 JSON 'taps' file.
+type jsonLoop struct {
+	ID      string    `json:"id,omitempty"`
+	Tapper  string    `json:"tapper,omitempty"`
+	Start   float64   `json:"start,omitempty"`
+	End     float64   `json:"end,omitempty"`
+	Latency float64   `json:"latency,omitempty"`
+	Weight  *float64  `json:"weight,omitempty"`
+	Labels  []string  `json:"labels,omitempty"`
+	Taps    []jsonTap `json:"taps"`
+}
+
+// A single 'tap' in a version 2 JSON 'taps' file, either as just the time (in seconds) or as an object with
+// the time and (optionally) the weight and/or confidence and label of the 'tap'.
+type jsonTap struct {
+	At         float64  `json:"at"`
+	Weight     *float64 `json:"weight,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty"`
+	Label      string   `json:"label,omitempty"`
+}
+
+// Returns true if the content is a JSON object with a 'taps' or 'loops' field.
+func IsJSON(b []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return false
+	}
+
+	doc := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return false
+	}
+
+	_, taps := doc["taps"]
+	_, loops := doc["loops"]
+
+	return taps || loops
+}
+
+func (j JSON) Read(r io.Reader) (taps2beats.TapSet, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	doc := struct {
+		Version int         `json:"version"`
+		Taps    [][]float64 `json:"taps"`
+		Loops   []jsonLoop  `json:"loops"`
+	}{}
+
+	if err := json.Unmarshal(bytes, &doc); err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	switch {
+	case doc.Version == 0 || doc.Version == 1:
+		if doc.Loops != nil {
+			return taps2beats.TapSet{}, fmt.Errorf("'loops' requires version 2")
+		}
+
+		return taps2beats.NewTapSetFromFloats(doc.Taps), nil
+
+	case doc.Version == 2:
+		set := taps2beats.TapSet{
+			Loops: []taps2beats.Loop{},
+		}
+
+		for i, l := range doc.Loops {
+			loop := taps2beats.Loop{
+				ID:      l.ID,
+				Tapper:  l.Tapper,
+				Start:   taps2beats.Seconds(l.Start),
+				End:     taps2beats.Seconds(l.End),
+				Latency: taps2beats.Seconds(l.Latency),
+				Weight:  1.0,
+				Labels:  l.Labels,
+				Taps:    []taps2beats.Tap{},
+			}
+
+			if loop.ID == "" {
+				loop.ID = strconv.Itoa(i + 1)
+			}
+
+			if l.Weight != nil {
+				loop.Weight = *l.Weight
+			}
+
+			if loop.Weight < 0 {
+				return taps2beats.TapSet{}, fmt.Errorf("invalid weight for loop %d (%v)", i+1, loop.Weight)
+			}
+
+			for j, t := range l.Taps {
+				w := 1.0
+				if t.Weight != nil {
+					w = *t.Weight
+				}
+
+				if t.Confidence != nil {
+					if *t.Confidence < 0 || *t.Confidence > 1 {
+						return taps2beats.TapSet{}, fmt.Errorf("invalid confidence for loop %d, tap %d (%v)", i+1, j+1, *t.Confidence)
+					}
+
+					w *= *t.Confidence
+				}
+
+				if w < 0 {
+					return taps2beats.TapSet{}, fmt.Errorf("invalid weight for loop %d, tap %d (%v)", i+1, j+1, w)
+				} else if w == 0 {
+					continue
+				}
+
+				loop.Taps = append(loop.Taps, taps2beats.Tap{At: taps2beats.Seconds(t.At), Weight: w, Label: t.Label})
+			}
+
+			if loop.Weight > 0 {
+				set.Loops = append(set.Loops, loop)
+			}
+		}
+
+		return set, nil
+
+	default:
+		return taps2beats.TapSet{}, fmt.Errorf("unsupported 'taps' version (%v)", doc.Version)
+	}
+}
+
+func (j JSON) Write(w io.Writer, beats taps2beats.Beats) error {
+	doc := taps2beats.Document{
+		Tool:      j.Tool,
+		Options:   j.Options,
+		Input:     j.Input,
+		Checksum:  j.Checksum,
+		Precision: j.Precision,
+		TimeBase:  j.TimeBase,
+		Beats:     beats,
+	}
+
+	var bytes []byte
+	var err error
+
+	if j.Indent == "" {
+		bytes, err = json.Marshal(doc)
+	} else {
+		bytes, err = json.MarshalIndent(doc, "", j.Indent)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bytes)
+
+	return err
+}
+
+func (t *jsonTap) UnmarshalJSON(bytes []byte) error {
+	var at float64
+	if err := json.Unmarshal(bytes, &at); err == nil {
+		*t = jsonTap{At: at}
+		return nil
+	}
+
+	tap := struct {
+		At         *float64 `json:"at"`
+		Weight     *float64 `json:"weight"`
+		Confidence *float64 `json:"confidence"`
+		Label      string   `json:"label"`
+	}{}
+
+	if err := json.Unmarshal(bytes, &tap); err != nil {
+		return err
+	} else if tap.At == nil {
+		return fmt.Errorf("missing 'at' for tap (%s)", string(bytes))
+	}
+
+	*t = jsonTap{
+		At:         *tap.At,
+		Weight:     tap.Weight,
+		Confidence: tap.Confidence,
+		Label:      tap.Label,
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package tapsio
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestReadJSONv1(t *testing.T) {
+	set, err := JSON{}.Read(strings.NewReader(`{"taps":[[4.57,5.06],[4.56]]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{4.57, 5.06}, {4.56}}, t)
+}
+
+func TestReadJSONv2(t *testing.T) {
+	doc := `{
+	  "version": 2,
+	  "loops": [
+	    { "id": "take-1", "tapper": "alice", "start": 12.5, "latency": 0.07, "weight": 0.8, "labels": ["verse"],
+	      "taps": [4.57, { "at": 5.06, "weight": 0.5 }, { "at": 5.60, "confidence": 0.9, "label": "snare" }] },
+	    { "taps": [4.56] }
+	  ]
+	}`
+
+	set, err := JSON{}.Read(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{4.57, 5.06, 5.60}, {4.56}}, t)
+
+	loop := set.Loops[0]
+	if loop.ID != "take-1" || loop.Tapper != "alice" || loop.Start != 12500*time.Millisecond || loop.Latency != 70*time.Millisecond || loop.Weight != 0.8 {
+		t.Errorf("Incorrect loop - got:%+v", loop)
+	}
+
+	if loop.Taps[1].Weight != 0.5 || loop.Taps[2].Weight != 0.9 || loop.Taps[2].Label != "snare" {
+		t.Errorf("Incorrect taps - got:%+v", loop.Taps)
+	}
+
+	if set.Loops[1].ID != "2" || set.Loops[1].Weight != 1.0 {
+		t.Errorf("Incorrect default loop - got:%+v", set.Loops[1])
+	}
+}
+
+func TestReadJSONWithZeroWeights(t *testing.T) {
+	doc := `{"version":2,"loops":[{"weight":0,"taps":[1.0]},{"taps":[{"at":1.5,"weight":0},2.0]}]}`
+
+	set, err := JSON{}.Read(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{2.0}}, t)
+}
+
+func TestReadJSONWithInvalidData(t *testing.T) {
+	tests := []string{
+		`{"loops":[{"taps":[1.0]}]}`,
+		`{"version":3,"taps":[[1.0]]}`,
+		`{"version":2,"loops":[{"weight":-1,"taps":[1.0]}]}`,
+		`{"version":2,"loops":[{"taps":[{"at":1.0,"confidence":1.5}]}]}`,
+		`{"version":2,"loops":[{"taps":[{"weight":1.0}]}]}`,
+	}
+
+	for _, test := range tests {
+		if _, err := (JSON{}).Read(strings.NewReader(test)); err == nil {
+			t.Errorf("Expected error for %v", test)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:    120,
+		Offset: 500 * time.Millisecond,
+		Beats:  beatsAt(0.5, 1.0),
+	}
+
+	var b bytes.Buffer
+	if err := (JSON{Tool: "test", Precision: time.Millisecond}).Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if !strings.HasPrefix(b.String(), `{"version":2,"tool":"test","precision":"1ms"`) {
+		t.Errorf("Incorrect JSON - got:%v", b.String())
+	}
+
+	var doc taps2beats.Document
+	if err := json.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if doc.Tool != "test" || doc.Beats.BPM != 120 || len(doc.Beats.Beats) != 2 || doc.Beats.Beats[1].At != time.Second {
+		t.Errorf("Incorrect document - got:%+v", doc)
+	}
+}
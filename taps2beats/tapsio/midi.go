@@ -0,0 +1,34 @@
+package tapsio
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/midi"
+)
+
+// Reads 'taps' from the note-on events in a Standard MIDI File and writes beats as a Standard MIDI File (see
+// midi.Import and midi.Export).
+type MIDI struct {
+	Import midi.Import // settings for reading the 'taps'
+	Export midi.Export // settings for writing the beats
+}
+
+// Returns true if the content is a Standard MIDI File.
+func IsMIDI(b []byte) bool {
+	return bytes.HasPrefix(b, []byte("MThd"))
+}
+
+func (m MIDI) Read(r io.Reader) (taps2beats.TapSet, error) {
+	taps, err := m.Import.Read(r)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	return taps2beats.NewTapSet(taps), nil
+}
+
+func (m MIDI) Write(w io.Writer, beats taps2beats.Beats) error {
+	return m.Export.Write(w, beats)
+}
@@ -0,0 +1,89 @@
+package tapsio
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Reads 'taps' from and writes beats as a MIREX style .beats file i.e. one beat per line, with the time of
+// the beat (in seconds) in the first column and (optionally) the position of the beat in the bar in the
+// second column.
+//
+// The beats in the file are read as a single loop.
+type MIREX struct {
+	BeatsPerBar int // beats per bar for the beat positions (0 for no beat positions)
+	Downbeat    int // index of the first downbeat for the beat positions
+}
+
+// Writes the BPM as a MIREX style .bpm file.
+type BPM struct {
+}
+
+// Reads the first column of each line as a single loop of 'taps'.
+func (m MIREX) Read(r io.Reader) (taps2beats.TapSet, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	row := []float64{}
+	re := regexp.MustCompile(`\s+`)
+
+	for i, line := range strings.Split(string(bytes), "\n") {
+		tokens := re.Split(strings.TrimSpace(line), -1)
+		if len(tokens) == 0 || tokens[0] == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(tokens[0], 64)
+		if err != nil {
+			return taps2beats.TapSet{}, fmt.Errorf("invalid beat at line %d (%v)", i+1, tokens[0])
+		}
+
+		row = append(row, v)
+	}
+
+	return taps2beats.NewTapSetFromFloats([][]float64{row}), nil
+}
+
+func (m MIREX) Write(w io.Writer, beats taps2beats.Beats) error {
+	g, _ := beats.Grid(m.BeatsPerBar, m.Downbeat)
+
+	for i, b := range beats.Beats {
+		if g != nil {
+			_, beat := g.Bar(g.Positions[i])
+			fmt.Fprintf(w, "%.3f\t%d\n", b.At.Seconds(), beat)
+		} else {
+			fmt.Fprintf(w, "%.3f\n", b.At.Seconds())
+		}
+	}
+
+	return nil
+}
+
+func (b BPM) Write(w io.Writer, beats taps2beats.Beats) error {
+	fmt.Fprintf(w, "%v\n", beats.BPM)
+
+	return nil
+}
+
+// Reads the tempo from a MIREX style .bpm file i.e. the first value in the file.
+func ReadBPM(r io.Reader) (float64, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+
+	tokens := strings.Fields(string(bytes))
+	if len(tokens) == 0 {
+		return 0, fmt.Errorf("no BPM")
+	}
+
+	return strconv.ParseFloat(tokens[0], 64)
+}
@@ -0,0 +1,79 @@
+package tapsio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestReadMIREX(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected [][]float64
+		err      bool
+	}{
+		{"0.5\n1.0\n1.5\n", [][]float64{{0.5, 1.0, 1.5}}, false},
+		{"0.5\t1\n  1.0  2\n\n1.5\t1\n", [][]float64{{0.5, 1.0, 1.5}}, false},
+		{"0.5\nbeat\n", nil, true},
+	}
+
+	for _, test := range tests {
+		set, err := MIREX{}.Read(strings.NewReader(test.content))
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error reading %q", test.content)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		compareTaps(set, test.expected, t)
+	}
+}
+
+func TestWriteMIREX(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0),
+	}
+
+	tests := []struct {
+		mirex    MIREX
+		expected []string
+	}{
+		{MIREX{}, []string{"0.500", "1.000", "1.500", "2.000"}},
+		{MIREX{BeatsPerBar: 3}, []string{"0.500\t1", "1.000\t2", "1.500\t3", "2.000\t1"}},
+		{MIREX{BeatsPerBar: 3, Downbeat: 2}, []string{"0.500\t2", "1.000\t3", "1.500\t1", "2.000\t2"}},
+	}
+
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := test.mirex.Write(&b, beats); err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		compare(b.String(), test.expected, t)
+	}
+}
+
+func TestBPM(t *testing.T) {
+	var b bytes.Buffer
+	if err := (BPM{}).Write(&b, taps2beats.Beats{BPM: 120}); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	bpm, err := ReadBPM(&b)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	} else if bpm != 120 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%v", 120, bpm)
+	}
+
+	if _, err := ReadBPM(strings.NewReader("\n")); err == nil {
+		t.Errorf("Expected error reading empty BPM file")
+	}
+}
@@ -0,0 +1,23 @@
+package tapsio
+
+import (
+	"io"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/reaper"
+)
+
+// Writes beats as a Reaper region/marker CSV file or (for TempoEnvelope) as the tempo envelope of an RPP
+// project file (see reaper.Export).
+type Reaper struct {
+	Export        reaper.Export // marker and tempo map settings
+	TempoEnvelope bool          // writes the tempo envelope rather than the markers
+}
+
+func (x Reaper) Write(w io.Writer, beats taps2beats.Beats) error {
+	if x.TempoEnvelope {
+		return x.Export.WriteTempoEnvelope(w, beats)
+	}
+
+	return x.Export.WriteMarkers(w, beats)
+}
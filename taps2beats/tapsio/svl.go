@@ -0,0 +1,241 @@
+package tapsio
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Reads 'taps' from a Sonic Visualiser layer (or session) file and writes beats as a Sonic Visualiser layer
+// file.
+//
+// Each time instants layer in the file is read as a separate loop. The beats are written as a time instants
+// layer, with the beat numbers or (for Labels 'bars') bar:beat as the labels, and (optionally) a time values
+// layer with the instantaneous BPM at each beat.
+type SVL struct {
+	Labels      string // 'beats' or 'bars'
+	BeatsPerBar int    // beats per bar for 'bars' labels
+	Downbeat    int    // index of the first downbeat for 'bars' labels
+	TempoLayer  bool   // adds a time values layer with the BPM at each beat
+}
+
+// Sonic Visualiser layer file (.svl) i.e. the subset of the Sonic Visualiser session XML used for
+// time instants and time values layers.
+type svl struct {
+	XMLName xml.Name `xml:"sv"`
+	Data    struct {
+		Models   []svlModel   `xml:"model"`
+		Datasets []svlDataset `xml:"dataset"`
+	} `xml:"data"`
+	Display struct {
+		Layers []svlLayer `xml:"layer"`
+	} `xml:"display"`
+}
+
+type svlModel struct {
+	ID          int    `xml:"id,attr"`
+	Name        string `xml:"name,attr"`
+	SampleRate  int    `xml:"sampleRate,attr"`
+	Start       int    `xml:"start,attr"`
+	End         int    `xml:"end,attr"`
+	Type        string `xml:"type,attr"`
+	Dimensions  int    `xml:"dimensions,attr"`
+	Resolution  int    `xml:"resolution,attr"`
+	NotifyOnAdd bool   `xml:"notifyOnAdd,attr"`
+	Dataset     int    `xml:"dataset,attr"`
+	Units       string `xml:"units,attr,omitempty"`
+}
+
+type svlDataset struct {
+	ID         int        `xml:"id,attr"`
+	Dimensions int        `xml:"dimensions,attr"`
+	Points     []svlPoint `xml:"point"`
+}
+
+type svlPoint struct {
+	Frame int      `xml:"frame,attr"`
+	Value *float64 `xml:"value,attr,omitempty"`
+	Label string   `xml:"label,attr"`
+}
+
+type svlLayer struct {
+	ID         int    `xml:"id,attr"`
+	Type       string `xml:"type,attr"`
+	Name       string `xml:"name,attr"`
+	Model      int    `xml:"model,attr"`
+	ColourName string `xml:"colourName,attr,omitempty"`
+	PlotStyle  *int   `xml:"plotStyle,attr,omitempty"`
+}
+
+const svlSampleRate = 44100
+
+// Returns true if the content is a Sonic Visualiser XML file.
+func IsSVL(b []byte) bool {
+	return bytes.Contains(b, []byte("<sv>"))
+}
+
+// Reads the time instants layers as 'taps', with each layer as a separate loop.
+func (s SVL) Read(r io.Reader) (taps2beats.TapSet, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	doc := svl{}
+	if err := xml.Unmarshal(bytes, &doc); err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	datasets := map[int]svlDataset{}
+	for _, d := range doc.Data.Datasets {
+		datasets[d.ID] = d
+	}
+
+	data := [][]float64{}
+	for _, m := range doc.Data.Models {
+		if m.Type != "sparse" || m.Dimensions != 1 || m.SampleRate <= 0 {
+			continue
+		}
+
+		if d, ok := datasets[m.Dataset]; ok {
+			row := []float64{}
+			for _, p := range d.Points {
+				row = append(row, float64(p.Frame)/float64(m.SampleRate))
+			}
+
+			if len(row) > 0 {
+				data = append(data, row)
+			}
+		}
+	}
+
+	if len(data) == 0 {
+		return taps2beats.TapSet{}, fmt.Errorf("no time instants layers")
+	}
+
+	return taps2beats.NewTapSetFromFloats(data), nil
+}
+
+func (s SVL) Write(w io.Writer, beats taps2beats.Beats) error {
+	frame := func(t time.Duration) int {
+		return int(math.Round(t.Seconds() * svlSampleRate))
+	}
+
+	instants := svlDataset{
+		ID:         0,
+		Dimensions: 1,
+		Points:     []svlPoint{},
+	}
+
+	var g *taps2beats.Grid
+	if s.Labels == "bars" {
+		g, _ = beats.Grid(s.BeatsPerBar, s.Downbeat)
+	}
+
+	for i, b := range beats.Beats {
+		instants.Points = append(instants.Points, svlPoint{
+			Frame: frame(b.At),
+			Label: label(g, i),
+		})
+	}
+
+	start, end := 0, 0
+	if N := len(beats.Beats); N > 0 {
+		start = frame(beats.Beats[0].At)
+		end = frame(beats.Beats[N-1].At)
+	}
+
+	doc := svl{}
+	doc.Data.Models = append(doc.Data.Models, svlModel{
+		ID:          1,
+		Name:        "Beats",
+		SampleRate:  svlSampleRate,
+		Start:       start,
+		End:         end,
+		Type:        "sparse",
+		Dimensions:  1,
+		Resolution:  1,
+		NotifyOnAdd: true,
+		Dataset:     0,
+	})
+
+	doc.Data.Datasets = append(doc.Data.Datasets, instants)
+	doc.Display.Layers = append(doc.Display.Layers, svlLayer{
+		ID:         2,
+		Type:       "timeinstants",
+		Name:       "Beats",
+		Model:      1,
+		ColourName: "Purple",
+	})
+
+	if s.TempoLayer && len(beats.Beats) > 1 {
+		values := svlDataset{
+			ID:         3,
+			Dimensions: 2,
+			Points:     []svlPoint{},
+		}
+
+		N := len(beats.Beats)
+		for i, b := range beats.Beats {
+			dt := time.Duration(0)
+			if i < N-1 {
+				dt = beats.Beats[i+1].At - b.At
+			} else {
+				dt = b.At - beats.Beats[i-1].At
+			}
+
+			if dt > 0 {
+				bpm := math.Round(10*60/dt.Seconds()) / 10
+				values.Points = append(values.Points, svlPoint{
+					Frame: frame(b.At),
+					Value: &bpm,
+					Label: "",
+				})
+			}
+		}
+
+		style := 3 // ... 'connected points'
+
+		doc.Data.Models = append(doc.Data.Models, svlModel{
+			ID:          4,
+			Name:        "BPM",
+			SampleRate:  svlSampleRate,
+			Start:       start,
+			End:         end,
+			Type:        "sparse",
+			Dimensions:  2,
+			Resolution:  1,
+			NotifyOnAdd: true,
+			Dataset:     3,
+			Units:       "bpm",
+		})
+
+		doc.Data.Datasets = append(doc.Data.Datasets, values)
+		doc.Display.Layers = append(doc.Display.Layers, svlLayer{
+			ID:         5,
+			Type:       "timevalues",
+			Name:       "BPM",
+			Model:      4,
+			ColourName: "Orange",
+			PlotStyle:  &style,
+		})
+	}
+
+	bytes, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(w, `<!DOCTYPE sonic-visualiser>`)
+	w.Write(bytes)
+	fmt.Fprintln(w)
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package tapsio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+const session = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE sonic-visualiser>
+<sv>
+  <data>
+    <model id="1" name="Tapper 1" sampleRate="1000" start="500" end="1500" type="sparse" dimensions="1" resolution="1" notifyOnAdd="true" dataset="0"/>
+    <dataset id="0" dimensions="1">
+      <point frame="500" label="1"/>
+      <point frame="1000" label="2"/>
+      <point frame="1500" label="3"/>
+    </dataset>
+    <model id="2" name="BPM" sampleRate="1000" start="500" end="1500" type="sparse" dimensions="2" resolution="1" notifyOnAdd="true" dataset="3" units="bpm"/>
+    <dataset id="3" dimensions="2">
+      <point frame="500" value="120" label=""/>
+    </dataset>
+    <model id="4" name="Tapper 2" sampleRate="2000" start="1020" end="3040" type="sparse" dimensions="1" resolution="1" notifyOnAdd="true" dataset="5"/>
+    <dataset id="5" dimensions="1">
+      <point frame="1020" label=""/>
+      <point frame="2040" label=""/>
+      <point frame="3040" label=""/>
+    </dataset>
+  </data>
+</sv>`
+
+func TestIsSVL(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{session, true},
+		{"<sv><data></data></sv>", true},
+		{"4.5\t4.5\tA\n", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if v := IsSVL([]byte(test.content)); v != test.expected {
+			t.Errorf("Incorrect IsSVL for %q - expected:%v, got:%v", test.content, test.expected, v)
+		}
+	}
+}
+
+func TestReadSVL(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected [][]float64
+		err      bool
+	}{
+		{session, [][]float64{{0.5, 1.0, 1.5}, {0.51, 1.02, 1.52}}, false},
+		{`<sv><data><model id="1" sampleRate="1000" type="sparse" dimensions="2" dataset="0"/><dataset id="0" dimensions="2"><point frame="500" value="120"/></dataset></data></sv>`, nil, true},
+		{`<sv><data>`, nil, true},
+	}
+
+	for _, test := range tests {
+		set, err := SVL{}.Read(strings.NewReader(test.content))
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error reading %q", test.content)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		compareTaps(set, test.expected, t)
+	}
+}
+
+func TestWriteSVL(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.25),
+	}
+
+	expected := []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<!DOCTYPE sonic-visualiser>`,
+		`<sv>`,
+		`  <data>`,
+		`    <model id="1" name="Beats" sampleRate="44100" start="22050" end="99225" type="sparse" dimensions="1" resolution="1" notifyOnAdd="true" dataset="0"></model>`,
+		`    <model id="4" name="BPM" sampleRate="44100" start="22050" end="99225" type="sparse" dimensions="2" resolution="1" notifyOnAdd="true" dataset="3" units="bpm"></model>`,
+		`    <dataset id="0" dimensions="1">`,
+		`      <point frame="22050" label="0:2"></point>`,
+		`      <point frame="44100" label="1:1"></point>`,
+		`      <point frame="66150" label="1:2"></point>`,
+		`      <point frame="99225" label="2:2"></point>`,
+		`    </dataset>`,
+		`    <dataset id="3" dimensions="2">`,
+		`      <point frame="22050" value="120" label=""></point>`,
+		`      <point frame="44100" value="120" label=""></point>`,
+		`      <point frame="66150" value="80" label=""></point>`,
+		`      <point frame="99225" value="80" label=""></point>`,
+		`    </dataset>`,
+		`  </data>`,
+		`  <display>`,
+		`    <layer id="2" type="timeinstants" name="Beats" model="1" colourName="Purple"></layer>`,
+		`    <layer id="5" type="timevalues" name="BPM" model="4" colourName="Orange" plotStyle="3"></layer>`,
+		`  </display>`,
+		`</sv>`,
+	}
+
+	var b bytes.Buffer
+	if err := (SVL{Labels: "bars", BeatsPerBar: 2, Downbeat: 1, TempoLayer: true}).Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteSVLWithCoincidentBeats(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 0.5, 1.0),
+	}
+
+	var b bytes.Buffer
+	if err := (SVL{TempoLayer: true}).Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if strings.Contains(b.String(), "Inf") || strings.Count(b.String(), "value=") != 2 {
+		t.Errorf("Incorrect tempo layer for coincident beats:\n%v", b.String())
+	}
+}
@@ -0,0 +1,303 @@
+// Package tapsio reads 'taps' and writes beats in the supported file formats, with a registry of the formats
+// by name, file extension and content so that applications can add formats without changing the code that
+// selects the format.
+//
+// The built-in formats are registered with their default settings - an application can replace a built-in
+// format with a differently configured Reader or Writer e.g. to set the beats per bar from the command line.
+package tapsio
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/midi"
+	"github.com/transcriptaze/taps2beats/taps2beats/reaper"
+	"github.com/transcriptaze/taps2beats/taps2beats/rekordbox"
+	"github.com/transcriptaze/taps2beats/taps2beats/video"
+)
+
+// Reads a set of 'taps' from a file format.
+type Reader interface {
+	Read(r io.Reader) (taps2beats.TapSet, error)
+}
+
+// Writes a set of beats in a file format.
+type Writer interface {
+	Write(w io.Writer, beats taps2beats.Beats) error
+}
+
+// Adapts an ordinary function to the Reader interface.
+type ReaderFunc func(r io.Reader) (taps2beats.TapSet, error)
+
+// Adapts an ordinary function to the Writer interface.
+type WriterFunc func(w io.Writer, beats taps2beats.Beats) error
+
+// A registered file format. A format may be read-only (no Writer) or write-only (no Reader).
+type Format struct {
+	Name       string            // unique name for the format e.g. 'txt' (case insensitive)
+	Extensions []string          // file extensions for the format e.g. '.txt'
+	Sniff      func([]byte) bool // returns true if the content is in this format (optional)
+	Onsets     bool              // true if the 'taps' are onsets (e.g. from an onset detector) rather than loops
+	Reader     Reader
+	Writer     Writer
+}
+
+var registry = struct {
+	sync.RWMutex
+	formats []Format
+}{}
+
+func init() {
+	Register(Format{
+		Name:       "json",
+		Extensions: []string{".json"},
+		Sniff:      IsJSON,
+		Reader:     JSON{},
+		Writer:     JSON{},
+	})
+
+	Register(Format{
+		Name:   "audacity",
+		Sniff:  IsAudacity,
+		Reader: Audacity{},
+		Writer: Audacity{},
+	})
+
+	Register(Format{
+		Name:       "txt",
+		Extensions: []string{".txt"},
+		Sniff:      IsTXT,
+		Reader:     TXT{},
+		Writer:     TXT{},
+	})
+
+	Register(Format{
+		Name:       "csv",
+		Extensions: []string{".csv"},
+		Reader:     CSV{Delimiter: ','},
+		Writer:     CSV{Delimiter: ',', BeatsPerBar: 4},
+	})
+
+	Register(Format{
+		Name:       "tsv",
+		Extensions: []string{".tsv"},
+		Reader:     CSV{Delimiter: '\t'},
+		Writer:     CSV{Delimiter: '\t', BeatsPerBar: 4},
+	})
+
+	Register(Format{
+		Name:       "svl",
+		Extensions: []string{".svl"},
+		Sniff:      IsSVL,
+		Onsets:     true,
+		Reader:     SVL{},
+		Writer:     SVL{},
+	})
+
+	Register(Format{
+		Name:       "jams",
+		Extensions: []string{".jams"},
+		Onsets:     true,
+		Reader:     JAMS{},
+		Writer:     JAMS{BeatsPerBar: 4},
+	})
+
+	Register(Format{
+		Name:       "beats",
+		Extensions: []string{".beats"},
+		Onsets:     true,
+		Reader:     MIREX{},
+		Writer:     MIREX{BeatsPerBar: 4},
+	})
+
+	Register(Format{
+		Name:       "bpm",
+		Extensions: []string{".bpm"},
+		Writer:     BPM{},
+	})
+
+	Register(Format{
+		Name:       "midi",
+		Extensions: []string{".mid", ".midi"},
+		Sniff:      IsMIDI,
+		Reader:     MIDI{Import: midi.NewImport(), Export: midi.NewExport()},
+		Writer:     MIDI{Import: midi.NewImport(), Export: midi.NewExport()},
+	})
+
+	Register(Format{
+		Name:       "wav",
+		Extensions: []string{".wav"},
+		Sniff:      IsWAV,
+		Onsets:     true,
+		Reader:     WAV{},
+	})
+
+	Register(Format{
+		Name:   "reaper",
+		Writer: Reaper{Export: reaper.NewExport()},
+	})
+
+	Register(Format{
+		Name:       "rpp",
+		Extensions: []string{".rpp"},
+		Writer:     Reaper{Export: reaper.NewExport(), TempoEnvelope: true},
+	})
+
+	Register(Format{
+		Name:   "rekordbox",
+		Writer: rekordbox.NewExport(),
+	})
+
+	Register(Format{
+		Name:       "fcpxml",
+		Extensions: []string{".fcpxml"},
+		Writer:     Video{Export: video.NewExport(), Format: FCPXML},
+	})
+
+	Register(Format{
+		Name:       "edl",
+		Extensions: []string{".edl"},
+		Writer:     Video{Export: video.NewExport(), Format: EDL},
+	})
+
+	Register(Format{
+		Name:       "ffmetadata",
+		Extensions: []string{".ffmetadata"},
+		Writer:     Video{Export: video.NewExport(), Format: FFMetadata},
+	})
+}
+
+func (f ReaderFunc) Read(r io.Reader) (taps2beats.TapSet, error) {
+	return f(r)
+}
+
+func (f WriterFunc) Write(w io.Writer, beats taps2beats.Beats) error {
+	return f(w, beats)
+}
+
+// Registers a file format, replacing the registered format with the same name (if any) e.g. to replace
+// a built-in format with a differently configured Reader or Writer. Formats are matched by extension
+// and content in the order in which they were first registered.
+func Register(format Format) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for i, f := range registry.formats {
+		if strings.EqualFold(f.Name, format.Name) {
+			registry.formats[i] = format
+			return
+		}
+	}
+
+	registry.formats = append(registry.formats, format)
+}
+
+// Returns the registered formats, sorted by name.
+func Formats() []Format {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	formats := append([]Format{}, registry.formats...)
+
+	sort.SliceStable(formats, func(i, j int) bool { return formats[i].Name < formats[j].Name })
+
+	return formats
+}
+
+// Returns the registered format with the name.
+func Lookup(name string) (Format, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for _, f := range registry.formats {
+		if strings.EqualFold(f.Name, name) {
+			return f, nil
+		}
+	}
+
+	return Format{}, fmt.Errorf("unknown format '%v'", name)
+}
+
+// Returns the format for reading 'taps' i.e. the named format if the name is not blank, otherwise the first
+// readable format with the file extension or, failing that, the first readable format that matches the
+// content. Returns an error if no readable format matches.
+func ReaderFor(name, file string, bytes []byte) (Format, error) {
+	if name != "" {
+		if f, err := Lookup(name); err != nil {
+			return Format{}, err
+		} else if f.Reader == nil {
+			return Format{}, fmt.Errorf("format '%v' cannot be read", f.Name)
+		} else {
+			return f, nil
+		}
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if f, ok := extension(file, func(f Format) bool { return f.Reader != nil }); ok {
+		return f, nil
+	}
+
+	for _, f := range registry.formats {
+		if f.Reader != nil && f.Sniff != nil && f.Sniff(bytes) {
+			return f, nil
+		}
+	}
+
+	return Format{}, fmt.Errorf("unrecognised format")
+}
+
+// Returns the format for writing beats i.e. the named format if the name is not blank, otherwise the first
+// writable format with the file extension. Returns an error if no writable format matches.
+func WriterFor(name, file string) (Format, error) {
+	if name != "" {
+		if f, err := Lookup(name); err != nil {
+			return Format{}, err
+		} else if f.Writer == nil {
+			return Format{}, fmt.Errorf("format '%v' cannot be written", f.Name)
+		} else {
+			return f, nil
+		}
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if f, ok := extension(file, func(f Format) bool { return f.Writer != nil }); ok {
+		return f, nil
+	}
+
+	return Format{}, fmt.Errorf("unrecognised format")
+}
+
+// Returns the first matching format for the file extension (the caller must hold the registry lock).
+func extension(file string, match func(f Format) bool) (Format, bool) {
+	if ext := strings.ToLower(filepath.Ext(file)); ext != "" {
+		for _, f := range registry.formats {
+			for _, e := range f.Extensions {
+				if strings.EqualFold(e, ext) && match(f) {
+					return f, true
+				}
+			}
+		}
+	}
+
+	return Format{}, false
+}
+
+// Returns the label for a beat i.e. bar:beat for a beat on a beat grid, otherwise the beat number.
+func label(g *taps2beats.Grid, i int) string {
+	if g != nil {
+		bar, beat := g.Bar(g.Positions[i])
+
+		return fmt.Sprintf("%d:%d", bar, beat)
+	}
+
+	return fmt.Sprintf("%d", i+1)
+}
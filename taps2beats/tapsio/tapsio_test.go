@@ -0,0 +1,164 @@
+package tapsio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"txt", "json", "audacity", "JSON"} {
+		if f, err := Lookup(name); err != nil {
+			t.Errorf("Unexpected error for %v (%v)", name, err)
+		} else if !strings.EqualFold(f.Name, name) {
+			t.Errorf("Incorrect format for %v - got:%v", name, f.Name)
+		}
+	}
+
+	if _, err := Lookup("xyz"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func TestOnsets(t *testing.T) {
+	tests := map[string]bool{
+		"svl":   true,
+		"jams":  true,
+		"beats": true,
+		"wav":   true,
+		"midi":  false,
+		"json":  false,
+		"txt":   false,
+	}
+
+	for name, expected := range tests {
+		if f, err := Lookup(name); err != nil {
+			t.Errorf("Unexpected error for %v (%v)", name, err)
+		} else if f.Onsets != expected {
+			t.Errorf("Incorrect onsets for %v - expected:%v, got:%v", name, expected, f.Onsets)
+		}
+	}
+}
+
+func TestReaderFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{"", "taps.txt", "4.57 5.06", "txt"},
+		{"", "TAPS.JSON", `{"taps":[[4.57]]}`, "json"},
+		{"", "taps.txt", "4.57\t4.57\tA\n", "txt"},
+		{"", "<stdin>", `{"taps":[[4.57]]}`, "json"},
+		{"", "<stdin>", "4.57\t4.57\tA\n", "audacity"},
+		{"", "<stdin>", "4.57 5.06\n4.56 5.07\n", "txt"},
+		{"json", "taps.txt", "4.57 5.06", "json"},
+	}
+
+	for _, test := range tests {
+		f, err := ReaderFor(test.name, test.file, []byte(test.content))
+		if err != nil {
+			t.Errorf("Unexpected error for %v (%v)", test.file, err)
+		} else if f.Name != test.expected {
+			t.Errorf("Incorrect format for %v %q - expected:%v, got:%v", test.file, test.content, test.expected, f.Name)
+		}
+	}
+
+	if _, err := ReaderFor("", "<stdin>", []byte("lorem ipsum")); err == nil {
+		t.Errorf("Expected error for unrecognised content")
+	}
+}
+
+func TestWriterFor(t *testing.T) {
+	if f, err := WriterFor("", "beats.json"); err != nil || f.Name != "json" {
+		t.Errorf("Incorrect format for beats.json - got:%v (%v)", f.Name, err)
+	}
+
+	if f, err := WriterFor("audacity", "beats.json"); err != nil || f.Name != "audacity" {
+		t.Errorf("Incorrect format for --to audacity - got:%v (%v)", f.Name, err)
+	}
+
+	if _, err := WriterFor("", "beats.xyz"); err == nil {
+		t.Errorf("Expected error for unrecognised extension")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(Format{
+		Name:       "test",
+		Extensions: []string{".test"},
+		Reader: ReaderFunc(func(r io.Reader) (taps2beats.TapSet, error) {
+			return taps2beats.NewTapSetFromFloats([][]float64{{1.0}}), nil
+		}),
+		Writer: WriterFunc(func(w io.Writer, beats taps2beats.Beats) error {
+			_, err := w.Write([]byte("test"))
+			return err
+		}),
+	})
+
+	f, err := ReaderFor("", "taps.test", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if set, err := f.Reader.Read(strings.NewReader("")); err != nil || len(set.Loops) != 1 {
+		t.Errorf("Incorrect tap set - got:%+v (%v)", set, err)
+	}
+
+	var b bytes.Buffer
+	if err := f.Writer.Write(&b, taps2beats.Beats{}); err != nil || b.String() != "test" {
+		t.Errorf("Incorrect output - got:%v (%v)", b.String(), err)
+	}
+
+	// ... replace
+	Register(Format{Name: "test", Extensions: []string{".test"}})
+
+	if f, err := ReaderFor("", "taps.test", nil); err == nil && f.Name == "test" {
+		t.Errorf("Expected replaced write-only format to be ignored for reading")
+	}
+}
+
+func compare(s string, expected []string, t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Incorrect number of lines - expected:%v, got:%v\n%v", len(expected), len(lines), s)
+	}
+
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Incorrect line %d\n   expected:%q\n   got:     %q", i+1, expected[i], lines[i])
+		}
+	}
+}
+
+func compareTaps(set taps2beats.TapSet, expected [][]float64, t *testing.T) {
+	if len(set.Loops) != len(expected) {
+		t.Fatalf("Incorrect number of loops - expected:%v, got:%v", len(expected), len(set.Loops))
+	}
+
+	for i, loop := range set.Loops {
+		if len(loop.Taps) != len(expected[i]) {
+			t.Fatalf("Incorrect loop %d - expected:%v, got:%+v", i+1, expected[i], loop.Taps)
+		}
+
+		for j, tap := range loop.Taps {
+			if dt := tap.At.Seconds() - expected[i][j]; dt > 0.000001 || dt < -0.000001 {
+				t.Errorf("Incorrect loop %d tap %d - expected:%v, got:%v", i+1, j+1, expected[i][j], tap.At)
+			}
+		}
+	}
+}
+
+func beatsAt(seconds ...float64) []taps2beats.Beat {
+	beats := []taps2beats.Beat{}
+	for _, s := range seconds {
+		beats = append(beats, taps2beats.Beat{At: time.Duration(s * float64(time.Second))})
+	}
+
+	return beats
+}
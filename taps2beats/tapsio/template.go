@@ -0,0 +1,210 @@
+package tapsio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Writes beats using a Go text/template, executed against a TemplateData view of the beats e.g. a Javascript
+// array of the beat times in milliseconds:
+//
+//	const beats = [{{range .Beats}}{{ms .At}}{{if not .Last}}, {{end}}{{end}}];
+//
+// In addition to the standard template functions, the template functions include:
+//
+//	seconds <time>           time in seconds (float64)
+//	ms <time>                time in milliseconds (float64)
+//	samples <time> <rate>    time in samples at the sample rate, rounded to the nearest sample
+//	timecode <time> <fps>    hh:mm:ss:ff timecode at the frame rate (e.g. "25" or "29.97df")
+//	time <time>              time in the TimeBase units (Go duration format by default)
+//	barbeat <index>          bar:beat of the beat index (using BeatsPerBar and Downbeat)
+//	add <a> <b>              a + b (integers)
+//	sub <a> <b>              a - b (integers)
+//	join <list> <separator>  list of times or strings joined with the separator (times are in seconds)
+type Template struct {
+	Text        string               // template text
+	BeatsPerBar int                  // beats per bar for barbeat (defaults to 4)
+	Downbeat    int                  // index of the first downbeat for barbeat
+	TimeBase    *taps2beats.TimeBase // time base for 'time' (defaults to Go durations)
+}
+
+// The view of the beats for a Template.
+type TemplateData struct {
+	BPM        uint                  // estimated BPM
+	Offset     time.Duration         // offset of the first beat from the beat grid origin
+	Count      int                   // number of beats
+	Beats      []TemplateBeat        // the beats
+	Statistics taps2beats.Statistics // fit statistics
+}
+
+// The view of a single beat for a Template.
+type TemplateBeat struct {
+	Index    int             // 0-based index of the beat
+	Number   int             // 1-based beat number
+	Bar      int             // 1-based bar number (0 for beats before the first downbeat)
+	Beat     int             // 1-based beat in the bar
+	At       time.Duration   // time of the beat
+	Mean     time.Duration   // mean of the 'taps' for the beat
+	Variance time.Duration   // variance of the 'taps' for the beat
+	Taps     []time.Duration // 'taps' for the beat
+	Tapped   bool            // true if the beat has 'taps' (false for interpolated beats)
+	First    bool            // true for the first beat
+	Last     bool            // true for the last beat
+}
+
+// Parses the template text, returning an error if the template is invalid.
+func (t Template) Parse() (*template.Template, error) {
+	return template.New("format").Funcs(t.funcs(nil)).Parse(t.Text)
+}
+
+func (t Template) Write(w io.Writer, beats taps2beats.Beats) error {
+	g, _ := beats.Grid(t.beatsPerBar(), t.Downbeat)
+
+	tmpl, err := template.New("format").Funcs(t.funcs(g)).Parse(t.Text)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, t.data(beats, g))
+}
+
+func (t Template) data(beats taps2beats.Beats, g *taps2beats.Grid) TemplateData {
+	if g != nil {
+		beats.Beats = g.Beats
+	}
+
+	data := TemplateData{
+		BPM:        beats.BPM,
+		Offset:     beats.Offset,
+		Count:      len(beats.Beats),
+		Beats:      make([]TemplateBeat, len(beats.Beats)),
+		Statistics: beats.Statistics(),
+	}
+
+	for i, b := range beats.Beats {
+		bar, beat := t.bar(g, i)
+
+		data.Beats[i] = TemplateBeat{
+			Index:    i,
+			Number:   i + 1,
+			Bar:      bar,
+			Beat:     beat,
+			At:       b.At,
+			Mean:     b.Mean,
+			Variance: b.Variance,
+			Taps:     b.Taps,
+			Tapped:   len(b.Taps) > 0,
+			First:    i == 0,
+			Last:     i == len(beats.Beats)-1,
+		}
+	}
+
+	return data
+}
+
+func (t Template) funcs(g *taps2beats.Grid) template.FuncMap {
+	tb := taps2beats.TimeBase{Units: taps2beats.UnitDuration}
+	if t.TimeBase != nil {
+		tb = *t.TimeBase
+	}
+
+	return template.FuncMap{
+		"seconds": func(t time.Duration) float64 {
+			return t.Seconds()
+		},
+
+		"ms": func(t time.Duration) float64 {
+			return float64(t) / float64(time.Millisecond)
+		},
+
+		"samples": func(t time.Duration, rate int) int64 {
+			return taps2beats.TimeBase{Units: taps2beats.UnitSamples, SampleRate: rate}.Value(t)
+		},
+
+		"timecode": func(t time.Duration, fps string) (string, error) {
+			tb, err := taps2beats.ParseTimeBase("smpte:" + fps)
+			if err != nil {
+				return "", err
+			}
+
+			return tb.Format(t), nil
+		},
+
+		"time": func(t time.Duration) string {
+			return tb.Format(t)
+		},
+
+		"barbeat": func(index int) string {
+			bar, beat := t.bar(g, index)
+
+			return fmt.Sprintf("%d:%d", bar, beat)
+		},
+
+		"add": func(a, b int) int {
+			return a + b
+		},
+
+		"sub": func(a, b int) int {
+			return a - b
+		},
+
+		"join": func(list interface{}, separator string) (string, error) {
+			values := []string{}
+			switch l := list.(type) {
+			case []time.Duration:
+				seconds := taps2beats.TimeBase{Units: taps2beats.UnitSeconds}
+				for _, v := range l {
+					values = append(values, seconds.Format(v))
+				}
+
+			case []string:
+				values = l
+
+			default:
+				return "", fmt.Errorf("invalid list for join (%T)", list)
+			}
+
+			return strings.Join(values, separator), nil
+		},
+	}
+}
+
+// Returns the 1-based bar and beat for a beat index from the position of the beat on the beat grid, with
+// beats before the first downbeat in bar 0. Beats past the end of the grid (or without a grid) are taken to
+// be on consecutive grid positions.
+func (t Template) bar(g *taps2beats.Grid, index int) (int, int) {
+	if g == nil {
+		bpb := t.beatsPerBar()
+		g = &taps2beats.Grid{
+			Positions:   []int{0},
+			BeatsPerBar: bpb,
+			Downbeat:    ((t.Downbeat % bpb) + bpb) % bpb,
+		}
+	}
+
+	N := len(g.Positions)
+	position := index
+	switch {
+	case index < 0:
+		position = g.Positions[0] + index
+	case index >= N:
+		position = g.Positions[N-1] + index - N + 1
+	default:
+		position = g.Positions[index]
+	}
+
+	return g.Bar(position)
+}
+
+func (t Template) beatsPerBar() int {
+	if t.BeatsPerBar <= 0 {
+		return 4
+	}
+
+	return t.BeatsPerBar
+}
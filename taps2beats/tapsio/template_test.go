@@ -0,0 +1,52 @@
+package tapsio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:    120,
+		Offset: 250 * time.Millisecond,
+		Beats: []taps2beats.Beat{
+			{At: 250 * time.Millisecond},
+			{At: 750 * time.Millisecond, Mean: 751 * time.Millisecond, Taps: []time.Duration{740 * time.Millisecond, 762 * time.Millisecond}},
+			{At: 1250 * time.Millisecond},
+		},
+	}
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{`[{{range .Beats}}{{ms .At}}{{if not .Last}}, {{end}}{{end}}]`, `[250, 750, 1250]`},
+		{`{{range .Beats}}{{barbeat .Index}} {{.Bar}}.{{.Beat}} {{end}}`, `0:4 0.4 1:1 1.1 1:2 1.2 `},
+		{`{{range .Beats}}{{samples .At 48000}};{{timecode .At "25"}};{{printf "%.2f" (seconds .At)}}|{{end}}`, `12000;00:00:00:06;0.25|36000;00:00:00:19;0.75|60000;00:00:01:06;1.25|`},
+		{`{{.BPM}} {{.Count}} {{.Statistics.Tapped}} {{time .Offset}} {{with index .Beats 1}}{{join .Taps ","}} {{add .Number 10}}{{end}}`, `120 3 1 250ms 0.74,0.762 12`},
+	}
+
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := (Template{Text: test.text, Downbeat: 1}).Write(&b, beats); err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		if b.String() != test.expected {
+			t.Errorf("Incorrect output for %v\n   expected:%v\n   got:     %v", test.text, test.expected, b.String())
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	if _, err := (Template{Text: `{{range .Beats}}`}).Parse(); err == nil {
+		t.Errorf("Expected error for invalid template")
+	}
+
+	if _, err := (Template{Text: `{{frames .At}}`}).Parse(); err == nil {
+		t.Errorf("Expected error for unknown function")
+	}
+}
@@ -0,0 +1,354 @@
+package tapsio
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Reads 'taps' from a plain text file and writes beats as a table of the beat number, time, mean, variance
+// and 'taps' of each beat (as Go durations or, if specified, in the TimeBase units).
+//
+// Each 'tap' is a whitespace separated value, either as a number (in seconds or the default units), a Go
+// duration (e.g. 570ms or 4.57s), an m:ss.fff (or h:mm:ss.fff) time or an hh:mm:ss:ff (or hh:mm:ss;ff
+// drop-frame) SMPTE timecode at the frame rate. Timecodes are converted to time using the exact frame rate
+// e.g. 00:10:00:00 at 29.97fps is 600.6s. A decimal comma is accepted in place of a decimal point (e.g. 4,57)
+// and everything following a '#' is a comment. Comment lines preceding the 'taps' may declare the default
+// units, the frame rate and how the 'taps' are grouped into loops e.g.:
+//
+//	# units: ms
+//	# fps: 29.97
+//	# loops: blocks
+//
+// The frame rate is a decimal (e.g. 25 or 29.97) or rational (e.g. 30000/1001) rate, with a 'df' suffix for
+// drop-frame timecode (e.g. 29.97df).
+//
+// The 'taps' on each line are a loop ('loops: lines') or, for 'loops: blocks', each block of consecutive lines
+// is a loop, with the loops separated by blank lines. If not declared, a file with a single 'tap' on every line
+// is read as blocks and any other file is read as lines.
+//
+// Invalid values are skipped and reported as diagnostics with the line and column of the value.
+//
+// An Audacity label file (which is also plain text) is read as an Audacity label file.
+type TXT struct {
+	LoopLabel string               // Audacity loop label (see Audacity)
+	Units     time.Duration        // units of values without a unit suffix (defaults to seconds)
+	Rate      taps2beats.FrameRate // frame rate for timecodes if not declared in the file (required for timecodes)
+	Warn      func(Diagnostic)     // invoked for each diagnostic (if not nil)
+
+	TimeBase *taps2beats.TimeBase // time base for the beats table (defaults to Go durations)
+}
+
+// A warning (or error) for a value in an input file, with the line and column of the value (1-based).
+type Diagnostic struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+type txtValue struct {
+	line   int
+	column int
+	text   string
+}
+
+var (
+	txtHeader   = regexp.MustCompile(`^#\s*(units|fps|loops)\s*[:=]\s*(\S+)\s*$`)
+	txtTimecode = regexp.MustCompile(`^(?:(\d+):)?(\d+):(\d+(?:\.\d*)?)$`)
+	txtFrames   = regexp.MustCompile(`^(\d+):(\d+):(\d+)[:;](\d+)$`)
+	txtUnits    = map[string]time.Duration{
+		"s":            time.Second,
+		"sec":          time.Second,
+		"seconds":      time.Second,
+		"ms":           time.Millisecond,
+		"milliseconds": time.Millisecond,
+		"us":           time.Microsecond,
+		"µs":           time.Microsecond,
+		"microseconds": time.Microsecond,
+	}
+)
+
+// Returns true if the content is blank or only 'taps' (and comments).
+func IsTXT(bytes []byte) bool {
+	_, diagnostics, err := TXT{Rate: taps2beats.FrameRate{Num: 25, Den: 1}}.Parse(bytes)
+
+	return err == nil && len(diagnostics) == 0
+}
+
+func (d Diagnostic) Error() string {
+	if d.Column > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", d.Line, d.Column, d.Message)
+	}
+
+	return fmt.Sprintf("line %d: %s", d.Line, d.Message)
+}
+
+func (d Diagnostic) String() string {
+	return d.Error()
+}
+
+// Reads the 'taps', reporting the diagnostics (if any) to the Warn function.
+func (txt TXT) Read(r io.Reader) (taps2beats.TapSet, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	if IsAudacity(bytes) {
+		return Audacity{LoopLabel: txt.LoopLabel}.parse(bytes)
+	}
+
+	set, diagnostics, err := txt.Parse(bytes)
+	if txt.Warn != nil {
+		for _, d := range diagnostics {
+			txt.Warn(d)
+		}
+	}
+
+	return set, err
+}
+
+// Parses the 'taps' in a plain text file, returning the 'taps' and the diagnostics for the invalid values. Returns
+// an error (as a Diagnostic) for an invalid header.
+func (txt TXT) Parse(bytes []byte) (taps2beats.TapSet, []Diagnostic, error) {
+	units := txt.Units
+	rate := txt.Rate
+	loops := ""
+
+	if units <= 0 {
+		units = time.Second
+	}
+
+	diagnostics := []Diagnostic{}
+	lines := [][]txtValue{}
+	data := false
+
+	for i, line := range strings.Split(string(bytes), "\n") {
+		line = strings.TrimRight(line, "\r")
+		number := i + 1
+		blank := strings.TrimSpace(line) == ""
+
+		if ix := strings.Index(line, "#"); ix >= 0 {
+			if match := txtHeader.FindStringSubmatch(strings.TrimSpace(line)); match != nil && !data {
+				switch strings.ToLower(match[1]) {
+				case "units":
+					if u, ok := txtUnits[strings.ToLower(match[2])]; !ok {
+						return taps2beats.TapSet{}, diagnostics, Diagnostic{number, 0, fmt.Sprintf("invalid units (%v)", match[2])}
+					} else {
+						units = u
+					}
+
+				case "fps":
+					v := strings.Replace(match[2], ",", ".", 1)
+					df := strings.HasSuffix(strings.ToLower(v), "df")
+					if df {
+						v = v[:len(v)-2]
+					}
+
+					if r, err := taps2beats.ParseFrameRate(v, df); err != nil {
+						return taps2beats.TapSet{}, diagnostics, Diagnostic{number, 0, fmt.Sprintf("invalid frame rate (%v)", match[2])}
+					} else {
+						rate = r
+					}
+
+				case "loops":
+					if l := strings.ToLower(match[2]); l != "lines" && l != "blocks" {
+						return taps2beats.TapSet{}, diagnostics, Diagnostic{number, 0, fmt.Sprintf("invalid loops (%v)", match[2])}
+					} else {
+						loops = l
+					}
+				}
+			}
+
+			line = line[:ix]
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if blank {
+				lines = append(lines, nil)
+			}
+
+			continue
+		}
+
+		values := []txtValue{}
+		column := 0
+		start := -1
+		for _, r := range line + " " {
+			column++
+			if unicode.IsSpace(r) {
+				if start >= 0 {
+					values = append(values, txtValue{number, start, string([]rune(line)[start-1 : column-1])})
+					start = -1
+				}
+			} else if start < 0 {
+				start = column
+			}
+		}
+
+		lines = append(lines, values)
+		data = true
+	}
+
+	if loops == "" {
+		loops = "blocks"
+		for _, values := range lines {
+			if len(values) > 1 {
+				loops = "lines"
+			}
+		}
+	}
+
+	set := taps2beats.TapSet{
+		Loops: []taps2beats.Loop{},
+	}
+
+	var loop *taps2beats.Loop
+	for _, values := range lines {
+		if values == nil || loops == "lines" {
+			if loop != nil && len(loop.Taps) > 0 {
+				set.Loops = append(set.Loops, *loop)
+			}
+
+			loop = nil
+		}
+
+		for _, v := range values {
+			t, err := txt.parse(v.text, units, rate)
+			if err != nil {
+				diagnostics = append(diagnostics, Diagnostic{v.line, v.column, err.Error()})
+				continue
+			}
+
+			if loop == nil {
+				loop = &taps2beats.Loop{
+					ID:     strconv.Itoa(len(set.Loops) + 1),
+					Weight: 1.0,
+					Taps:   []taps2beats.Tap{},
+				}
+			}
+
+			loop.Taps = append(loop.Taps, taps2beats.Tap{At: t, Weight: 1.0})
+		}
+	}
+
+	if loop != nil && len(loop.Taps) > 0 {
+		set.Loops = append(set.Loops, *loop)
+	}
+
+	return set, diagnostics, nil
+}
+
+// Parses a single value as a number (in the default units), a Go duration or a timecode.
+func (txt TXT) parse(s string, units time.Duration, rate taps2beats.FrameRate) (time.Duration, error) {
+	v := s
+	if strings.Count(v, ",") == 1 && !strings.Contains(v, ".") {
+		v = strings.Replace(v, ",", ".", 1)
+	}
+
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, fmt.Errorf("invalid value (%s)", s)
+		}
+
+		return time.Duration(math.Round(f * float64(units))), nil
+	}
+
+	if txtFrames.MatchString(v) {
+		if rate.Num <= 0 || rate.Den <= 0 {
+			return 0, fmt.Errorf("timecode requires a frame rate (%s)", s)
+		}
+
+		frame, err := rate.ParseTimecode(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timecode (%s)", s)
+		}
+
+		return rate.Time(frame), nil
+	}
+
+	if match := txtTimecode.FindStringSubmatch(v); match != nil {
+		hh := 0
+		if match[1] != "" {
+			hh, _ = strconv.Atoi(match[1])
+		}
+
+		mm, _ := strconv.Atoi(match[2])
+		ss, _ := strconv.ParseFloat(match[3], 64)
+
+		if (match[1] != "" && mm > 59) || ss >= 60 {
+			return 0, fmt.Errorf("invalid time (%s)", s)
+		}
+
+		t := float64(hh*3600+mm*60) + ss
+
+		return time.Duration(math.Round(t * float64(time.Second))), nil
+	}
+
+	if d, err := time.ParseDuration(v); err == nil {
+		return d, nil
+	}
+
+	return 0, fmt.Errorf("invalid value (%s)", s)
+}
+
+func (txt TXT) Write(w io.Writer, beats taps2beats.Beats) error {
+	tb := taps2beats.TimeBase{Units: taps2beats.UnitDuration}
+	if txt.TimeBase != nil {
+		tb = *txt.TimeBase
+	}
+
+	grid := [][]string{}
+	for i, b := range beats.Beats {
+		row := []string{
+			fmt.Sprintf("%d", i+1),
+			tb.Format(b.At),
+		}
+
+		if len(b.Taps) > 0 {
+			row = append(row, tb.Format(b.Mean))
+			row = append(row, tb.FormatInterval(b.Variance))
+			for _, t := range b.Taps {
+				row = append(row, tb.Format(t))
+			}
+		}
+
+		grid = append(grid, row)
+	}
+
+	columns := 0
+	for _, row := range grid {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	cols := make([]int, columns)
+	for _, row := range grid {
+		for i, v := range row {
+			if len(v) > cols[i] {
+				cols[i] = len(v)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "BPM:    %v\n", beats.BPM)
+	fmt.Fprintf(w, "Offset: %v\n\n", tb.Format(beats.Offset))
+	for _, row := range grid {
+		fmt.Fprintf(w, "%-*s", cols[0], row[0])
+		for i, v := range row[1:] {
+			fmt.Fprintf(w, " %-*s", cols[i+1], v)
+		}
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package tapsio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestReadTXT(t *testing.T) {
+	warnings := []string{}
+	txt := TXT{
+		Warn: func(d Diagnostic) { warnings = append(warnings, d.Error()) },
+	}
+
+	set, err := txt.Read(strings.NewReader("4.57 5.06  5.6\n\n4.5\t5.1 x\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{4.57, 5.06, 5.6}, {4.5, 5.1}}, t)
+
+	if len(warnings) != 1 || warnings[0] != "line 3, column 9: invalid value (x)" {
+		t.Errorf("Incorrect warnings - got:%v", warnings)
+	}
+}
+
+func TestParseTXT(t *testing.T) {
+	text := `# units: ms
+# fps: 25
+
+# loop 1
+4570 5,06s 5600ms  # comment
+1:04.5 00:01:05:12 1:00:00.25
+
+4.57 # no units
+`
+
+	set, diagnostics, err := TXT{}.Parse([]byte(text))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{4.57, 5.06, 5.6}, {64.5, 65.48, 3600.25}, {0.00457}}, t)
+
+	if len(diagnostics) != 0 {
+		t.Errorf("Unexpected diagnostics (%v)", diagnostics)
+	}
+}
+
+func TestParseTXTTimecodes(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected [][]float64
+	}{
+		{"# fps: 29.97\n00:10:00:00 00:00:01:15\n", [][]float64{{600.6, 1.5015}}},
+		{"# fps: 30000/1001\n00:10:00;00 00:01:00;02\n", [][]float64{{599.9994, 60.06}}},
+		{"# fps: 29.97df\n00:10:00:00 00:01:00:02\n", [][]float64{{599.9994, 60.06}}},
+		{"# fps: 25\n00:10:00:00 00:00:01:12\n", [][]float64{{600, 1.48}}},
+	}
+
+	for _, test := range tests {
+		set, diagnostics, err := TXT{}.Parse([]byte(test.text))
+		if err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		}
+
+		if len(diagnostics) != 0 {
+			t.Errorf("Unexpected diagnostics (%v)", diagnostics)
+		}
+
+		compareTaps(set, test.expected, t)
+	}
+
+	// ... drop-frame timecode requires a 29.97 or 59.94 frame rate
+	_, diagnostics, _ := TXT{Rate: taps2beats.FrameRate{Num: 25, Den: 1}}.Parse([]byte("00:00:01;12\n"))
+	if len(diagnostics) != 1 || diagnostics[0].Message != "invalid timecode (00:00:01;12)" {
+		t.Errorf("Incorrect diagnostics - got:%v", diagnostics)
+	}
+}
+
+func TestParseTXTBlocks(t *testing.T) {
+	text := "4.57\n5.06\n\n\n4.5\n# comment\n5.1\n\n5.6\n"
+
+	set, _, err := TXT{}.Parse([]byte(text))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{4.57, 5.06}, {4.5, 5.1}, {5.6}}, t)
+
+	if set.Loops[1].ID != "2" {
+		t.Errorf("Incorrect loop ID - expected:%v, got:%v", "2", set.Loops[1].ID)
+	}
+
+	set, _, err = TXT{}.Parse([]byte("# loops: lines\n4.57\n5.06\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{4.57}, {5.06}}, t)
+}
+
+func TestParseTXTDiagnostics(t *testing.T) {
+	text := "4.57 5.06 4..5\n\t00:00:01:12 1:75.0 5.6\n"
+
+	set, diagnostics, err := TXT{}.Parse([]byte(text))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{4.57, 5.06}, {5.6}}, t)
+
+	expected := []Diagnostic{
+		{Line: 1, Column: 11, Message: "invalid value (4..5)"},
+		{Line: 2, Column: 2, Message: "timecode requires a frame rate (00:00:01:12)"},
+		{Line: 2, Column: 14, Message: "invalid time (1:75.0)"},
+	}
+
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("Incorrect diagnostics - expected:%v, got:%v", expected, diagnostics)
+	}
+
+	for i := range expected {
+		if diagnostics[i] != expected[i] {
+			t.Errorf("Incorrect diagnostic %d - expected:%v, got:%v", i+1, expected[i], diagnostics[i])
+		}
+	}
+}
+
+func TestParseTXTInvalidHeader(t *testing.T) {
+	_, _, err := TXT{}.Parse([]byte("# comment\n# units: furlongs\n4.57\n"))
+	if err == nil {
+		t.Fatalf("Expected error, got:%v", err)
+	}
+
+	if err.Error() != "line 2: invalid units (furlongs)" {
+		t.Errorf("Incorrect error - expected:%v, got:%v", "line 2: invalid units (furlongs)", err)
+	}
+}
+
+func TestIsTXT(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected bool
+	}{
+		{"", true},
+		{"4.57 5.06\n4.5 5.1\n", true},
+		{"# taps\n4,57 1:05.5 570ms\n", true},
+		{"<sv></sv>", false},
+		{"{\"taps\": []}", false},
+	}
+
+	for _, test := range tests {
+		if IsTXT([]byte(test.text)) != test.expected {
+			t.Errorf("Incorrect IsTXT for %q - expected:%v, got:%v", test.text, test.expected, !test.expected)
+		}
+	}
+}
+
+func TestReadTXTAudacity(t *testing.T) {
+	set, err := TXT{}.Read(strings.NewReader("4.5\t4.5\tA\n5.0\t5.0\tB\n4.51\t4.51\tA\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	compareTaps(set, [][]float64{{4.5, 5.0}, {4.51}}, t)
+}
+
+func TestWriteTXT(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:    120,
+		Offset: 500 * time.Millisecond,
+		Beats: []taps2beats.Beat{
+			{At: 500 * time.Millisecond, Mean: 510 * time.Millisecond, Variance: time.Millisecond, Taps: []time.Duration{500 * time.Millisecond, 520 * time.Millisecond}},
+			{At: 1 * time.Second},
+		},
+	}
+
+	var b bytes.Buffer
+	if err := (TXT{}).Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"BPM:    120",
+		"Offset: 500ms",
+		"",
+		"1 500ms 510ms 1ms 500ms 520ms",
+		"2 1s",
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteTXTWithTimeBase(t *testing.T) {
+	beats := taps2beats.Beats{
+		BPM:    120,
+		Offset: 500 * time.Millisecond,
+		Beats: []taps2beats.Beat{
+			{At: 500 * time.Millisecond, Mean: 510 * time.Millisecond, Variance: time.Millisecond, Taps: []time.Duration{500 * time.Millisecond, 520 * time.Millisecond}},
+			{At: 1 * time.Second},
+		},
+	}
+
+	tb := taps2beats.TimeBase{Units: taps2beats.UnitSMPTE, Rate: taps2beats.FrameRate{Num: 25, Den: 1}}
+
+	var b bytes.Buffer
+	if err := (TXT{TimeBase: &tb}).Write(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"BPM:    120",
+		"Offset: 00:00:00:13",
+		"",
+		"1 00:00:00:13 00:00:00:13 0 00:00:00:13 00:00:00:13",
+		"2 00:00:01:00",
+	}
+
+	compare(b.String(), expected, t)
+}
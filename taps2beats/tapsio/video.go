@@ -0,0 +1,64 @@
+package tapsio
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/video"
+)
+
+// Selects the video timeline marker format.
+type VideoFormat int
+
+const (
+	FCPXML     VideoFormat = iota // Final Cut Pro XML project with a marker at every bar (or beat)
+	EDL                           // CMX3600 EDL with a locator at every bar (or beat)
+	FFMetadata                    // FFmpeg FFMETADATA file with a chapter for every bar
+)
+
+// Writes beats as video timeline markers (see video.Export), reporting the markers that are before the start
+// of the timeline (and so moved to frame 0) to the Warn function and the markers moved to the nearest frame to
+// the Shift function.
+type Video struct {
+	Export video.Export       // frame rate, bar and marker settings
+	Format VideoFormat        // marker format
+	Warn   func(video.Marker) // invoked for each marker before the start of the timeline (if not nil)
+	Shift  func(video.Marker) // invoked for each marker that is not exactly on a frame (if not nil)
+}
+
+func (v Video) Write(w io.Writer, beats taps2beats.Beats) error {
+	export := v.Export
+	if v.Format == FFMetadata {
+		export.EveryBeat = false
+	}
+
+	markers, err := export.Markers(beats)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range markers {
+		if export.Rate.Frame(m.At) < 0 {
+			if v.Warn != nil {
+				v.Warn(m)
+			}
+		} else if m.Shift != 0 && v.Shift != nil {
+			v.Shift(m)
+		}
+	}
+
+	switch v.Format {
+	case FCPXML:
+		return export.WriteFCPXML(w, beats)
+
+	case EDL:
+		return export.WriteEDL(w, beats)
+
+	case FFMetadata:
+		return export.WriteFFMetadata(w, beats)
+
+	default:
+		return fmt.Errorf("invalid video format (%v)", v.Format)
+	}
+}
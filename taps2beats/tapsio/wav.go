@@ -0,0 +1,34 @@
+package tapsio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+	"github.com/transcriptaze/taps2beats/taps2beats/audio"
+)
+
+// Reads the onsets in a PCM (8, 16, 24 or 32 bit) or floating point WAV file as 'taps', using the default
+// spectral flux onset detector.
+type WAV struct {
+}
+
+// Returns true if the content is a RIFF WAVE file.
+func IsWAV(b []byte) bool {
+	return len(b) > 12 && bytes.HasPrefix(b, []byte("RIFF")) && string(b[8:12]) == "WAVE"
+}
+
+func (x WAV) Read(r io.Reader) (taps2beats.TapSet, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	wav, err := audio.DecodeWAV(b)
+	if err != nil {
+		return taps2beats.TapSet{}, err
+	}
+
+	return taps2beats.NewTapSet(audio.Taps(wav)), nil
+}
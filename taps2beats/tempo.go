@@ -0,0 +1,69 @@
+package taps2beats
+
+import (
+	"fmt"
+)
+
+// Constrains the tempo used to quantize and interpolate beats e.g. when the tempo is already known from
+// a click track or DAW session and only the phase of the beats is uncertain. A zero Min or Max leaves the
+// lower or upper bound unconstrained and a Tempo with Min equal to Max fixes the tempo exactly.
+type Tempo struct {
+	Min float64 // minimum BPM
+	Max float64 // maximum BPM
+}
+
+// Returns a Tempo that fixes the BPM to the supplied value.
+func FixedTempo(bpm float64) Tempo {
+	return Tempo{
+		Min: bpm,
+		Max: bpm,
+	}
+}
+
+// Returns true if the tempo constrains the BPM to a single value.
+func (tempo Tempo) IsFixed() bool {
+	return tempo.Min > 0 && tempo.Min == tempo.Max
+}
+
+// Returns true if the tempo constrains the BPM at all.
+func (tempo Tempo) IsConstrained() bool {
+	return tempo.Min > 0 || tempo.Max > 0
+}
+
+// Implementation of the Stringer interface.
+func (tempo Tempo) String() string {
+	switch {
+	case tempo.IsFixed():
+		return fmt.Sprintf("%v", tempo.Min)
+
+	case tempo.Min > 0 && tempo.Max > 0:
+		return fmt.Sprintf("%v:%v", tempo.Min, tempo.Max)
+
+	case tempo.Min > 0:
+		return fmt.Sprintf("%v:", tempo.Min)
+
+	case tempo.Max > 0:
+		return fmt.Sprintf(":%v", tempo.Max)
+
+	default:
+		return "*"
+	}
+}
+
+// Returns the beat period (in seconds) for a fixed tempo.
+func (tempo Tempo) period() float64 {
+	return 60.0 / tempo.Min
+}
+
+// Clamps a beat period (in seconds) to the BPM range of the tempo.
+func (tempo Tempo) constrain(m float64) float64 {
+	if tempo.Max > 0 && m < 60.0/tempo.Max {
+		return 60.0 / tempo.Max
+	}
+
+	if tempo.Min > 0 && m > 60.0/tempo.Min {
+		return 60.0 / tempo.Min
+	}
+
+	return m
+}
@@ -0,0 +1,139 @@
+package taps2beats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestQuantizeWithFixedTempo(t *testing.T) {
+	beats := Beats{
+		BPM:    123,
+		Offset: 117 * time.Millisecond,
+		Beats:  []Beat{clone(beats[8]), clone(beats[9]), clone(beats[10]), clone(beats[11]), clone(beats[12]), clone(beats[13]), clone(beats[14]), clone(beats[15])},
+	}
+
+	if err := beats.QuantizeWithTempo(FixedTempo(120)); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if beats.BPM != 120 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%v", 120, beats.BPM)
+	}
+
+	for i := 1; i < len(beats.Beats); i++ {
+		if dt := beats.Beats[i].At - beats.Beats[i-1].At; math.Abs(dt.Seconds()-0.5) > 0.000001 {
+			t.Errorf("Incorrect beat interval - expected:%v, got:%v", 500*time.Millisecond, dt)
+		}
+	}
+
+	// ... offset-only fit should centre the beats on the taps
+	sum := 0.0
+	for _, b := range beats.Beats {
+		sum += b.At.Seconds() - b.Mean.Seconds()
+	}
+
+	if math.Abs(sum) > 0.000001 {
+		t.Errorf("Offset not fitted - expected zero mean residual, got:%.6f", sum)
+	}
+}
+
+func TestQuantizeWithFixedTempoAndOneBeat(t *testing.T) {
+	beats := Beats{
+		Beats: []Beat{clone(beats[8])},
+	}
+
+	if err := beats.QuantizeWithTempo(FixedTempo(120)); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if beats.BPM != 120 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%v", 120, beats.BPM)
+	}
+
+	if expected := Seconds(0.023694381); math.Abs(beats.Offset.Seconds()-expected.Seconds()) > 0.0011 {
+		t.Errorf("Incorrect offset - expected:%v, got:%v", expected, beats.Offset)
+	}
+}
+
+func TestQuantizeWithTempoRange(t *testing.T) {
+	beats := Beats{
+		BPM:    123,
+		Offset: 117 * time.Millisecond,
+		Beats:  []Beat{clone(beats[8]), clone(beats[9]), clone(beats[10]), clone(beats[11]), clone(beats[12]), clone(beats[13]), clone(beats[14]), clone(beats[15])},
+	}
+
+	if err := beats.QuantizeWithTempo(Tempo{Min: 100, Max: 110}); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if beats.BPM != 110 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%v", 110, beats.BPM)
+	}
+}
+
+func TestQuantizeWithUnconstrainedTempo(t *testing.T) {
+	beats := Beats{
+		BPM:    123,
+		Offset: 117 * time.Millisecond,
+		Beats:  []Beat{clone(beats[8]), clone(beats[9]), clone(beats[10]), clone(beats[11]), clone(beats[12]), clone(beats[13]), clone(beats[14]), clone(beats[15])},
+	}
+
+	if err := beats.QuantizeWithTempo(Tempo{Min: 100, Max: 130}); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if beats.BPM != 114 {
+		t.Errorf("Incorrect BPM - expected:%v, got:%v", 114, beats.BPM)
+	}
+}
+
+func TestInterpolateWithFixedTempoAndOneBeat(t *testing.T) {
+	beats := Beats{
+		Beats: []Beat{clone(beats[8])},
+	}
+
+	if err := beats.InterpolateWithTempo(3*time.Second, 6*time.Second, FixedTempo(120)); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := seconds(3.023694381, 3.523694381, 4.023694381, 4.523694381, 5.023694381, 5.523694381)
+	if len(beats.Beats) != len(expected) {
+		t.Fatalf("Invalid result\n   expected: %v beats\n   got:      %v beats", len(expected), len(beats.Beats))
+	}
+
+	for i, v := range expected {
+		if math.Abs(beats.Beats[i].At.Seconds()-v.Seconds()) > 0.000001 {
+			t.Errorf("Invalid beat %d - expected:%v, got:%v", i+1, v, beats.Beats[i].At)
+		}
+	}
+
+	if len(beats.Beats[3].Taps) != len(bins[0]) {
+		t.Errorf("Tapped beat not retained - expected:%v taps, got:%v", len(bins[0]), len(beats.Beats[3].Taps))
+	}
+}
+
+func TestReindexWithTempoRange(t *testing.T) {
+	// ... 4 alternate beats i.e. 57 BPM without a tempo constraint
+	p := []Beat{clone(beats[8]), clone(beats[10]), clone(beats[12]), clone(beats[14])}
+
+	if err := reindex(p, Tempo{Max: 80}); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	for i, x := range []int{1, 2, 3, 4} {
+		if p[i].beat != x {
+			t.Errorf("Invalid beat [%d] - expected:%v, got:%v", i+1, x, p[i].beat)
+		}
+	}
+
+	if err := reindex(p, Tempo{Min: 100, Max: 140}); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	for i, x := range []int{1, 3, 5, 7} {
+		if p[i].beat != x {
+			t.Errorf("Invalid beat [%d] - expected:%v, got:%v", i+1, x, p[i].beat)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package taps2beats
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Units of a time base i.e. of the source of a set of 'taps' or of the times in the output.
+type Units int
+
+const (
+	UnitSeconds Units = iota
+	UnitSamples
+	UnitTicks
+	UnitFrames
+	UnitSMPTE
+	UnitDuration
+)
+
+// The time base of the source of a set of 'taps' (i.e. seconds, audio samples at a sample rate or MIDI
+// ticks at a fixed tempo) or of the times in the output (which may also be video frames, SMPTE timecode
+// or Go durations).
+//
+// Times are converted to the nearest sample, tick or frame, with halves rounded away from zero. Seconds
+// are exact (to the nanosecond) and SMPTE timecode is the timecode of the nearest frame.
+type TimeBase struct {
+	Units      Units
+	SampleRate int           // samples per second (UnitSamples only)
+	PPQ        int           // ticks per quarter note (UnitTicks only)
+	Tempo      time.Duration // duration of a quarter note (UnitTicks only)
+	Rate       FrameRate     // frame rate and drop-frame timecode setting (UnitFrames and UnitSMPTE only, defaults to 25)
+}
+
+var units = map[Units]string{
+	UnitSeconds:  "seconds",
+	UnitSamples:  "samples",
+	UnitTicks:    "ticks",
+	UnitFrames:   "frames",
+	UnitSMPTE:    "smpte",
+	UnitDuration: "duration",
+}
+
+// Parses a time base specification i.e. one of:
+//
+//	duration               Go durations e.g. 4.524s (the default for text output)
+//	seconds                seconds e.g. 4.524
+//	samples[:<rate>]       audio samples at the sample rate (defaults to 44100)
+//	frames[:<fps>]         video frames at the frame rate (defaults to 25)
+//	smpte[:<fps>[df]]      hh:mm:ss:ff timecode at the frame rate (hh:mm:ss;ff for drop-frame)
+//	ticks[:<ppq>[@<bpm>]]  MIDI ticks at the PPQ and tempo (defaults to 480 @ 120 BPM)
+//
+// The frame rate is either a rational (e.g. 30000/1001) or decimal (e.g. 25 or 29.97) number of frames
+// per second, with the NTSC rates (e.g. 29.97) converted to the exact rational rate (e.g. 30000/1001).
+func ParseTimeBase(s string) (TimeBase, error) {
+	name, arg := strings.TrimSpace(s), ""
+	if ix := strings.Index(name, ":"); ix >= 0 {
+		name, arg = name[:ix], name[ix+1:]
+	}
+
+	switch strings.ToLower(name) {
+	case "duration":
+		if arg == "" {
+			return TimeBase{Units: UnitDuration}, nil
+		}
+
+	case "seconds", "s":
+		if arg == "" {
+			return TimeBase{Units: UnitSeconds}, nil
+		}
+
+	case "samples":
+		rate := 44100
+		if arg != "" {
+			if v, err := strconv.Atoi(arg); err != nil || v <= 0 {
+				return TimeBase{}, fmt.Errorf("invalid sample rate (%v)", arg)
+			} else {
+				rate = v
+			}
+		}
+
+		return TimeBase{Units: UnitSamples, SampleRate: rate}, nil
+
+	case "frames", "smpte":
+		tb := TimeBase{Units: UnitFrames}
+		dropFrame := false
+		if strings.ToLower(name) == "smpte" {
+			tb.Units = UnitSMPTE
+			if strings.HasSuffix(strings.ToLower(arg), "df") {
+				dropFrame = true
+				arg = arg[:len(arg)-2]
+			}
+		}
+
+		if arg == "" {
+			arg = "25"
+		}
+
+		rate, err := ParseFrameRate(arg, dropFrame)
+		if err != nil {
+			return TimeBase{}, err
+		}
+
+		tb.Rate = rate
+
+		return tb, nil
+
+	case "ticks":
+		ppq := 480
+		bpm := 120.0
+		if arg != "" {
+			tokens := strings.SplitN(arg, "@", 2)
+			if v, err := strconv.Atoi(tokens[0]); err != nil || v <= 0 {
+				return TimeBase{}, fmt.Errorf("invalid PPQ (%v)", tokens[0])
+			} else {
+				ppq = v
+			}
+
+			if len(tokens) > 1 {
+				if v, err := strconv.ParseFloat(tokens[1], 64); err != nil || v <= 0 {
+					return TimeBase{}, fmt.Errorf("invalid tempo (%v)", tokens[1])
+				} else {
+					bpm = v
+				}
+			}
+		}
+
+		return TimeBase{Units: UnitTicks, PPQ: ppq, Tempo: Seconds(60.0 / bpm)}, nil
+	}
+
+	return TimeBase{}, fmt.Errorf("invalid time base (%v)", s)
+}
+
+// Converts a value in the time base units to a duration, rounded to the nearest nanosecond.
+func (tb TimeBase) Duration(v int64) time.Duration {
+	switch tb.Units {
+	case UnitSamples:
+		return time.Duration(round(v*int64(time.Second), int64(tb.SampleRate)))
+
+	case UnitTicks:
+		return time.Duration(round(v*int64(tb.Tempo), int64(tb.PPQ)))
+
+	case UnitFrames, UnitSMPTE:
+		return tb.rate().Time(v)
+
+	default:
+		return time.Duration(v) * time.Second
+	}
+}
+
+// Converts a duration to the time base units, rounded to the nearest unit.
+func (tb TimeBase) Value(t time.Duration) int64 {
+	switch tb.Units {
+	case UnitSamples:
+		return round(int64(t)*int64(tb.SampleRate), int64(time.Second))
+
+	case UnitTicks:
+		return round(int64(t)*int64(tb.PPQ), int64(tb.Tempo))
+
+	case UnitFrames, UnitSMPTE:
+		return tb.rate().Frame(t)
+
+	default:
+		return round(int64(t), int64(time.Second))
+	}
+}
+
+// Formats a time in the time base units.
+func (tb TimeBase) Format(t time.Duration) string {
+	switch tb.Units {
+	case UnitSeconds:
+		sign := ""
+		if t < 0 {
+			sign, t = "-", -t
+		}
+
+		s := fmt.Sprintf("%s%d.%09d", sign, t/time.Second, t%time.Second)
+
+		return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+
+	case UnitSamples, UnitTicks, UnitFrames:
+		return strconv.FormatInt(tb.Value(t), 10)
+
+	case UnitSMPTE:
+		return tb.rate().Timecode(tb.Value(t))
+
+	default:
+		return t.String()
+	}
+}
+
+// Formats an interval (e.g. a variance or residual) in the time base units. Identical to Format except
+// that intervals are formatted as a number of frames for SMPTE timecode.
+func (tb TimeBase) FormatInterval(dt time.Duration) string {
+	if tb.Units == UnitSMPTE {
+		return strconv.FormatInt(tb.Value(dt), 10)
+	}
+
+	return tb.Format(dt)
+}
+
+// Parses a time formatted by Format.
+func (tb TimeBase) Parse(s string) (time.Duration, error) {
+	switch tb.Units {
+	case UnitSeconds:
+		if v, err := strconv.ParseFloat(s, 64); err != nil {
+			return 0, err
+		} else {
+			return time.Duration(math.Round(v * float64(time.Second))), nil
+		}
+
+	case UnitSamples, UnitTicks, UnitFrames:
+		if v, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return 0, err
+		} else {
+			return tb.Duration(v), nil
+		}
+
+	case UnitSMPTE:
+		if frame, err := tb.rate().ParseTimecode(s); err != nil {
+			return 0, err
+		} else {
+			return tb.Duration(frame), nil
+		}
+
+	default:
+		return time.ParseDuration(s)
+	}
+}
+
+// Returns the time base specification (as accepted by ParseTimeBase).
+func (tb TimeBase) String() string {
+	switch tb.Units {
+	case UnitSamples:
+		return fmt.Sprintf("samples:%d", tb.SampleRate)
+
+	case UnitTicks:
+		return fmt.Sprintf("ticks:%d@%v", tb.PPQ, strconv.FormatFloat(60.0/tb.Tempo.Seconds(), 'f', -1, 64))
+
+	case UnitFrames, UnitSMPTE:
+		r := tb.rate()
+		rate := fmt.Sprintf("%d", r.Num)
+		if r.Den != 1 {
+			rate = fmt.Sprintf("%d/%d", r.Num, r.Den)
+		}
+
+		if r.DropFrame {
+			rate += "df"
+		}
+
+		return units[tb.Units] + ":" + rate
+
+	default:
+		return units[tb.Units]
+	}
+}
+
+// Returns the frame rate, defaulting to 25fps if the frame rate is not set (or is invalid).
+func (tb TimeBase) rate() FrameRate {
+	if !tb.Rate.valid() {
+		return FrameRate{Num: 25, Den: 1}
+	}
+
+	return tb.Rate
+}
+
+func round(v, d int64) int64 {
+	if v < 0 {
+		return -round(-v, d)
+	}
+
+	return (v + d/2) / d
+}
@@ -0,0 +1,156 @@
+package taps2beats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeBase(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected TimeBase
+	}{
+		{"duration", TimeBase{Units: UnitDuration}},
+		{"seconds", TimeBase{Units: UnitSeconds}},
+		{"samples", TimeBase{Units: UnitSamples, SampleRate: 44100}},
+		{"samples:48000", TimeBase{Units: UnitSamples, SampleRate: 48000}},
+		{"frames", TimeBase{Units: UnitFrames, Rate: FrameRate{Num: 25, Den: 1}}},
+		{"frames:29.97", TimeBase{Units: UnitFrames, Rate: FrameRate{Num: 30000, Den: 1001}}},
+		{"smpte:30000/1001df", TimeBase{Units: UnitSMPTE, Rate: FrameRate{Num: 30000, Den: 1001, DropFrame: true}}},
+		{"ticks", TimeBase{Units: UnitTicks, PPQ: 480, Tempo: 500 * time.Millisecond}},
+		{"ticks:960@100", TimeBase{Units: UnitTicks, PPQ: 960, Tempo: 600 * time.Millisecond}},
+	}
+
+	for _, test := range tests {
+		tb, err := ParseTimeBase(test.spec)
+		if err != nil {
+			t.Fatalf("Unexpected error for %v (%v)", test.spec, err)
+		}
+
+		if tb != test.expected {
+			t.Errorf("Incorrect time base for %v - expected:%+v, got:%+v", test.spec, test.expected, tb)
+		}
+
+		if v, err := ParseTimeBase(tb.String()); err != nil || v != tb {
+			t.Errorf("Incorrect time base for %v - expected:%+v, got:%+v", tb.String(), tb, v)
+		}
+	}
+
+	for _, spec := range []string{"", "minutes", "seconds:10", "samples:0", "frames:27.5", "smpte:25df", "ticks:480@0"} {
+		if _, err := ParseTimeBase(spec); err == nil {
+			t.Errorf("Expected error for invalid time base %q", spec)
+		}
+	}
+}
+
+func TestTimeBaseFormat(t *testing.T) {
+	at := 4524600 * time.Microsecond
+
+	tests := []struct {
+		spec     string
+		expected string
+		interval string
+	}{
+		{"duration", "4.5246s", "4.5246s"},
+		{"seconds", "4.5246", "4.5246"},
+		{"samples:48000", "217181", "217181"},
+		{"frames:25", "113", "113"},
+		{"smpte:25", "00:00:04:13", "113"},
+		{"ticks:480@120", "4344", "4344"},
+	}
+
+	for _, test := range tests {
+		tb, _ := ParseTimeBase(test.spec)
+
+		if s := tb.Format(at); s != test.expected {
+			t.Errorf("Incorrect %v time - expected:%v, got:%v", test.spec, test.expected, s)
+		}
+
+		if s := tb.FormatInterval(at); s != test.interval {
+			t.Errorf("Incorrect %v interval - expected:%v, got:%v", test.spec, test.interval, s)
+		}
+	}
+}
+
+func TestTimeBaseRounding(t *testing.T) {
+	tb := TimeBase{Units: UnitFrames, Rate: FrameRate{Num: 25, Den: 1}}
+
+	tests := []struct {
+		at       time.Duration
+		expected int64
+	}{
+		{19 * time.Millisecond, 0},
+		{20 * time.Millisecond, 1},
+		{-20 * time.Millisecond, -1},
+		{59 * time.Millisecond, 1},
+		{60 * time.Millisecond, 2},
+	}
+
+	for _, test := range tests {
+		if v := tb.Value(test.at); v != test.expected {
+			t.Errorf("Incorrect frame for %v - expected:%v, got:%v", test.at, test.expected, v)
+		}
+	}
+}
+
+func TestTimeBaseDropFrame(t *testing.T) {
+	tb, _ := ParseTimeBase("smpte:29.97df")
+
+	tests := []struct {
+		frame    int64
+		expected string
+	}{
+		{0, "00:00:00;00"},
+		{1799, "00:00:59;29"},
+		{1800, "00:01:00;02"},
+		{17982, "00:10:00;00"},
+	}
+
+	for _, test := range tests {
+		at := tb.Duration(test.frame)
+		if s := tb.Format(at); s != test.expected {
+			t.Errorf("Incorrect timecode for frame %v - expected:%v, got:%v", test.frame, test.expected, s)
+		}
+
+		if v, err := tb.Parse(test.expected); err != nil || tb.Value(v) != test.frame {
+			t.Errorf("Incorrect frame for %v - expected:%v, got:%v", test.expected, test.frame, tb.Value(v))
+		}
+	}
+}
+
+func TestTimeBaseWithoutFrameRate(t *testing.T) {
+	tb := TimeBase{Units: UnitSMPTE}
+
+	if s := tb.Format(90061 * 40 * time.Millisecond); s != "01:00:02:11" {
+		t.Errorf("Incorrect timecode - expected:%v, got:%v", "01:00:02:11", s)
+	}
+
+	if s := tb.String(); s != "smpte:25" {
+		t.Errorf("Incorrect time base - expected:%v, got:%v", "smpte:25", s)
+	}
+
+	beats := Beats{BPM: 120, TimeBase: &tb, Beats: []Beat{{At: 480 * time.Millisecond}}}
+	if s := beats.String(); !strings.Contains(s, "00:00:00:12") {
+		t.Errorf("Incorrect beats - expected:%v, got:%q", "00:00:00:12", s)
+	}
+}
+
+func TestBeatsStringWithTimeBase(t *testing.T) {
+	tb := TimeBase{Units: UnitSamples, SampleRate: 1000}
+	beats := Beats{
+		BPM:      120,
+		Offset:   250 * time.Millisecond,
+		TimeBase: &tb,
+		Beats: []Beat{
+			{At: 250 * time.Millisecond},
+			{At: 750 * time.Millisecond, Mean: 751 * time.Millisecond, Variance: time.Millisecond, Taps: []time.Duration{740 * time.Millisecond, 762 * time.Millisecond}},
+		},
+	}
+
+	expected := "BPM:    120\nOffset: 250\n\n1   250\n2   750 751 1   740 762\n"
+
+	if s := beats.String(); s != expected {
+		t.Errorf("Incorrect beats\n   expected:%q\n   got:     %q", expected, s)
+	}
+}
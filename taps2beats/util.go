@@ -4,8 +4,9 @@ import (
 	"time"
 )
 
-// Utility function to convert an array of 'taps' in seconds to
-// the array of 'taps' as Durations used by Taps2Beats.
+// Utility function to convert an array of 'taps' in seconds to an
+// array of 'taps' as Durations e.g. for NewTapSet (NewTapSetFromFloats
+// creates a TapSet for Taps2Beats directly from the 'taps' in seconds).
 func Floats2Seconds(floats [][]float64) [][]time.Duration {
 	l := [][]time.Duration{}
 
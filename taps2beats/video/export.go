@@ -0,0 +1,337 @@
+// Package video exports beats as video editor timeline markers i.e. Final Cut Pro XML markers, CMX3600
+// EDL locators and FFmpeg FFMETADATA chapters, with the beat times converted to frames and timecode.
+package video
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+// Settings for exporting a set of beats as video timeline markers.
+//
+// The markers are placed on the bar lines (or every beat) of the beat grid, with the bars numbered from
+// the first bar line (beats before the first bar line are in bar 0) and bar lines for missing downbeats
+// interpolated from the adjacent beats. Chapters are always one per bar. Marker names are formatted from
+// the Name template, with {bar} replaced by the bar number, {beat} by the beat in the bar and {n} by the
+// marker number.
+type Export struct {
+	Rate        taps2beats.FrameRate // frame rate and drop-frame timecode setting
+	BeatsPerBar int                  // beats per bar
+	Downbeat    int                  // index of the first downbeat in the list of beats
+	EveryBeat   bool                 // places a marker on every beat rather than on every bar line
+	Name        string               // marker name template (defaults to 'Bar {bar}' for bars and '{bar}.{beat}' for beats)
+	Title       string               // project (or EDL) title
+}
+
+// A timeline marker, with the time of the bar (or beat), the nearest frame and the shift from rounding
+// the time to the frame.
+type Marker struct {
+	At    time.Duration
+	Frame int64
+	Shift time.Duration
+	Name  string
+}
+
+// Returns the default export settings i.e. 25fps, 4/4 time and a marker at every bar line.
+func NewExport() Export {
+	return Export{
+		Rate:        taps2beats.FrameRate{Num: 25, Den: 1},
+		BeatsPerBar: 4,
+		Title:       "taps2beats",
+	}
+}
+
+// Returns the timeline markers for the beats, converted to the nearest frame. A marker before the start of
+// the timeline is moved to frame 0 (with the Shift to frame 0).
+func (x Export) Markers(beats taps2beats.Beats) ([]Marker, error) {
+	return x.markers(beats, x.EveryBeat)
+}
+
+// Writes the markers as a Final Cut Pro XML (FCPXML 1.8) project with the markers on a gap clip that
+// spans the beats, for importing into Final Cut Pro (or DaVinci Resolve).
+func (x Export) WriteFCPXML(w io.Writer, beats taps2beats.Beats) error {
+	markers, err := x.Markers(beats)
+	if err != nil {
+		return err
+	}
+
+	g, err := x.grid(beats)
+	if err != nil {
+		return err
+	}
+
+	// ... gap clip extends to one beat after the last beat
+	duration, _ := x.frame(g.At(g.Positions[len(g.Positions)-1] + 1))
+	if last := markers[len(markers)-1].Frame; duration <= last {
+		duration = last + 1
+	}
+
+	type marker struct {
+		Start    string `xml:"start,attr"`
+		Duration string `xml:"duration,attr"`
+		Value    string `xml:"value,attr"`
+	}
+
+	type gap struct {
+		Name     string   `xml:"name,attr"`
+		Offset   string   `xml:"offset,attr"`
+		Duration string   `xml:"duration,attr"`
+		Start    string   `xml:"start,attr"`
+		Markers  []marker `xml:"marker"`
+	}
+
+	type format struct {
+		ID            string `xml:"id,attr"`
+		FrameDuration string `xml:"frameDuration,attr"`
+		Width         int    `xml:"width,attr"`
+		Height        int    `xml:"height,attr"`
+	}
+
+	type fcpxml struct {
+		XMLName   xml.Name `xml:"fcpxml"`
+		Version   string   `xml:"version,attr"`
+		Resources struct {
+			Format format `xml:"format"`
+		} `xml:"resources"`
+		Library struct {
+			Event struct {
+				Name    string `xml:"name,attr"`
+				Project struct {
+					Name     string `xml:"name,attr"`
+					Sequence struct {
+						Format   string `xml:"format,attr"`
+						Duration string `xml:"duration,attr"`
+						TCStart  string `xml:"tcStart,attr"`
+						TCFormat string `xml:"tcFormat,attr"`
+						Spine    struct {
+							Gap gap `xml:"gap"`
+						} `xml:"spine"`
+					} `xml:"sequence"`
+				} `xml:"project"`
+			} `xml:"event"`
+		} `xml:"library"`
+	}
+
+	doc := fcpxml{Version: "1.8"}
+	doc.Resources.Format = format{
+		ID:            "r1",
+		FrameDuration: rational(x.Rate, 1),
+		Width:         1920,
+		Height:        1080,
+	}
+
+	tcFormat := "NDF"
+	if x.Rate.DropFrame {
+		tcFormat = "DF"
+	}
+
+	doc.Library.Event.Name = x.Title
+	doc.Library.Event.Project.Name = x.Title
+	doc.Library.Event.Project.Sequence.Format = "r1"
+	doc.Library.Event.Project.Sequence.Duration = rational(x.Rate, duration)
+	doc.Library.Event.Project.Sequence.TCStart = "0s"
+	doc.Library.Event.Project.Sequence.TCFormat = tcFormat
+
+	clip := gap{
+		Name:     "Gap",
+		Offset:   "0s",
+		Duration: rational(x.Rate, duration),
+		Start:    "0s",
+	}
+
+	for _, m := range markers {
+		clip.Markers = append(clip.Markers, marker{
+			Start:    rational(x.Rate, m.Frame),
+			Duration: rational(x.Rate, 1),
+			Value:    m.Name,
+		})
+	}
+
+	doc.Library.Event.Project.Sequence.Spine.Gap = clip
+
+	bytes, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintln(w, "<!DOCTYPE fcpxml>")
+	w.Write(bytes)
+	fmt.Fprintln(w)
+
+	return nil
+}
+
+// Writes the markers as locators in a CMX3600 EDL, with a single black event spanning the markers.
+func (x Export) WriteEDL(w io.Writer, beats taps2beats.Beats) error {
+	markers, err := x.Markers(beats)
+	if err != nil {
+		return err
+	}
+
+	end := x.Rate.Timecode(markers[len(markers)-1].Frame + 1)
+	start := x.Rate.Timecode(0)
+
+	fcm := "NON-DROP FRAME"
+	if x.Rate.DropFrame {
+		fcm = "DROP FRAME"
+	}
+
+	fmt.Fprintf(w, "TITLE: %s\n", x.Title)
+	fmt.Fprintf(w, "FCM: %s\n", fcm)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "001  BL       V     C        %s %s %s %s\n", start, end, start, end)
+
+	for _, m := range markers {
+		fmt.Fprintf(w, "* LOC: %s YELLOW  %s\n", x.Rate.Timecode(m.Frame), m.Name)
+	}
+
+	return nil
+}
+
+// Writes a chapter for every bar as an FFmpeg FFMETADATA file, with the chapter start and end in frames
+// (i.e. the chapter timebase is the frame duration), for adding to a video with e.g.
+// ffmpeg -i video.mp4 -i chapters.txt -map_metadata 1 -codec copy output.mp4.
+func (x Export) WriteFFMetadata(w io.Writer, beats taps2beats.Beats) error {
+	markers, err := x.markers(beats, false)
+	if err != nil {
+		return err
+	}
+
+	g, err := x.grid(beats)
+	if err != nil {
+		return err
+	}
+
+	bars := g.Bars()
+	end, _ := x.frame(g.At(bars[len(bars)-1] + g.BeatsPerBar))
+
+	escape := strings.NewReplacer("\\", "\\\\", "=", "\\=", ";", "\\;", "#", "\\#", "\n", "\\\n")
+
+	fmt.Fprintln(w, ";FFMETADATA1")
+	fmt.Fprintf(w, "title=%s\n", escape.Replace(x.Title))
+
+	for i, m := range markers {
+		next := end
+		if i+1 < len(markers) {
+			next = markers[i+1].Frame
+		}
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "[CHAPTER]")
+		fmt.Fprintf(w, "TIMEBASE=%d/%d\n", x.Rate.Den, x.Rate.Num)
+		fmt.Fprintf(w, "START=%d\n", m.Frame)
+		fmt.Fprintf(w, "END=%d\n", next)
+		fmt.Fprintf(w, "title=%s\n", escape.Replace(m.Name))
+	}
+
+	return nil
+}
+
+func (x Export) markers(beats taps2beats.Beats, everyBeat bool) ([]Marker, error) {
+	g, err := x.grid(beats)
+	if err != nil {
+		return nil, err
+	}
+
+	name := x.Name
+	if name == "" && everyBeat {
+		name = "{bar}.{beat}"
+	} else if name == "" {
+		name = "Bar {bar}"
+	}
+
+	format := func(n, position int) string {
+		bar, beat := g.Bar(position)
+
+		return strings.NewReplacer(
+			"{bar}", fmt.Sprintf("%d", bar),
+			"{beat}", fmt.Sprintf("%d", beat),
+			"{n}", fmt.Sprintf("%d", n)).Replace(name)
+	}
+
+	positions := g.Bars()
+	if everyBeat {
+		positions = g.Positions
+	}
+
+	markers := []Marker{}
+	for i, p := range positions {
+		at := g.At(p)
+		frame, shift := x.frame(at)
+
+		markers = append(markers, Marker{
+			At:    at,
+			Frame: frame,
+			Shift: shift,
+			Name:  format(i+1, p),
+		})
+	}
+
+	if len(markers) == 0 {
+		return nil, fmt.Errorf("no bars")
+	}
+
+	return markers, nil
+}
+
+// Returns the frame nearest to a time and the shift (i.e. the difference between the frame time and the
+// time). Times before 0 are placed on frame 0.
+func (x Export) frame(t time.Duration) (int64, time.Duration) {
+	frame := x.Rate.Frame(t)
+	if frame < 0 {
+		frame = 0
+	}
+
+	return frame, x.Rate.Time(frame) - t
+}
+
+func (x Export) grid(beats taps2beats.Beats) (*taps2beats.Grid, error) {
+	if x.Rate.Num <= 0 || x.Rate.Den <= 0 {
+		return nil, fmt.Errorf("invalid frame rate (%v/%v)", x.Rate.Num, x.Rate.Den)
+	}
+
+	g, err := beats.Grid(x.BeatsPerBar, x.Downbeat)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.Period <= 0 {
+		return nil, fmt.Errorf("insufficient data")
+	}
+
+	return g, nil
+}
+
+// Formats a frame as an FCPXML rational time (e.g. 1001/30000s) reduced to lowest terms.
+func rational(r taps2beats.FrameRate, frames int64) string {
+	num := frames * r.Den
+	den := r.Num
+
+	if num == 0 {
+		return "0s"
+	}
+
+	g := gcd(num, den)
+	num /= g
+	den /= g
+
+	if den == 1 {
+		return fmt.Sprintf("%ds", num)
+	}
+
+	return fmt.Sprintf("%d/%ds", num, den)
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
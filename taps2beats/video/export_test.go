@@ -0,0 +1,193 @@
+package video
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transcriptaze/taps2beats/taps2beats"
+)
+
+func TestMarkers(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(1.02, 1.51, 2.03, 2.50, 3.55, 4.04, 4.52),
+	}
+
+	export := NewExport()
+
+	markers, err := export.Markers(beats)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	// ... bar 2 starts on the missing beat at 3.025s (interpolated)
+	expected := []Marker{
+		{At: 1020 * time.Millisecond, Frame: 26, Shift: 20 * time.Millisecond, Name: "Bar 1"},
+		{At: 3025 * time.Millisecond, Frame: 76, Shift: 15 * time.Millisecond, Name: "Bar 2"},
+	}
+
+	if len(markers) != len(expected) {
+		t.Fatalf("Incorrect number of markers - expected:%v, got:%v", len(expected), len(markers))
+	}
+
+	for i, m := range markers {
+		if m != expected[i] {
+			t.Errorf("Incorrect marker %d - expected:%+v, got:%+v", i+1, expected[i], m)
+		}
+	}
+
+	export.Downbeat = 1
+	export.EveryBeat = true
+	if markers, err = export.Markers(beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	} else if len(markers) != 7 || markers[0].Name != "0.4" || markers[1].Name != "1.1" || markers[4].Name != "2.1" {
+		t.Errorf("Incorrect beat markers - got:%+v", markers)
+	}
+}
+
+func TestWriteEDL(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0, 2.5, 3.0, 3.5, 4.0, 4.5),
+	}
+
+	export := NewExport()
+	export.Rate = taps2beats.FrameRate{Num: 30000, Den: 1001, DropFrame: true}
+
+	var b bytes.Buffer
+	if err := export.WriteEDL(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"TITLE: taps2beats",
+		"FCM: DROP FRAME",
+		"",
+		"001  BL       V     C        00:00:00;00 00:00:04;16 00:00:00;00 00:00:04;16",
+		"* LOC: 00:00:00;15 YELLOW  Bar 1",
+		"* LOC: 00:00:02;15 YELLOW  Bar 2",
+		"* LOC: 00:00:04;15 YELLOW  Bar 3",
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteFFMetadata(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0, 2.5, 3.0, 3.5, 4.0),
+	}
+
+	var b bytes.Buffer
+	if err := NewExport().WriteFFMetadata(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		";FFMETADATA1",
+		"title=taps2beats",
+		"",
+		"[CHAPTER]",
+		"TIMEBASE=1/25",
+		"START=13",
+		"END=63",
+		"title=Bar 1",
+		"",
+		"[CHAPTER]",
+		"TIMEBASE=1/25",
+		"START=63",
+		"END=113",
+		"title=Bar 2",
+	}
+
+	compare(b.String(), expected, t)
+}
+
+func TestWriteFCPXML(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0, 1.5, 2.0, 2.5),
+	}
+
+	export := NewExport()
+	export.Rate = taps2beats.FrameRate{Num: 24000, Den: 1001}
+
+	var b bytes.Buffer
+	if err := export.WriteFCPXML(&b, beats); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	s := b.String()
+	for _, expected := range []string{
+		`<format id="r1" frameDuration="1001/24000s" width="1920" height="1080"></format>`,
+		`<sequence format="r1" duration="3003/1000s" tcStart="0s" tcFormat="NDF">`,
+		`<marker start="1001/2000s" duration="1001/24000s" value="Bar 1"></marker>`,
+		`<marker start="1001/400s" duration="1001/24000s" value="Bar 2"></marker>`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("Missing %v\n%v", expected, s)
+		}
+	}
+}
+
+func TestExportWithInvalidSettings(t *testing.T) {
+	beats := taps2beats.Beats{
+		Beats: beatsAt(0.5, 1.0),
+	}
+
+	if _, err := NewExport().Markers(taps2beats.Beats{}); err == nil {
+		t.Errorf("Expected error for no beats")
+	}
+
+	export := NewExport()
+	export.Rate = taps2beats.FrameRate{}
+	if _, err := export.Markers(beats); err == nil {
+		t.Errorf("Expected error for invalid frame rate")
+	}
+
+	export = NewExport()
+	export.BeatsPerBar = 0
+	if _, err := export.Markers(beats); err == nil {
+		t.Errorf("Expected error for invalid beats per bar")
+	}
+}
+
+func beatsAt(seconds ...float64) []taps2beats.Beat {
+	beats := []taps2beats.Beat{}
+	for _, s := range seconds {
+		beats = append(beats, taps2beats.Beat{At: taps2beats.Seconds(s)})
+	}
+
+	return beats
+}
+
+func compare(s string, expected []string, t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Incorrect number of lines - expected:%v, got:%v\n%v", len(expected), len(lines), s)
+	}
+
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Incorrect line %d\n   expected:%v\n   got:     %v", i+1, expected[i], lines[i])
+		}
+	}
+}
+
+func TestRational(t *testing.T) {
+	tests := []struct {
+		rate     taps2beats.FrameRate
+		frames   int64
+		expected string
+	}{
+		{taps2beats.FrameRate{Num: 25, Den: 1}, 0, "0s"},
+		{taps2beats.FrameRate{Num: 25, Den: 1}, 1, "1/25s"},
+		{taps2beats.FrameRate{Num: 25, Den: 1}, 50, "2s"},
+		{taps2beats.FrameRate{Num: 30000, Den: 1001}, 1, "1001/30000s"},
+		{taps2beats.FrameRate{Num: 30000, Den: 1001}, 30, "1001/1000s"},
+	}
+
+	for _, test := range tests {
+		if s := rational(test.rate, test.frames); s != test.expected {
+			t.Errorf("Incorrect rational time for %v frames - expected:%v, got:%v", test.frames, test.expected, s)
+		}
+	}
+}